@@ -0,0 +1,229 @@
+// Package abuse scores the behaviour of the clients creating pastes to
+// penalize the automated abuse that gets past the rate limits: bursts of
+// creations, the same content posted again and again, requests without a
+// user agent and filled honeypot fields. Scores are kept in memory, so every
+// instance judges the clients it serves.
+package abuse
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Signals raised by suspicious creations
+const (
+	SignalBurst     = "burst"
+	SignalDuplicate = "duplicate"
+	SignalUserAgent = "user_agent"
+	SignalHoneypot  = "honeypot"
+)
+
+// weights are the points every signal adds to the score of a client. Filling
+// the honeypot is enough to be penalized on its own.
+var weights = map[string]int{
+	SignalBurst:     3,
+	SignalDuplicate: 3,
+	SignalUserAgent: 1,
+}
+
+// burstWindow is the period creation bursts are counted over
+const burstWindow = time.Minute
+
+// maxCreations is the number of recent creations remembered per client
+const maxCreations = 100
+
+// cleanupInterval is how often the clients without recent activity are forgotten
+const cleanupInterval = time.Minute
+
+// Config tunes the detection
+type Config struct {
+	// BurstLimit is the number of pastes a client may create per minute
+	BurstLimit int
+	// DuplicateLimit is the number of times a client may create the same
+	// content within Window
+	DuplicateLimit int
+	// Threshold is the score penalizing a client
+	Threshold int
+	// Window is the period signals count towards the score
+	Window time.Duration
+	// BanDuration is how long clients stay penalized
+	BanDuration time.Duration
+}
+
+// Creation is a paste created by a client
+type Creation struct {
+	ContentHash string
+	UserAgent   string
+	// Honeypot is set when the hidden field left empty by people was filled
+	Honeypot bool
+}
+
+// Stats are the counters of the detection since startup
+type Stats struct {
+	Signals          map[string]int64 `json:"signals"`
+	Penalties        int64            `json:"penalties"`
+	PenalizedClients int              `json:"penalized_clients"`
+	BlockedRequests  int64            `json:"blocked_requests"`
+}
+
+type creation struct {
+	at   time.Time
+	hash string
+}
+
+type signal struct {
+	at     time.Time
+	weight int
+}
+
+type client struct {
+	creations      []creation
+	signals        []signal
+	penalizedUntil time.Time
+}
+
+// Detector scores the clients creating pastes
+type Detector struct {
+	config Config
+
+	mu          sync.Mutex
+	clients     map[string]*client
+	stats       Stats
+	lastCleanup time.Time
+}
+
+// New creates a Detector
+func New(config Config) *Detector {
+	return &Detector{
+		config:  config,
+		clients: make(map[string]*client),
+		stats:   Stats{Signals: make(map[string]int64)},
+	}
+}
+
+// Observe records a creation by the client and returns the signals it raised
+// and whether they got the client penalized
+func (d *Detector) Observe(key string, c Creation, now time.Time) ([]string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cleanup(now)
+
+	cl, ok := d.clients[key]
+	if !ok {
+		cl = &client{}
+		d.clients[key] = cl
+	}
+	cl.creations = append(cl.creations, creation{at: now, hash: c.ContentHash})
+	if len(cl.creations) > maxCreations {
+		cl.creations = cl.creations[len(cl.creations)-maxCreations:]
+	}
+
+	var raised []string
+	if c.Honeypot {
+		raised = append(raised, SignalHoneypot)
+	}
+	var burst, duplicates int
+	for _, previous := range cl.creations {
+		if now.Sub(previous.at) < burstWindow {
+			burst++
+		}
+		if c.ContentHash != "" && previous.hash == c.ContentHash && now.Sub(previous.at) < d.config.Window {
+			duplicates++
+		}
+	}
+	if d.config.BurstLimit > 0 && burst > d.config.BurstLimit {
+		raised = append(raised, SignalBurst)
+	}
+	if d.config.DuplicateLimit > 0 && duplicates > d.config.DuplicateLimit {
+		raised = append(raised, SignalDuplicate)
+	}
+	if c.UserAgent == "" {
+		raised = append(raised, SignalUserAgent)
+	}
+
+	for _, name := range raised {
+		d.stats.Signals[name]++
+		weight := weights[name]
+		if name == SignalHoneypot {
+			weight = d.config.Threshold
+		}
+		cl.signals = append(cl.signals, signal{at: now, weight: weight})
+	}
+	cl.signals = recent(cl.signals, now.Add(-d.config.Window))
+
+	score := 0
+	for _, s := range cl.signals {
+		score += s.weight
+	}
+	if len(raised) == 0 || score < d.config.Threshold || now.Before(cl.penalizedUntil) {
+		return raised, false
+	}
+	cl.penalizedUntil = now.Add(d.config.BanDuration)
+	cl.signals = nil
+	d.stats.Penalties++
+	return raised, true
+}
+
+// Penalized reports whether the client is penalized at now
+func (d *Detector) Penalized(key string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cl, ok := d.clients[key]
+	return ok && now.Before(cl.penalizedUntil)
+}
+
+// Blocked counts a request of a penalized client that was shadow banned or
+// tarpitted
+func (d *Detector) Blocked() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stats.BlockedRequests++
+}
+
+// Stats returns the counters of the detection
+func (d *Detector) Stats(now time.Time) Stats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := d.stats
+	stats.Signals = make(map[string]int64, len(d.stats.Signals))
+	for name, count := range d.stats.Signals {
+		stats.Signals[name] = count
+	}
+	for _, cl := range d.clients {
+		if now.Before(cl.penalizedUntil) {
+			stats.PenalizedClients++
+		}
+	}
+	return stats
+}
+
+// cleanup forgets the clients without recent creations that aren't
+// penalized, at most once per cleanupInterval
+func (d *Detector) cleanup(now time.Time) {
+	if now.Sub(d.lastCleanup) < cleanupInterval {
+		return
+	}
+	d.lastCleanup = now
+
+	keep := d.config.Window
+	if keep < burstWindow {
+		keep = burstWindow
+	}
+	for key, cl := range d.clients {
+		last := cl.creations[len(cl.creations)-1].at
+		if now.Sub(last) >= keep && !now.Before(cl.penalizedUntil) {
+			delete(d.clients, key)
+		}
+	}
+}
+
+// recent returns the signals raised after since
+func recent(signals []signal, since time.Time) []signal {
+	i := sort.Search(len(signals), func(i int) bool {
+		return signals[i].at.After(since)
+	})
+	return signals[i:]
+}
@@ -0,0 +1,95 @@
+package abuse_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/abuse"
+)
+
+func newDetector() *abuse.Detector {
+	return abuse.New(abuse.Config{
+		BurstLimit:     3,
+		DuplicateLimit: 2,
+		Threshold:      6,
+		Window:         10 * time.Minute,
+		BanDuration:    time.Hour,
+	})
+}
+
+func TestHoneypot(t *testing.T) {
+	d := newDetector()
+	now := time.Now()
+
+	signals, penalized := d.Observe("a", abuse.Creation{UserAgent: "test", Honeypot: true}, now)
+	if !penalized || len(signals) != 1 || signals[0] != abuse.SignalHoneypot {
+		t.Fatalf("expected the honeypot to penalize, got %v %v", signals, penalized)
+	}
+	if !d.Penalized("a", now.Add(time.Minute)) {
+		t.Error("expected the client penalized")
+	}
+	if d.Penalized("a", now.Add(2*time.Hour)) {
+		t.Error("expected the penalty to end")
+	}
+	if d.Penalized("b", now) {
+		t.Error("expected other clients not penalized")
+	}
+}
+
+func TestBurstAndDuplicates(t *testing.T) {
+	d := newDetector()
+	now := time.Now()
+
+	var penalized bool
+	for i := 0; i < 5 && !penalized; i++ {
+		var signals []string
+		signals, penalized = d.Observe("a", abuse.Creation{ContentHash: "same", UserAgent: "test"}, now.Add(time.Duration(i)*time.Second))
+		if i < 2 && len(signals) > 0 {
+			t.Errorf("creation %d: unexpected signals %v", i, signals)
+		}
+	}
+	if !penalized {
+		t.Fatal("expected bursts of duplicates to penalize")
+	}
+
+	// Spread out creations of different content are fine
+	for i := 0; i < 5; i++ {
+		if signals, _ := d.Observe("b", abuse.Creation{ContentHash: string(rune('a' + i)), UserAgent: "test"}, now.Add(time.Duration(i)*time.Minute)); len(signals) > 0 {
+			t.Errorf("creation %d: unexpected signals %v", i, signals)
+		}
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	d := newDetector()
+	now := time.Now()
+
+	signals, penalized := d.Observe("a", abuse.Creation{}, now)
+	if penalized || len(signals) != 1 || signals[0] != abuse.SignalUserAgent {
+		t.Fatalf("expected a user agent signal without penalty, got %v %v", signals, penalized)
+	}
+	for i := 1; i < 6; i++ {
+		_, penalized = d.Observe("a", abuse.Creation{ContentHash: string(rune('a' + i))}, now.Add(time.Duration(i)*time.Minute))
+	}
+	if !penalized {
+		t.Error("expected repeated requests without a user agent to penalize")
+	}
+}
+
+func TestStats(t *testing.T) {
+	d := newDetector()
+	now := time.Now()
+
+	d.Observe("a", abuse.Creation{Honeypot: true}, now)
+	d.Blocked()
+	stats := d.Stats(now)
+	if stats.Penalties != 1 || stats.PenalizedClients != 1 || stats.BlockedRequests != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if stats.Signals[abuse.SignalHoneypot] != 1 || stats.Signals[abuse.SignalUserAgent] != 1 {
+		t.Errorf("unexpected signals %v", stats.Signals)
+	}
+	if stats := d.Stats(now.Add(2 * time.Hour)); stats.PenalizedClients != 0 {
+		t.Errorf("expected no penalized clients after the ban, got %d", stats.PenalizedClients)
+	}
+}
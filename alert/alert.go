@@ -0,0 +1,127 @@
+// Package alert notifies the moderators and the admin of the events needing
+// their attention, such as reported pastes and scanner hits, on chat
+// channels like Slack, Discord and Matrix.
+package alert
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/coolguy1771/wastebin/audit"
+	"github.com/coolguy1771/wastebin/log"
+	"go.uber.org/zap"
+)
+
+const (
+	// queueSize is the number of alerts waiting to be sent, beyond which
+	// new ones are dropped
+	queueSize = 100
+	// sendTimeout bounds the sending of an alert to a channel
+	sendTimeout = 10 * time.Second
+	// throttleInterval is how often alerts of the same key are sent
+	throttleInterval = 10 * time.Minute
+)
+
+// Alert is an event needing attention
+type Alert struct {
+	// Kind is the audit action of the event, such as paste.report
+	Kind  string
+	Title string
+	Text  string
+	// URL links the subject of the alert, such as the reported paste
+	URL  string
+	Time time.Time
+	// Key throttles the alerts a client can trigger at will, such as quota
+	// hits, to one per key every 10 minutes. Alerts without one are always
+	// sent.
+	Key string
+}
+
+// Channel sends the alerts, such as a Slack webhook
+type Channel interface {
+	Send(ctx context.Context, alert Alert) error
+}
+
+// Notifier sends the alerts to every channel in the background, so that the
+// requests raising them don't wait for the channels
+type Notifier struct {
+	channels []Channel
+	logger   *log.Logger
+	throttle *audit.Throttle
+
+	queue   chan Alert
+	done    chan struct{}
+	stopped chan struct{}
+	stop    sync.Once
+}
+
+// New creates a Notifier sending to channels
+func New(logger *log.Logger, channels ...Channel) *Notifier {
+	return &Notifier{
+		channels: channels,
+		logger:   logger,
+		throttle: audit.NewThrottle(throttleInterval),
+		queue:    make(chan Alert, queueSize),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// Notify queues an alert. It is dropped when the queue is full or its key
+// was alerted recently.
+func (n *Notifier) Notify(alert Alert) {
+	if alert.Time.IsZero() {
+		alert.Time = time.Now()
+	}
+	if alert.Key != "" && !n.throttle.Allow(alert.Kind+"|"+alert.Key, alert.Time) {
+		return
+	}
+	select {
+	case n.queue <- alert:
+	default:
+		n.logger.Warn("Dropped alert, too many queued", zap.String("kind", alert.Kind))
+	}
+}
+
+// Start sends the queued alerts in the background until Stop is called
+func (n *Notifier) Start() {
+	go func() {
+		defer close(n.stopped)
+		for {
+			select {
+			case <-n.done:
+				return
+			case alert := <-n.queue:
+				n.send(alert)
+			}
+		}
+	}()
+}
+
+// Stop sends the alerts still queued and stops the Notifier
+func (n *Notifier) Stop() {
+	n.stop.Do(func() {
+		close(n.done)
+		<-n.stopped
+		for {
+			select {
+			case alert := <-n.queue:
+				n.send(alert)
+			default:
+				return
+			}
+		}
+	})
+}
+
+// send sends an alert to every channel
+func (n *Notifier) send(alert Alert) {
+	for _, channel := range n.channels {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		if err := channel.Send(ctx, alert); err != nil {
+			n.logger.Warn("Error sending alert", zap.String("kind", alert.Kind), zap.Error(err))
+		}
+		cancel()
+	}
+}
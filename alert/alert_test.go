@@ -0,0 +1,137 @@
+package alert_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/alert"
+	"github.com/coolguy1771/wastebin/log"
+)
+
+var reported = alert.Alert{
+	Kind:  "paste.report",
+	Title: "Paste reported <@everyone>",
+	Text:  "Reason: Phishing & spam",
+	URL:   "https://paste.example.com/paste/2b2c0c14-9d0a-4a51-8a5d-6a5a4e1c4f43",
+	Time:  time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+}
+
+// receive serves the request bodies of a channel on body, failing the
+// requests for which check returns false
+func receive(t *testing.T, check func(r *http.Request) bool, body interface{}) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !check(r) || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSlack(t *testing.T) {
+	var body struct {
+		Text string `json:"text"`
+	}
+	server := receive(t, func(r *http.Request) bool { return r.Method == http.MethodPost }, &body)
+	if err := alert.NewSlack(server.URL).Send(context.Background(), reported); err != nil {
+		t.Fatal(err)
+	}
+	want := "*Paste reported &lt;@everyone&gt;*\nReason: Phishing &amp; spam\n<" + reported.URL + "|" + reported.URL + ">"
+	if body.Text != want {
+		t.Errorf("expected %q, got %q", want, body.Text)
+	}
+}
+
+func TestDiscord(t *testing.T) {
+	var body struct {
+		Embeds []struct {
+			Title     string `json:"title"`
+			URL       string `json:"url"`
+			Timestamp string `json:"timestamp"`
+		} `json:"embeds"`
+		AllowedMentions struct {
+			Parse []string `json:"parse"`
+		} `json:"allowed_mentions"`
+	}
+	server := receive(t, func(r *http.Request) bool { return r.Method == http.MethodPost }, &body)
+	if err := alert.NewDiscord(server.URL).Send(context.Background(), reported); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Embeds) != 1 || body.Embeds[0].Title != reported.Title || body.Embeds[0].URL != reported.URL ||
+		body.Embeds[0].Timestamp != "2021-01-01T00:00:00Z" || body.AllowedMentions.Parse == nil || len(body.AllowedMentions.Parse) != 0 {
+		t.Errorf("unexpected Discord message %+v", body)
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	var body map[string]string
+	var paths []string
+	server := receive(t, func(r *http.Request) bool {
+		paths = append(paths, r.URL.EscapedPath())
+		return r.Method == http.MethodPut && r.Header.Get("Authorization") == "Bearer token"
+	}, &body)
+
+	matrix := alert.NewMatrix(server.URL+"/", "!alerts:example.com", "token")
+	for i := 0; i < 2; i++ {
+		if err := matrix.Send(context.Background(), reported); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prefix := "/_matrix/client/v3/rooms/%21alerts:example.com/send/m.room.message/"
+	if len(paths) != 2 || !strings.HasPrefix(paths[0], prefix) || paths[0] == paths[1] {
+		t.Errorf("expected distinct transactions in the room, got %v", paths)
+	}
+	if body["msgtype"] != "m.notice" || !strings.HasPrefix(body["body"], reported.Title+"\n") ||
+		!strings.Contains(body["formatted_body"], "<strong>Paste reported &lt;@everyone&gt;</strong>") {
+		t.Errorf("unexpected Matrix message %v", body)
+	}
+	if err := alert.NewMatrix(server.URL, "!alerts:example.com", "other").Send(context.Background(), reported); err == nil {
+		t.Error("expected the refused message to fail")
+	}
+}
+
+// channel records the alerts it receives
+type channel struct {
+	mu     sync.Mutex
+	fail   bool
+	alerts []alert.Alert
+}
+
+func (c *channel) Send(ctx context.Context, a alert.Alert) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fail {
+		return errors.New("connection refused")
+	}
+	c.alerts = append(c.alerts, a)
+	return nil
+}
+
+func TestNotifier(t *testing.T) {
+	failing, working := &channel{fail: true}, &channel{}
+	notifier := alert.New(log.Default(), failing, working)
+	notifier.Start()
+
+	notifier.Notify(alert.Alert{Kind: "paste.report", Title: "Paste reported"})
+	// Alerts with a key are throttled
+	notifier.Notify(alert.Alert{Kind: "quota.exceeded", Title: "Paste quota exceeded", Key: "203.0.113.1"})
+	notifier.Notify(alert.Alert{Kind: "quota.exceeded", Title: "Paste quota exceeded", Key: "203.0.113.1"})
+	notifier.Notify(alert.Alert{Kind: "quota.exceeded", Title: "Paste quota exceeded", Key: "203.0.113.2"})
+	notifier.Stop()
+
+	// A failing channel doesn't keep the alerts from the others
+	if len(working.alerts) != 3 || working.alerts[0].Kind != "paste.report" || working.alerts[0].Time.IsZero() ||
+		working.alerts[2].Key != "203.0.113.2" {
+		t.Errorf("unexpected alerts %+v", working.alerts)
+	}
+}
@@ -0,0 +1,137 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Slack posts the alerts to a Slack incoming webhook
+type Slack struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlack creates a Slack posting to the incoming webhook url
+func NewSlack(url string) *Slack {
+	return &Slack{url: url, client: &http.Client{}}
+}
+
+// slackEscaper escapes the characters Slack reads as markup
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Send posts an alert
+func (s *Slack) Send(ctx context.Context, alert Alert) error {
+	text := "*" + slackEscaper.Replace(alert.Title) + "*"
+	if alert.Text != "" {
+		text += "\n" + slackEscaper.Replace(alert.Text)
+	}
+	if alert.URL != "" {
+		text += "\n<" + alert.URL + "|" + slackEscaper.Replace(alert.URL) + ">"
+	}
+	return send(ctx, s.client, http.MethodPost, s.url, "", map[string]interface{}{"text": text})
+}
+
+// Discord posts the alerts as embeds to a Discord webhook
+type Discord struct {
+	url    string
+	client *http.Client
+}
+
+// NewDiscord creates a Discord posting to the webhook url
+func NewDiscord(url string) *Discord {
+	return &Discord{url: url, client: &http.Client{}}
+}
+
+// discordColor is the color of the side bar of the embeds, amber
+const discordColor = 0xf0a020
+
+// Send posts an alert
+func (d *Discord) Send(ctx context.Context, alert Alert) error {
+	embed := map[string]interface{}{
+		"title":       alert.Title,
+		"description": alert.Text,
+		"timestamp":   alert.Time.UTC().Format(time.RFC3339),
+		"color":       discordColor,
+		"footer":      map[string]string{"text": alert.Kind},
+	}
+	if alert.URL != "" {
+		embed["url"] = alert.URL
+	}
+	return send(ctx, d.client, http.MethodPost, d.url, "", map[string]interface{}{
+		"embeds": []interface{}{embed},
+		// Reasons and titles written by clients must not ping anyone
+		"allowed_mentions": map[string]interface{}{"parse": []string{}},
+	})
+}
+
+// Matrix sends the alerts as notices to a Matrix room
+type Matrix struct {
+	homeserver string
+	room       string
+	token      string
+	client     *http.Client
+	txn        int64
+}
+
+// NewMatrix creates a Matrix sending to the room with the access token of
+// a user of homeserver who joined it
+func NewMatrix(homeserver, room, token string) *Matrix {
+	return &Matrix{homeserver: strings.TrimSuffix(homeserver, "/"), room: room, token: token, client: &http.Client{}}
+}
+
+// Send sends an alert
+func (m *Matrix) Send(ctx context.Context, alert Alert) error {
+	plain := alert.Title
+	formatted := "<strong>" + html.EscapeString(alert.Title) + "</strong>"
+	if alert.Text != "" {
+		plain += "\n" + alert.Text
+		formatted += "<br>" + html.EscapeString(alert.Text)
+	}
+	if alert.URL != "" {
+		plain += "\n" + alert.URL
+		formatted += `<br><a href="` + html.EscapeString(alert.URL) + `">` + html.EscapeString(alert.URL) + "</a>"
+	}
+	// The transaction ID makes the retries of a request idempotent
+	txn := strconv.FormatInt(alert.Time.UnixNano(), 36) + "." + strconv.FormatInt(atomic.AddInt64(&m.txn, 1), 36)
+	target := m.homeserver + "/_matrix/client/v3/rooms/" + url.PathEscape(m.room) + "/send/m.room.message/" + txn
+	return send(ctx, m.client, http.MethodPut, target, m.token, map[string]string{
+		"msgtype":        "m.notice",
+		"body":           plain,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": formatted,
+	})
+}
+
+// send sends body as JSON to target, with token as a bearer token when set
+func send(ctx context.Context, client *http.Client, method, target, token string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s answered %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
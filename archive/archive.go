@@ -0,0 +1,168 @@
+// Package archive reads and writes the portable JSON lines format pastes are
+// exported to for backups and migrations between databases.
+//
+// The first line of an archive is a Header recording the format version and
+// the instance that created it, every other line is a Record. When the
+// Record format changes Version is incremented and an upgrade from the
+// previous version is added to upgrades, so archives written by older
+// releases are upgraded on the fly while they are read.
+package archive
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+)
+
+// Format identifies wastebin archives
+const Format = "wastebin-archive"
+
+// Version is the version of the archives written by this release
+const Version = 1
+
+// Kinds of records
+const (
+	KindPaste = "paste"
+)
+
+// maxLineSize is the longest line an archive may contain
+const maxLineSize = 64 * 1024 * 1024
+
+// ErrUnsupportedVersion is returned when reading an archive written by a newer release
+var ErrUnsupportedVersion = errors.New("unsupported archive version")
+
+// upgrades convert a record of the version they are keyed by to the next version
+var upgrades = map[int]func(record map[string]interface{}) error{}
+
+// Header describes an archive
+type Header struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Instance  Instance  `json:"instance"`
+}
+
+// Instance describes the wastebin instance an archive was exported from
+type Instance struct {
+	Hostname string `json:"hostname"`
+	Database string `json:"database"`
+}
+
+// Record is a line of an archive
+type Record struct {
+	Kind  string        `json:"kind"`
+	Paste *models.Paste `json:"paste,omitempty"`
+}
+
+// Writer writes an archive
+type Writer struct {
+	encoder *json.Encoder
+}
+
+// NewWriter writes the header of an archive exported from instance
+func NewWriter(w io.Writer, instance Instance) (*Writer, error) {
+	encoder := json.NewEncoder(w)
+	err := encoder.Encode(Header{
+		Format:    Format,
+		Version:   Version,
+		CreatedAt: time.Now().UTC(),
+		Instance:  instance,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{encoder: encoder}, nil
+}
+
+// WritePaste appends a paste to the archive
+func (w *Writer) WritePaste(paste models.Paste) error {
+	return w.encoder.Encode(Record{Kind: KindPaste, Paste: &paste})
+}
+
+// Reader reads an archive, upgrading its records to the current version
+type Reader struct {
+	Header  Header
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewReader reads the header of an archive
+func NewReader(r io.Reader) (*Reader, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
+
+	reader := &Reader{scanner: scanner}
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("empty archive")
+	}
+	reader.line++
+
+	if err := json.Unmarshal(scanner.Bytes(), &reader.Header); err != nil {
+		return nil, fmt.Errorf("invalid archive header: %w", err)
+	}
+	if reader.Header.Format != Format {
+		return nil, fmt.Errorf("not a wastebin archive: format %q", reader.Header.Format)
+	}
+	if reader.Header.Version < 1 || reader.Header.Version > Version {
+		return nil, fmt.Errorf("%w %d, this release supports versions up to %d", ErrUnsupportedVersion, reader.Header.Version, Version)
+	}
+
+	return reader, nil
+}
+
+// Next returns the next record of the archive or io.EOF once all were read
+func (r *Reader) Next() (Record, error) {
+	var record Record
+
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return record, err
+		}
+		return record, io.EOF
+	}
+	r.line++
+
+	data := r.scanner.Bytes()
+	if r.Header.Version < Version {
+		var err error
+		if data, err = upgrade(data, r.Header.Version); err != nil {
+			return record, fmt.Errorf("line %d: %w", r.line, err)
+		}
+	}
+
+	if err := json.Unmarshal(data, &record); err != nil {
+		return record, fmt.Errorf("line %d: %w", r.line, err)
+	}
+	if record.Kind == KindPaste && record.Paste == nil {
+		return record, fmt.Errorf("line %d: paste record without a paste", r.line)
+	}
+	return record, nil
+}
+
+// upgrade applies the upgrades from version to the current version to a record
+func upgrade(data []byte, version int) ([]byte, error) {
+	var record map[string]interface{}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+
+	for ; version < Version; version++ {
+		upgrade, ok := upgrades[version]
+		if !ok {
+			return nil, fmt.Errorf("no upgrade from archive version %d", version)
+		}
+		if err := upgrade(record); err != nil {
+			return nil, fmt.Errorf("upgrading from archive version %d: %w", version, err)
+		}
+	}
+
+	return json.Marshal(record)
+}
@@ -0,0 +1,64 @@
+package archive_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/archive"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/google/uuid"
+)
+
+func TestRoundTrip(t *testing.T) {
+	paste := models.Paste{
+		Content:         "Paste A",
+		Language:        "go",
+		UUID:            uuid.New(),
+		ExpiryTimestamp: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		CreatedAt:       time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	var buf bytes.Buffer
+	writer, err := archive.NewWriter(&buf, archive.Instance{Hostname: "wastebin-0", Database: "sqlite"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WritePaste(paste); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := archive.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader.Header.Version != archive.Version || reader.Header.Instance.Hostname != "wastebin-0" {
+		t.Errorf("unexpected header %+v", reader.Header)
+	}
+
+	record, err := reader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Kind != archive.KindPaste || !reflect.DeepEqual(*record.Paste, paste) {
+		t.Errorf("unexpected record %+v", record)
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF after the last record, got %v", err)
+	}
+}
+
+func TestNewReaderRejectsUnknownArchives(t *testing.T) {
+	_, err := archive.NewReader(strings.NewReader(`{"format":"wastebin-archive","version":999}`))
+	if !errors.Is(err, archive.ErrUnsupportedVersion) {
+		t.Errorf("expected an unsupported version error, got %v", err)
+	}
+
+	if _, err := archive.NewReader(strings.NewReader(`{"format":"something-else","version":1}`)); err == nil {
+		t.Error("expected an error reading an archive of another format")
+	}
+}
@@ -0,0 +1,201 @@
+package audit
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"gorm.io/gorm"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionPasteDelete     = "paste.delete"
+	ActionPasteBurn       = "paste.burn"
+	ActionPasteReject     = "paste.reject"
+	ActionPasteQuarantine = "paste.quarantine"
+	ActionPasteFlag       = "paste.flag"
+	ActionPasteRelease    = "paste.release"
+	ActionPasteReport     = "paste.report"
+	ActionPasteShare      = "paste.share"
+	ActionPasteExtend     = "paste.extend"
+	ActionAuditExport     = "audit.export"
+	ActionConfigReload    = "config.reload"
+
+	ActionRequestBlocked  = "request.blocked"
+	ActionRateLimited     = "request.rate_limited"
+	ActionQuotaExceeded   = "quota.exceeded"
+	ActionAuthFailure     = "auth.failure"
+	ActionClientPenalized = "client.penalized"
+	ActionAdminRequest    = "admin.request"
+)
+
+// Names of the files in an export bundle
+const (
+	eventsFile    = "audit.jsonl"
+	signatureFile = "audit.jsonl.sig"
+)
+
+// exportBatchSize is the number of events read from the database at once
+const exportBatchSize = 500
+
+// ErrInvalidBundle is returned by Verify when a bundle was modified
+var ErrInvalidBundle = errors.New("invalid audit bundle")
+
+// Entry is a line of an exported bundle. Every entry includes the hash of
+// the previous one so removing, reordering or changing entries breaks the chain.
+type Entry struct {
+	Event    models.AuditEvent `json:"event"`
+	PrevHash string            `json:"prev_hash"`
+	Hash     string            `json:"hash"`
+}
+
+// Record stores an event in the audit log
+func Record(db *gorm.DB, action, actor, target string) error {
+	return db.Create(&models.AuditEvent{
+		Timestamp: time.Now().UTC(),
+		Action:    action,
+		Actor:     actor,
+		Target:    target,
+	}).Error
+}
+
+// ParseSigningKey decodes a base64 encoded ed25519 seed or private key
+func ParseSigningKey(s string) (ed25519.PrivateKey, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	switch len(key) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(key), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(key), nil
+	default:
+		return nil, fmt.Errorf("signing key must be %d or %d bytes, got %d", ed25519.SeedSize, ed25519.PrivateKeySize, len(key))
+	}
+}
+
+// hashEntry chains the event to the hash of the previous entry
+func hashEntry(prevHash string, event models.AuditEvent) (string, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(prevHash), data...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Export writes the events recorded between from and to as a zip bundle of
+// hash chained JSON lines and their ed25519 signature
+func Export(w io.Writer, db *gorm.DB, from, to time.Time, key ed25519.PrivateKey) error {
+	var (
+		lines    bytes.Buffer
+		prevHash string
+		batch    []models.AuditEvent
+	)
+
+	encoder := json.NewEncoder(&lines)
+	err := db.Where("timestamp >= ? AND timestamp < ?", from, to).Order("id").FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, event := range batch {
+			hash, err := hashEntry(prevHash, event)
+			if err != nil {
+				return err
+			}
+			if err := encoder.Encode(Entry{Event: event, PrevHash: prevHash, Hash: hash}); err != nil {
+				return err
+			}
+			prevHash = hash
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	signature := ed25519.Sign(key, lines.Bytes())
+
+	archive := zip.NewWriter(w)
+	file, err := archive.Create(eventsFile)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(lines.Bytes()); err != nil {
+		return err
+	}
+	file, err = archive.Create(signatureFile)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(file, base64.StdEncoding.EncodeToString(signature)); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// Verify checks the signature and the hash chain of a bundle created by Export
+// and returns its entries
+func Verify(r io.ReaderAt, size int64, key ed25519.PublicKey) ([]Entry, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := readFile(archive, eventsFile)
+	if err != nil {
+		return nil, err
+	}
+	encodedSignature, err := readFile(archive, signatureFile)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.StdEncoding.DecodeString(string(encodedSignature))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	if !ed25519.Verify(key, lines, signature) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidBundle)
+	}
+
+	var (
+		entries  []Entry
+		prevHash string
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(lines))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+		}
+		hash, err := hashEntry(prevHash, entry.Event)
+		if err != nil {
+			return nil, err
+		}
+		if entry.PrevHash != prevHash || entry.Hash != hash {
+			return nil, fmt.Errorf("%w: broken hash chain at event %d", ErrInvalidBundle, entry.Event.ID)
+		}
+		prevHash = hash
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func readFile(archive *zip.Reader, name string) ([]byte, error) {
+	file, err := archive.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
@@ -0,0 +1,155 @@
+package audit_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/audit"
+	"github.com/coolguy1771/wastebin/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestExportVerify(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.AuditEvent{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range []string{audit.ActionPasteDelete, audit.ActionPasteBurn} {
+		if err := audit.Record(db, action, "203.0.113.7", "paste"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bundle bytes.Buffer
+	if err := audit.Export(&bundle, db, time.Time{}, time.Now().Add(time.Minute), private); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := audit.Verify(bytes.NewReader(bundle.Bytes()), int64(bundle.Len()), public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[1].PrevHash != entries[0].Hash {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	// Changing an event invalidates the signature
+	tampered := rewrite(t, bundle.Bytes(), func(name string, data []byte) []byte {
+		if name == "audit.jsonl" {
+			return []byte(strings.Replace(string(data), audit.ActionPasteDelete, audit.ActionPasteBurn, 1))
+		}
+		return data
+	})
+	if _, err := audit.Verify(bytes.NewReader(tampered), int64(len(tampered)), public); !errors.Is(err, audit.ErrInvalidBundle) {
+		t.Fatalf("expected the tampered bundle to be invalid, got %v", err)
+	}
+}
+
+// rewrite copies a zip archive changing the content of its files with fn
+func rewrite(t *testing.T, bundle []byte, fn func(name string, data []byte) []byte) []byte {
+	reader, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	writer := zip.NewWriter(&out)
+	for _, file := range reader.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := writer.Create(file.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(fn(file.Name, data))
+	}
+	writer.Close()
+	return out.Bytes()
+}
+
+func TestQueryPurge(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:auditquery?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.AuditEvent{}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	for i, event := range []models.AuditEvent{
+		{Timestamp: now.Add(-48 * time.Hour), Action: audit.ActionPasteDelete, Actor: "203.0.113.7"},
+		{Timestamp: now.Add(-time.Hour), Action: audit.ActionAuthFailure, Actor: "203.0.113.7"},
+		{Timestamp: now, Action: audit.ActionPasteDelete, Actor: "198.51.100.1"},
+	} {
+		event.Target = strings.Repeat("x", i)
+		if err := db.Create(&event).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, err := audit.Query(db, audit.Filter{Action: audit.ActionPasteDelete})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Actor != "198.51.100.1" {
+		t.Fatalf("expected the deletions newest first, got %+v", events)
+	}
+
+	events, err = audit.Query(db, audit.Filter{Actor: "203.0.113.7", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Action != audit.ActionAuthFailure {
+		t.Fatalf("unexpected first page %+v", events)
+	}
+	events, err = audit.Query(db, audit.Filter{Actor: "203.0.113.7", Before: events[0].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Action != audit.ActionPasteDelete {
+		t.Fatalf("unexpected second page %+v", events)
+	}
+
+	purged, err := audit.Purge(db, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Fatalf("expected 1 purged event, got %d", purged)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	throttle := audit.NewThrottle(time.Minute)
+	now := time.Now()
+	if !throttle.Allow("a", now) || throttle.Allow("a", now.Add(time.Second)) {
+		t.Error("expected a single event per minute")
+	}
+	if !throttle.Allow("b", now) {
+		t.Error("expected keys to be throttled separately")
+	}
+	if !throttle.Allow("a", now.Add(time.Minute)) {
+		t.Error("expected the event to be allowed again after a minute")
+	}
+}
@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"gorm.io/gorm"
+)
+
+// Limits of the number of events returned by Query
+const (
+	DefaultQueryLimit = 100
+	MaxQueryLimit     = 1000
+)
+
+// maxThrottled bounds the memory used by a Throttle, which forgets every key
+// once it is reached
+const maxThrottled = 10000
+
+// Filter selects the events returned by Query. Empty fields match any event.
+type Filter struct {
+	Action string
+	Actor  string
+	From   time.Time
+	To     time.Time
+	// Before only matches the events older than the event with this ID, to
+	// page through the results
+	Before uint
+	Limit  int
+}
+
+// Query returns the events matching the filter, newest first
+func Query(db *gorm.DB, filter Filter) ([]models.AuditEvent, error) {
+	query := db.Model(&models.AuditEvent{})
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("timestamp >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("timestamp < ?", filter.To)
+	}
+	if filter.Before > 0 {
+		query = query.Where("id < ?", filter.Before)
+	}
+	if filter.Limit <= 0 || filter.Limit > MaxQueryLimit {
+		filter.Limit = DefaultQueryLimit
+	}
+
+	events := []models.AuditEvent{}
+	err := query.Order("id DESC").Limit(filter.Limit).Find(&events).Error
+	return events, err
+}
+
+// Purge deletes the events recorded before the given time and returns how
+// many were deleted
+func Purge(db *gorm.DB, before time.Time) (int64, error) {
+	result := db.Where("timestamp < ?", before).Delete(&models.AuditEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// Throttle limits how often events that clients can trigger at will, such as
+// rate limit hits, are recorded so they can't flood the audit log
+type Throttle struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// NewThrottle creates a Throttle allowing an event per key every interval
+func NewThrottle(interval time.Duration) *Throttle {
+	return &Throttle{interval: interval, last: make(map[string]time.Time)}
+}
+
+// Allow reports whether the event of key should be recorded
+func (t *Throttle) Allow(key string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if last, ok := t.last[key]; ok && now.Sub(last) < t.interval {
+		return false
+	}
+	if len(t.last) >= maxThrottled {
+		t.last = make(map[string]time.Time)
+	}
+	t.last[key] = now
+	return true
+}
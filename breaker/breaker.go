@@ -0,0 +1,251 @@
+// Package breaker stops querying the database once it keeps failing. While
+// the breaker is open the queries fail at once with ErrOpen instead of
+// waiting on an unreachable database, and the database is probed in the
+// background until it answers again.
+package breaker
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ErrOpen is the error of the queries refused while the breaker is open
+var ErrOpen = errors.New("database circuit breaker is open")
+
+// Config sets when the breaker opens and how it probes for recovery
+type Config struct {
+	// Threshold is the number of consecutive failed queries opening the
+	// breaker, 0 disables it
+	Threshold int
+	// Cooldown is the time between the probes of the open breaker
+	Cooldown time.Duration
+	// Probe checks whether the database answers again
+	Probe func(ctx context.Context) error
+}
+
+// State is the state of the breaker
+type State string
+
+const (
+	// Closed lets the queries through
+	Closed State = "closed"
+	// Open refuses the queries until a probe succeeds
+	Open State = "open"
+)
+
+// Stats are the state of the breaker and its counters since startup
+type Stats struct {
+	State State `json:"state"`
+	// OpenedAt is set while the breaker is open
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+	Trips    int64      `json:"trips"`
+	Rejected int64      `json:"rejected"`
+	// Failures is the number of consecutive failed queries
+	Failures int `json:"failures"`
+}
+
+// Breaker counts the failed queries and refuses them once too many failed in
+// a row
+type Breaker struct {
+	config Config
+	logger *log.Logger
+	done   chan struct{}
+	stop   sync.Once
+
+	mu       sync.Mutex
+	open     bool
+	openedAt time.Time
+	failures int
+	trips    int64
+	rejected int64
+}
+
+// New creates a Breaker
+func New(config Config, logger *log.Logger) *Breaker {
+	return &Breaker{config: config, logger: logger, done: make(chan struct{})}
+}
+
+// Register makes the queries of db go through the breaker. It does nothing
+// when the breaker is disabled.
+func (b *Breaker) Register(db *gorm.DB) error {
+	if b.config.Threshold <= 0 {
+		return nil
+	}
+	c := db.Callback()
+	for _, err := range []error{
+		c.Create().Before("*").Register("breaker:before_create", b.before),
+		c.Create().After("*").Register("breaker:after_create", b.after),
+		c.Query().Before("*").Register("breaker:before_query", b.before),
+		c.Query().After("*").Register("breaker:after_query", b.after),
+		c.Update().Before("*").Register("breaker:before_update", b.before),
+		c.Update().After("*").Register("breaker:after_update", b.after),
+		c.Delete().Before("*").Register("breaker:before_delete", b.before),
+		c.Delete().After("*").Register("breaker:after_delete", b.after),
+		c.Row().Before("*").Register("breaker:before_row", b.before),
+		c.Row().After("*").Register("breaker:after_row", b.after),
+		c.Raw().Before("*").Register("breaker:before_raw", b.before),
+		c.Raw().After("*").Register("breaker:after_raw", b.after),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// before refuses the query while the breaker is open, which makes gorm skip
+// running it
+func (b *Breaker) before(db *gorm.DB) {
+	if !b.Allow() {
+		db.AddError(ErrOpen)
+	}
+}
+
+// after records the outcome of the query, unless its context ended: the
+// errors of a query cut short by the request going away or running out of
+// time say nothing about the database
+func (b *Breaker) after(db *gorm.DB) {
+	if ctx := db.Statement.Context; ctx != nil && ctx.Err() != nil {
+		return
+	}
+	if !errors.Is(db.Error, ErrOpen) {
+		b.Record(db.Error)
+	}
+}
+
+// Allow reports whether a query may run, counting the refused ones
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.open {
+		b.rejected++
+	}
+	return !b.open
+}
+
+// Record counts the outcome of a query. Errors of the query itself, such as
+// a missing row, show the database answers and reset the count like
+// successes do.
+func (b *Breaker) Record(err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		// The client went away or ran out of time, which says nothing about
+		// the database
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !failure(err) {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.open || b.failures < b.config.Threshold {
+		return
+	}
+	b.open = true
+	b.openedAt = time.Now()
+	b.trips++
+	b.logger.Error("Database circuit breaker opened", zap.Int("failures", b.failures), zap.Error(err))
+	go b.probe()
+}
+
+// failure reports whether err is a driver or connection error showing the
+// database is unreachable
+func failure(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &netErr)
+}
+
+// probe checks the database every cooldown until it answers and closes the
+// breaker then. The cooldown is jittered so that instances sharing the
+// database don't probe it at once.
+func (b *Breaker) probe() {
+	for {
+		jitter := 0.8 + 0.4*rand.Float64()
+		timer := time.NewTimer(time.Duration(float64(b.config.Cooldown) * jitter))
+		select {
+		case <-b.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), b.config.Cooldown)
+		err := b.config.Probe(ctx)
+		cancel()
+		if err != nil {
+			b.logger.Warn("Database is still unavailable", zap.Error(err))
+			continue
+		}
+
+		b.mu.Lock()
+		b.open = false
+		b.failures = 0
+		downtime := time.Since(b.openedAt)
+		b.mu.Unlock()
+		b.logger.Info("Database circuit breaker closed", zap.Duration("downtime", downtime))
+		return
+	}
+}
+
+// Stop ends the probing of the database
+func (b *Breaker) Stop() {
+	b.stop.Do(func() { close(b.done) })
+}
+
+// IsOpen reports whether the queries are refused
+func (b *Breaker) IsOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open
+}
+
+// Stats returns the state and counters of the breaker
+func (b *Breaker) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := Stats{State: Closed, Trips: b.trips, Rejected: b.rejected, Failures: b.failures}
+	if b.open {
+		openedAt := b.openedAt
+		stats.State = Open
+		stats.OpenedAt = &openedAt
+	}
+	return stats
+}
+
+// Handler answers the requests failing while the breaker is open with 503
+// and a Retry-After header, so that clients back off instead of seeing
+// server errors
+func (b *Breaker) Handler(c *fiber.Ctx) error {
+	if b.config.Threshold <= 0 {
+		return c.Next()
+	}
+	err := c.Next()
+	if !b.IsOpen() || !errors.Is(err, ErrOpen) && c.Response().StatusCode() < fiber.StatusInternalServerError {
+		return err
+	}
+	retryAfter := int(math.Ceil(b.config.Cooldown.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return fiber.NewError(fiber.StatusServiceUnavailable, "Database is unavailable, try again later")
+}
@@ -0,0 +1,155 @@
+package breaker_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/breaker"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestBreaker(t *testing.T) {
+	var healthy atomic.Bool
+	b := breaker.New(breaker.Config{
+		Threshold: 2,
+		Cooldown:  20 * time.Millisecond,
+		Probe: func(ctx context.Context) error {
+			if !healthy.Load() {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+	}, log.Default())
+	defer b.Stop()
+
+	// Errors of the queries themselves reset the count
+	b.Record(driver.ErrBadConn)
+	b.Record(gorm.ErrRecordNotFound)
+	b.Record(driver.ErrBadConn)
+	if !b.Allow() {
+		t.Fatal("expected the breaker to stay closed below the threshold")
+	}
+
+	// Cancellations and deadlines neither count nor reset the count
+	b.Record(context.Canceled)
+	b.Record(context.DeadlineExceeded)
+	if stats := b.Stats(); stats.Failures != 1 {
+		t.Fatalf("expected context errors to be ignored, got %d failures", stats.Failures)
+	}
+
+	b.Record(driver.ErrBadConn)
+	if b.Allow() {
+		t.Fatal("expected the breaker to open after consecutive failures")
+	}
+	stats := b.Stats()
+	if stats.State != breaker.Open || stats.Trips != 1 || stats.Rejected != 1 || stats.OpenedAt == nil {
+		t.Errorf("unexpected stats of the open breaker: %+v", stats)
+	}
+
+	// The breaker stays open while the probes fail
+	time.Sleep(60 * time.Millisecond)
+	if !b.IsOpen() {
+		t.Fatal("expected the breaker to stay open while the database is down")
+	}
+	healthy.Store(true)
+	deadline := time.Now().Add(time.Second)
+	for b.IsOpen() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if b.IsOpen() {
+		t.Fatal("expected the breaker to close once the probe succeeds")
+	}
+	if stats := b.Stats(); stats.State != breaker.Closed || stats.Failures != 0 {
+		t.Errorf("unexpected stats of the closed breaker: %+v", stats)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:breaker?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.Paste{}); err != nil {
+		t.Fatal(err)
+	}
+	b := breaker.New(breaker.Config{
+		Threshold: 1,
+		Cooldown:  time.Hour,
+		Probe:     func(ctx context.Context) error { return nil },
+	}, log.Default())
+	defer b.Stop()
+	if err := b.Register(db); err != nil {
+		t.Fatal(err)
+	}
+
+	var paste models.Paste
+	if err := db.First(&paste).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("expected the query to run, got %v", err)
+	}
+	// Queries of a request past its deadline don't count
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	db.WithContext(ctx).First(&paste)
+	if b.IsOpen() {
+		t.Fatal("expected a query past its deadline not to open the breaker")
+	}
+	b.Record(driver.ErrBadConn)
+	if err := db.First(&paste).Error; !errors.Is(err, breaker.ErrOpen) {
+		t.Fatalf("expected the query to be refused, got %v", err)
+	}
+	if err := db.Exec("DELETE FROM pastes").Error; !errors.Is(err, breaker.ErrOpen) {
+		t.Fatalf("expected the statement to be refused, got %v", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	b := breaker.New(breaker.Config{
+		Threshold: 1,
+		Cooldown:  1500 * time.Millisecond,
+		Probe:     func(ctx context.Context) error { return errors.New("connection refused") },
+	}, log.Default())
+	defer b.Stop()
+	app := fiber.New()
+	app.Use(b.Handler)
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusInternalServerError).JSON(map[string]string{"error": "Error retrieving paste"})
+	})
+	app.Get("/ok", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	get := func(path string) *http.Response {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if resp := get("/fail"); resp.StatusCode != fiber.StatusInternalServerError {
+		t.Errorf("expected the failures to be kept while the breaker is closed, got %d", resp.StatusCode)
+	}
+
+	b.Record(driver.ErrBadConn)
+	resp := get("/fail")
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while the breaker is open, got %d", resp.StatusCode)
+	}
+	if retryAfter := resp.Header.Get(fiber.HeaderRetryAfter); retryAfter != "2" {
+		t.Errorf("expected Retry-After to round the cooldown up, got %q", retryAfter)
+	}
+	if resp := get("/ok"); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("expected the requests not needing the database to succeed, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,136 @@
+// Package captcha verifies the hCaptcha and Cloudflare Turnstile challenges
+// solved by the browsers creating pastes
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Supported providers
+const (
+	ProviderHCaptcha  = "hcaptcha"
+	ProviderTurnstile = "turnstile"
+)
+
+// verifyURLs are the endpoints verifying the solved challenges of every provider
+var verifyURLs = map[string]string{
+	ProviderHCaptcha:  "https://api.hcaptcha.com/siteverify",
+	ProviderTurnstile: "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+}
+
+// responseFields are the form fields the widgets of every provider submit
+// the solved challenge in
+var responseFields = map[string]string{
+	ProviderHCaptcha:  "h-captcha-response",
+	ProviderTurnstile: "cf-turnstile-response",
+}
+
+// verifyTimeout bounds the time spent verifying a challenge
+const verifyTimeout = 10 * time.Second
+
+// ErrMissing is returned when no solved challenge was sent
+var ErrMissing = errors.New("captcha required")
+
+// FailedError is returned when the provider rejected the solved challenge
+type FailedError struct {
+	Codes []string
+}
+
+func (e *FailedError) Error() string {
+	if len(e.Codes) == 0 {
+		return "captcha verification failed"
+	}
+	return "captcha verification failed: " + strings.Join(e.Codes, ", ")
+}
+
+// Verifier checks the solved challenges with a provider
+type Verifier struct {
+	provider  string
+	siteKey   string
+	secretKey string
+	verifyURL string
+	client    *http.Client
+}
+
+// New creates a Verifier for the provider with the keys of the site. The
+// challenges are verified with verifyURL instead of the endpoint of the
+// provider when it is set.
+func New(provider, siteKey, secretKey, verifyURL string) (*Verifier, error) {
+	defaultURL, ok := verifyURLs[provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown captcha provider %q", provider)
+	}
+	if verifyURL == "" {
+		verifyURL = defaultURL
+	}
+	return &Verifier{
+		provider:  provider,
+		siteKey:   siteKey,
+		secretKey: secretKey,
+		verifyURL: verifyURL,
+		client:    &http.Client{Timeout: verifyTimeout},
+	}, nil
+}
+
+// ResponseField returns the form field the widget of the provider submits
+// the solved challenge in
+func ResponseField(provider string) string {
+	return responseFields[provider]
+}
+
+// ResponseField returns the form field the widget submits the solved challenge in
+func (v *Verifier) ResponseField() string {
+	return ResponseField(v.provider)
+}
+
+// verifyResponse is the answer of the siteverify endpoints
+type verifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// Verify checks the solved challenge sent by the client with the IP address.
+// It returns ErrMissing when there is none and a *FailedError when the
+// provider rejected it.
+func (v *Verifier) Verify(ctx context.Context, response, remoteIP string) error {
+	if response == "" {
+		return ErrMissing
+	}
+	form := url.Values{
+		"secret":   {v.secretKey},
+		"response": {response},
+		"sitekey":  {v.siteKey},
+	}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %s", v.provider, resp.Status)
+	}
+	var result verifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid %s response: %w", v.provider, err)
+	}
+	if !result.Success {
+		return &FailedError{Codes: result.ErrorCodes}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package captcha_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coolguy1771/wastebin/captcha"
+)
+
+func TestVerify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("secret") != "secret" || r.FormValue("remoteip") != "203.0.113.7" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		if r.FormValue("response") == "solved" {
+			w.Write([]byte(`{"success": true}`))
+			return
+		}
+		w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+	}))
+	defer server.Close()
+
+	if _, err := captcha.New("recaptcha", "site", "secret", ""); err == nil {
+		t.Error("expected an unknown provider to be rejected")
+	}
+	verifier, err := captcha.New(captcha.ProviderTurnstile, "site", "secret", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verifier.ResponseField() != "cf-turnstile-response" {
+		t.Errorf("unexpected response field %q", verifier.ResponseField())
+	}
+
+	if err := verifier.Verify(context.Background(), "solved", "203.0.113.7"); err != nil {
+		t.Errorf("expected the challenge verified, got %v", err)
+	}
+	if err := verifier.Verify(context.Background(), "", "203.0.113.7"); !errors.Is(err, captcha.ErrMissing) {
+		t.Errorf("expected a missing challenge, got %v", err)
+	}
+	var failed *captcha.FailedError
+	if err := verifier.Verify(context.Background(), "guessed", "203.0.113.7"); !errors.As(err, &failed) || failed.Codes[0] != "invalid-input-response" {
+		t.Errorf("expected the challenge rejected, got %v", err)
+	}
+}
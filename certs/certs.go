@@ -0,0 +1,107 @@
+// Package certs serves a TLS certificate from files that can be replaced
+// while the server runs.
+package certs
+
+import (
+	"crypto/tls"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce is how long the watcher waits for further file events before
+// reloading, the certificate and key are usually replaced one after the other
+const reloadDebounce = 500 * time.Millisecond
+
+// Reloader holds the certificate of a key pair and reloads it from its files
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// NewReloader loads the key pair from certFile and keyFile
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the key pair again. The previous certificate is kept when the
+// files can't be loaded, such as while only one of them was replaced.
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	return nil
+}
+
+// GetCertificate returns the current certificate, for use in tls.Config
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// TLSConfig returns a TLS configuration serving the current certificate
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.GetCertificate,
+	}
+}
+
+// Watch reloads the key pair whenever its files change and calls onReload
+// after every reload. The directories of the files are watched so that
+// replacing them through renames, as Kubernetes does for mounted secrets,
+// is noticed. It blocks until done is closed.
+func (r *Reloader) Watch(done <-chan struct{}, onReload func(), onError func(error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	for _, dir := range []string{filepath.Dir(r.certFile), filepath.Dir(r.keyFile)} {
+		if err := watcher.Add(dir); err != nil {
+			return err
+		}
+	}
+
+	reload := time.NewTimer(reloadDebounce)
+	reload.Stop()
+
+	for {
+		select {
+		case <-done:
+			return nil
+		case _, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			reload.Reset(reloadDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			onError(err)
+		case <-reload.C:
+			if err := r.Reload(); err != nil {
+				onError(err)
+				continue
+			}
+			onReload()
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Package client talks to the API of a remote wastebin instance, for the
+// paste commands of the wastebin binary
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/google/uuid"
+)
+
+// timeout bounds the requests to the instance
+const timeout = time.Minute
+
+// Client sends requests to a wastebin instance
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// New creates a Client of the instance at baseURL. Requests are authenticated
+// with token as a bearer token when it is set.
+func New(baseURL, token string) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("server %q is not an absolute http or https URL", baseURL)
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// APIError is an error answered by the instance
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server answered %d %s", e.Status, http.StatusText(e.Status))
+	}
+	return fmt.Sprintf("server answered %d: %s", e.Status, e.Message)
+}
+
+// Paste is a paste to create
+type Paste struct {
+	Content     string
+	Expires     time.Duration
+	Burn        bool
+	Language    string
+	Title       string
+	Description string
+	Visibility  models.Visibility
+}
+
+// Created is the answer to the creation of a paste
+type Created struct {
+	UUID string `json:"uuid"`
+	URL  string `json:"url"`
+	// OwnerToken deletes the paste, and reads it when it is private
+	OwnerToken string `json:"owner_token,omitempty"`
+	// Warning tells what the content scanners found in the paste
+	Warning string `json:"warning,omitempty"`
+}
+
+// Create creates a paste. The expiry is rounded up to whole minutes and the
+// default of the instance is used when it is 0.
+func (c *Client) Create(ctx context.Context, paste Paste) (Created, error) {
+	form := url.Values{"text": {paste.Content}}
+	if paste.Expires > 0 {
+		minutes := (paste.Expires + time.Minute - 1) / time.Minute
+		form.Set("expires", strconv.FormatInt(int64(minutes), 10))
+	}
+	if paste.Burn {
+		form.Set("burn", "true")
+	}
+	if paste.Language != "" {
+		form.Set("extension", paste.Language)
+	}
+	if paste.Title != "" {
+		form.Set("title", paste.Title)
+	}
+	if paste.Description != "" {
+		form.Set("description", paste.Description)
+	}
+	if paste.Visibility != "" {
+		form.Set("visibility", string(paste.Visibility))
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/v1/paste", strings.NewReader(form.Encode()))
+	if err != nil {
+		return Created{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var created Created
+	return created, c.do(req, &created)
+}
+
+// Get reads a paste by its UUID or the URL of its page. Reading burns burn
+// after reading pastes.
+func (c *Client) Get(ctx context.Context, id string) (models.Paste, error) {
+	var paste models.Paste
+	pasteUUID, err := ParseID(id)
+	if err != nil {
+		return paste, err
+	}
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/v1/paste/"+pasteUUID.String(), nil)
+	if err != nil {
+		return paste, err
+	}
+	if err := c.do(req, &paste); err != nil {
+		return paste, err
+	}
+	// Expired pastes are deleted when read
+	if paste.UUID == uuid.Nil {
+		return paste, &APIError{Status: http.StatusNotFound, Message: "paste expired"}
+	}
+	return paste, nil
+}
+
+// ParseID returns the UUID of a paste from the UUID itself or the URL of its page
+func ParseID(id string) (uuid.UUID, error) {
+	if u, err := url.Parse(id); err == nil && u.Host != "" {
+		id = strings.TrimSuffix(u.Path, "/")
+		id = strings.TrimSuffix(id, "/raw")
+		id = id[strings.LastIndex(id, "/")+1:]
+	}
+	pasteUUID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%q is not a paste UUID or URL", id)
+	}
+	return pasteUUID, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// do sends a request and decodes the JSON answer into v, or returns an
+// *APIError when the instance answered with an error
+func (c *Client) do(req *http.Request, v interface{}) error {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// The answers are wrapped in an envelope with the data or the error
+	var body struct {
+		Data  json.RawMessage `json:"data"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	decodeErr := json.NewDecoder(resp.Body).Decode(&body)
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{Status: resp.StatusCode}
+		if decodeErr == nil && body.Error != nil {
+			apiErr.Message = body.Error.Message
+		}
+		return apiErr
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("invalid server answer: %w", decodeErr)
+	}
+	if err := json.Unmarshal(body.Data, v); err != nil {
+		return fmt.Errorf("invalid server answer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin"
+	"github.com/coolguy1771/wastebin/client"
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestClient(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:client?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := config.Default()
+	wb, err := wastebin.New(&conf, wastebin.Options{DB: db, DisableUI: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	server := httptest.NewServer(wb.Handler())
+	defer server.Close()
+
+	c, err := client.New(server.URL+"/", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	created, err := c.Create(ctx, client.Paste{Content: "package main", Expires: 90 * time.Second, Language: "go", Title: "Main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paste, err := c.Get(ctx, created.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paste.Content != "package main" || paste.Language != "go" || paste.Title != "Main" {
+		t.Errorf("unexpected paste %+v", paste)
+	}
+	if expiry := time.Until(paste.ExpiryTimestamp); expiry < time.Minute || expiry > 2*time.Minute {
+		t.Errorf("expected the expiry rounded up to 2 minutes, got %v", expiry)
+	}
+
+	private, err := c.Create(ctx, client.Paste{Content: "secret", Expires: time.Hour, Visibility: models.VisibilityPrivate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var apiErr *client.APIError
+	if _, err := c.Get(ctx, private.UUID); !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+		t.Errorf("expected a private paste not found without its token, got %v", err)
+	}
+	owner, err := client.New(server.URL, private.OwnerToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paste, err := owner.Get(ctx, private.UUID); err != nil || paste.Content != "secret" {
+		t.Errorf("expected the owner to read the private paste, got %v", err)
+	}
+
+	if _, err := c.Create(ctx, client.Paste{Content: "bad", Expires: time.Hour, Visibility: "hidden"}); !errors.As(err, &apiErr) || apiErr.Status != http.StatusBadRequest || apiErr.Message == "" {
+		t.Errorf("expected the invalid paste refused with a message, got %v", err)
+	}
+	if _, err := c.Get(ctx, uuid.NewString()); !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+		t.Errorf("expected a missing paste not found, got %v", err)
+	}
+}
+
+func TestParseID(t *testing.T) {
+	id := uuid.New()
+	for _, value := range []string{
+		id.String(),
+		"https://paste.example.com/paste/" + id.String(),
+		"https://paste.example.com/paste/" + id.String() + "/raw",
+	} {
+		if parsed, err := client.ParseID(value); err != nil || parsed != id {
+			t.Errorf("%s: expected %s, got %s %v", value, id, parsed, err)
+		}
+	}
+	if _, err := client.ParseID("https://paste.example.com/paste/"); err == nil {
+		t.Error("expected a URL without a UUID to be refused")
+	}
+}
@@ -0,0 +1,114 @@
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// localsKey is the key the resolved client IP is stored under in the request locals
+const localsKey = "client_ip"
+
+// ParseCIDRs parses a comma separated list of CIDRs, plain IP addresses are
+// treated as single host networks
+func ParseCIDRs(s string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", entry)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			entry = fmt.Sprintf("%s/%d", entry, bits)
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// Contains reports whether ip is part of one of the networks
+func Contains(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolver is a fiber middleware determining the IP of the client. The
+// forwarding headers are only honored when the request comes from a trusted
+// proxy, otherwise the address of the connection is used.
+type Resolver struct {
+	trusted []*net.IPNet
+}
+
+// New creates a Resolver trusting the proxies in a comma separated list of CIDRs
+func New(trustedProxies string) (*Resolver, error) {
+	trusted, err := ParseCIDRs(trustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	return &Resolver{trusted: trusted}, nil
+}
+
+// Resolve returns the IP of the client that made the request
+func (r *Resolver) Resolve(c *fiber.Ctx) net.IP {
+	remote := c.Context().RemoteIP()
+	if !Contains(r.trusted, remote) {
+		return remote
+	}
+
+	// Every proxy appends the address it received the request from, so the
+	// client is the rightmost address that isn't a trusted proxy
+	if header := c.Get(fiber.HeaderXForwardedFor); header != "" {
+		hops := strings.Split(header, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				break
+			}
+			remote = ip
+			if !Contains(r.trusted, ip) {
+				break
+			}
+		}
+		return remote
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(c.Get("X-Real-Ip"))); ip != nil {
+		return ip
+	}
+	return remote
+}
+
+// Handler stores the client IP for Get
+func (r *Resolver) Handler(c *fiber.Ctx) error {
+	c.Locals(localsKey, r.Resolve(c).String())
+	return c.Next()
+}
+
+// Get returns the client IP determined by the Resolver middleware, falling
+// back to the address of the connection
+func Get(c *fiber.Ctx) string {
+	if ip, ok := c.Locals(localsKey).(string); ok {
+		return ip
+	}
+	return c.IP()
+}
@@ -0,0 +1,52 @@
+package clientip_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coolguy1771/wastebin/clientip"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestResolve(t *testing.T) {
+	// app.Test connects from 0.0.0.0
+	cases := []struct {
+		name     string
+		trusted  string
+		header   string
+		expected string
+	}{
+		{"untrusted remote ignores the header", "", "203.0.113.7", "0.0.0.0"},
+		{"trusted remote uses the header", "0.0.0.0", "203.0.113.7", "203.0.113.7"},
+		{"spoofed addresses are skipped", "0.0.0.0, 10.0.0.0/8", "198.51.100.1, 203.0.113.7, 10.0.0.2", "203.0.113.7"},
+		{"trusted remote without header", "0.0.0.0", "", "0.0.0.0"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver, err := clientip.New(tc.trusted)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ip string
+			app := fiber.New()
+			app.Use(resolver.Handler)
+			app.Get("/", func(c *fiber.Ctx) error {
+				ip = clientip.Get(c)
+				return nil
+			})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tc.header != "" {
+				req.Header.Set("X-Forwarded-For", tc.header)
+			}
+			if _, err := app.Test(req); err != nil {
+				t.Fatal(err)
+			}
+			if ip != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, ip)
+			}
+		})
+	}
+}
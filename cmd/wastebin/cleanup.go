@@ -0,0 +1,38 @@
+package main
+
+import (
+	"time"
+
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func newCleanupExpiredCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "cleanup-expired",
+		Short: "Delete the pastes that expired and publish the embargoed ones that are due",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, logger, err := connect()
+			if err != nil {
+				return err
+			}
+			defer storage.Close(db)
+
+			now := time.Now()
+			deleted, err := storage.DeleteExpired(db, now)
+			if err != nil {
+				return err
+			}
+			logger.Info("Deleted expired pastes", zap.Int64("deleted", deleted))
+
+			published, err := storage.PublishDue(db, now)
+			if err != nil {
+				return err
+			}
+			logger.Info("Published embargoed pastes", zap.Int64("published", published))
+			return nil
+		},
+	}
+}
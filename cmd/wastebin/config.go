@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/spf13/cobra"
+)
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "validate",
+		Short: "Check that the configuration can be loaded and is valid",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := config.Read()
+			if err != nil {
+				return err
+			}
+			if conf.Profile != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "Configuration is valid (profile %s)\n", conf.Profile)
+				return nil
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Configuration is valid")
+			return nil
+		},
+	})
+	return cmd
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func newExportCmd() *cobra.Command {
+	var format, out string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export every paste to a portable archive",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "jsonl" {
+				return fmt.Errorf("unsupported format %q", format)
+			}
+
+			db, logger, err := connect()
+			if err != nil {
+				return err
+			}
+			defer storage.Close(db)
+
+			w := cmd.OutOrStdout()
+			if out == "-" {
+				// GORM logs slow queries to stdout, keep them out of the archive
+				db = db.Session(&gorm.Session{Logger: gormlogger.Discard})
+			} else {
+				file, err := os.Create(out)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				w = file
+			}
+
+			exported, err := storage.ExportPastes(db, w)
+			if err != nil {
+				return err
+			}
+			logger.Info("Exported pastes", zap.Int("pastes", exported), zap.String("out", out))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "jsonl", "archive format, only jsonl is supported")
+	cmd.Flags().StringVar(&out, "out", "-", "file to write the archive to, - for stdout")
+
+	return cmd
+}
+
+func newImportCmd() *cobra.Command {
+	var (
+		in        string
+		batchSize int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import the pastes of an archive created by export",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if batchSize < 1 {
+				return fmt.Errorf("batch size must be at least 1, got %d", batchSize)
+			}
+
+			db, logger, err := connect()
+			if err != nil {
+				return err
+			}
+			defer storage.Close(db)
+
+			// Importing into a new database is the common case when migrating
+			if err := storage.Migrate(db, logger); err != nil {
+				return err
+			}
+
+			var r io.Reader = cmd.InOrStdin()
+			if in != "-" {
+				file, err := os.Open(in)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				r = file
+			}
+
+			var failed int
+			imported, skipped, err := storage.ImportPastes(db, r, batchSize, func(e storage.BatchError) {
+				failed++
+				logger.Error("Failed to import paste", zap.String("uuid", e.UUID.String()), zap.Error(e.Err))
+			})
+			logger.Info("Imported pastes", zap.Int("pastes", imported), zap.Int("skipped", skipped), zap.Int("failed", failed))
+			if err != nil {
+				return err
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d pastes could not be imported", failed)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&in, "in", "-", "file to read the archive from, - for stdin")
+	cmd.Flags().IntVar(&batchSize, "batch-size", 500, "number of pastes inserted per statement")
+
+	return cmd
+}
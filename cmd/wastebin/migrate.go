@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/spf13/cobra"
+)
+
+func newMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply the database migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, logger, err := connect()
+			if err != nil {
+				return err
+			}
+			defer storage.Close(db)
+
+			return storage.Migrate(db, logger)
+		},
+	}
+
+	var steps int
+	down := &cobra.Command{
+		Use:   "down",
+		Short: "Revert the last database migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if steps < 1 {
+				return fmt.Errorf("steps must be at least 1, got %d", steps)
+			}
+
+			db, logger, err := connect()
+			if err != nil {
+				return err
+			}
+			defer storage.Close(db)
+
+			return storage.MigrateDown(db, logger, steps)
+		},
+	}
+	down.Flags().IntVar(&steps, "steps", 1, "number of migrations to revert")
+
+	version := &cobra.Command{
+		Use:   "version",
+		Short: "Print the version of the database schema",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, _, err := connect()
+			if err != nil {
+				return err
+			}
+			defer storage.Close(db)
+
+			version, err := storage.MigrationVersion(db)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), version)
+			return nil
+		},
+	}
+
+	cmd.AddCommand(down, version)
+	return cmd
+}
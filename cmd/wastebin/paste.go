@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coolguy1771/wastebin/client"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/spf13/cobra"
+)
+
+func newPasteCmd() *cobra.Command {
+	var server, token string
+
+	cmd := &cobra.Command{
+		Use:   "paste",
+		Short: "Create and read pastes on a remote instance",
+	}
+	cmd.PersistentFlags().StringVar(&server, "server", os.Getenv("WASTEBIN_SERVER"), "URL of the instance, instead of WASTEBIN_SERVER")
+	cmd.PersistentFlags().StringVar(&token, "token", os.Getenv("WASTEBIN_TOKEN"), "bearer token of the requests, instead of WASTEBIN_TOKEN")
+	newClient := func() (*client.Client, error) {
+		if server == "" {
+			return nil, fmt.Errorf("set the instance with --server or WASTEBIN_SERVER")
+		}
+		return client.New(server, token)
+	}
+
+	var file, visibility string
+	var paste client.Paste
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create a paste from a file or stdin and print its URL",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newClient()
+			if err != nil {
+				return err
+			}
+
+			r := cmd.InOrStdin()
+			if file != "-" {
+				f, err := os.Open(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				r = f
+				// The extension of the file is the language of its content
+				if paste.Language == "" {
+					paste.Language = strings.TrimPrefix(filepath.Ext(file), ".")
+				}
+			}
+			content, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			paste.Content = string(content)
+			paste.Visibility = models.Visibility(visibility)
+
+			created, err := c.Create(cmd.Context(), paste)
+			if err != nil {
+				return err
+			}
+			// Only the URL goes to stdout so that it can be piped
+			fmt.Fprintln(cmd.OutOrStdout(), created.URL)
+			if created.OwnerToken != "" {
+				fmt.Fprintln(cmd.ErrOrStderr(), "Owner token:", created.OwnerToken)
+			}
+			if created.Warning != "" {
+				fmt.Fprintln(cmd.ErrOrStderr(), "Warning:", created.Warning)
+			}
+			return nil
+		},
+	}
+	create.Flags().StringVar(&file, "file", "-", "file to paste, - for stdin")
+	create.Flags().DurationVar(&paste.Expires, "expires", 0, "time until the paste expires, the default of the instance when unset")
+	create.Flags().BoolVar(&paste.Burn, "burn", false, "delete the paste once it is read")
+	create.Flags().StringVar(&paste.Language, "language", "", "language of the content, the extension of the file when unset")
+	create.Flags().StringVar(&paste.Title, "title", "", "title of the paste")
+	create.Flags().StringVar(&paste.Description, "description", "", "description of the paste")
+	create.Flags().StringVar(&visibility, "visibility", "", "public, unlisted or private")
+
+	get := &cobra.Command{
+		Use:   "get <uuid or url>",
+		Short: "Print the content of a paste",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newClient()
+			if err != nil {
+				return err
+			}
+			paste, err := c.Get(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(cmd.OutOrStdout(), paste.Content)
+			return err
+		},
+	}
+
+	cmd.AddCommand(create, get)
+	return cmd
+}
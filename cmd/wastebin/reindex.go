@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func newReindexCmd() *cobra.Command {
+	var batchSize int
+
+	cmd := &cobra.Command{
+		Use:   "reindex",
+		Short: "Recompute the fields derived from the content of every paste",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if batchSize < 1 {
+				return fmt.Errorf("batch size must be at least 1, got %d", batchSize)
+			}
+
+			db, logger, err := connect()
+			if err != nil {
+				return err
+			}
+			defer storage.Close(db)
+
+			reindexed, err := storage.Reindex(db, batchSize, func(done, total int64) {
+				logger.Info("Reindexing pastes", zap.Int64("done", done), zap.Int64("total", total))
+			})
+			if err != nil {
+				return err
+			}
+			logger.Info("Reindexed pastes", zap.Int64("pastes", reindexed))
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&batchSize, "batch-size", 500, "number of pastes updated per transaction")
+
+	return cmd
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+func newRootCmd() *cobra.Command {
+	serve := newServeCmd()
+
+	var configFile string
+	root := &cobra.Command{
+		Use:          "wastebin",
+		Short:        "A simple pastebin",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		// The flag is passed on through the environment so the config is
+		// reloaded from the same file
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if configFile == "" {
+				return nil
+			}
+			return os.Setenv("WASTEBIN_CONFIG_FILE", configFile)
+		},
+		// Running wastebin without a command starts the server
+		RunE: serve.RunE,
+	}
+	root.PersistentFlags().StringVar(&configFile, "config", "", "YAML, TOML or JSON config file, instead of WASTEBIN_CONFIG_FILE")
+	root.AddCommand(
+		serve,
+		newMigrateCmd(),
+		newCleanupExpiredCmd(),
+		newExportCmd(),
+		newImportCmd(),
+		newReindexCmd(),
+		newConfigCmd(),
+		newPasteCmd(),
+		newVersionCmd(),
+	)
+
+	return root
+}
+
+// setup reads the configuration and creates the default logger writing to
+// out unless another output is configured
+func setup(out io.Writer) (*config.Config, *log.Logger, error) {
+	conf, err := config.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Development logs are easier to read on a console
+	format := conf.LogFormat
+	if format == "" && conf.Dev {
+		format = log.FormatConsole
+	}
+	logger, err := log.Open(out, log.Options{
+		Level:      conf.LogLevel,
+		Format:     format,
+		Output:     conf.LogOutput,
+		File:       conf.LogFile,
+		MaxSize:    conf.LogMaxSize,
+		MaxAge:     conf.LogMaxAge,
+		MaxBackups: conf.LogMaxBackups,
+		SyslogTag:  "wastebin",
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	log.ResetDefault(logger)
+
+	return &conf, logger, nil
+}
+
+// connect reads the configuration and connects to the database for the
+// commands that work on it directly. These log to stderr so that their output
+// can be piped.
+func connect() (*gorm.DB, *log.Logger, error) {
+	conf, logger, err := setup(os.Stderr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := storage.Connect(conf, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, logger, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/server"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func newServeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Start the server",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, logger, err := setup(os.Stdout)
+			if err != nil {
+				return err
+			}
+			defer log.Sync()
+
+			srv, err := server.New(conf, logger)
+			if err != nil {
+				return err
+			}
+
+			// Create a channel to receive OS signals
+			sigChan := make(chan os.Signal, 1)
+
+			// Register the channel to receive SIGINT (Ctrl+C) and SIGTERM signals
+			signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+			// Reload the configuration on SIGHUP
+			hangups := make(chan os.Signal, 1)
+			signal.Notify(hangups, syscall.SIGHUP)
+			go func() {
+				for range hangups {
+					logger.Info("Received SIGHUP, reloading the configuration")
+					srv.Reload()
+				}
+			}()
+
+			// Use a separate goroutine to listen for signals and shutdown the server gracefully
+			go func() {
+				sig := <-sigChan
+				logger.Info("Received signal to shutdown server", zap.String("signal", sig.String()))
+				if err := srv.Shutdown(); err != nil {
+					logger.Error("Error shutting down the server", zap.Error(err))
+				}
+			}()
+
+			// Listen on the user specified port defaulting to 3000
+			return srv.Start()
+		},
+	}
+}
@@ -1,13 +1,19 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/knadh/koanf"
 	"github.com/knadh/koanf/providers/confmap"
 	"github.com/knadh/koanf/providers/env"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 var Conf Config
@@ -31,9 +37,171 @@ type Config struct {
 	DBName         string `koanf:"DB_NAME"`
 	DBMaxIdleConns int    `koanf:"DB_MAX_IDLE_CONNS"`
 	DBMaxOpenConns int    `koanf:"DB_MAX_OPEN_CONNS"`
+	// DBPrepareStatements caches prepared statements for the queries made
+	DBPrepareStatements bool `koanf:"DB_PREPARE_STATEMENTS"`
+	// DBBreakerThreshold consecutive failed queries stop the queries for
+	// DBBreakerCooldown, until the database answers a probe again
+	DBBreakerThreshold int           `koanf:"DB_BREAKER_THRESHOLD"`
+	DBBreakerCooldown  time.Duration `koanf:"DB_BREAKER_COOLDOWN"`
+	// DBQueryTimeout bounds every query of the server, 0 disables it
+	DBQueryTimeout time.Duration `koanf:"DB_QUERY_TIMEOUT"`
+
+	AccessLog     bool   `koanf:"ACCESS_LOG"`
+	LogFormat     string `koanf:"LOG_FORMAT"`
+	LogOutput     string `koanf:"LOG_OUTPUT"`
+	LogFile       string `koanf:"LOG_FILE"`
+	LogMaxSize    int    `koanf:"LOG_MAX_SIZE"`
+	LogMaxAge     int    `koanf:"LOG_MAX_AGE"`
+	LogMaxBackups int    `koanf:"LOG_MAX_BACKUPS"`
+
+	DBPoolStatsInterval time.Duration `koanf:"DB_POOL_STATS_INTERVAL"`
+	DBPoolWaitThreshold time.Duration `koanf:"DB_POOL_WAIT_THRESHOLD"`
+	StatsCacheInterval  time.Duration `koanf:"STATS_CACHE_INTERVAL"`
+	PublicStats         bool          `koanf:"PUBLIC_STATS"`
+
 	WebappPort     string `koanf:"WEBAPP_PORT"`
+	Listen         string `koanf:"LISTEN"`
+	GRPCPort       string `koanf:"GRPC_PORT"`
+	BaseURL        string `koanf:"BASE_URL"`
 	Dev            bool   `koanf:"DEV"`
 	LocalDB        bool   `koanf:"LOCAL_DB"`
+	LogLevel       string `koanf:"LOG_LEVEL"`
+	AllowedOrigins string `koanf:"ALLOWED_ORIGINS"`
+	ConfigDir      string `koanf:"CONFIG_DIR"`
+	ConfigFile     string `koanf:"CONFIG_FILE"`
+	Profile        string `koanf:"PROFILE"`
+	LifecycleToken string `koanf:"LIFECYCLE_TOKEN"`
+	AdminToken     string `koanf:"ADMIN_TOKEN"`
+	// ShutdownTimeout bounds the wait for the requests being handled when
+	// shutting down, 0 waits for all of them
+	ShutdownTimeout time.Duration `koanf:"SHUTDOWN_TIMEOUT"`
+	// APITimeout and UploadTimeout bound the API requests and those creating
+	// pastes, 0 disables the deadline
+	APITimeout    time.Duration `koanf:"API_TIMEOUT"`
+	UploadTimeout time.Duration `koanf:"UPLOAD_TIMEOUT"`
+	// MaxConcurrentRequests bounds the requests handled at once, 0 disables
+	// the limit. Requests over it wait in a queue of RequestQueueDepth for
+	// up to RequestQueueTimeout.
+	MaxConcurrentRequests int           `koanf:"MAX_CONCURRENT_REQUESTS"`
+	RequestQueueDepth     int           `koanf:"REQUEST_QUEUE_DEPTH"`
+	RequestQueueTimeout   time.Duration `koanf:"REQUEST_QUEUE_TIMEOUT"`
+	// PasteCacheSize bounds the bytes of the pastes kept in memory for
+	// PasteCacheTTL after being read, 0 disables the cache
+	PasteCacheSize int           `koanf:"PASTE_CACHE_SIZE"`
+	PasteCacheTTL  time.Duration `koanf:"PASTE_CACHE_TTL"`
+	// EventStreamsPerIP and MaxEventStreams bound the paste event streams
+	// open at once per client and in total, 0 disables the limit
+	EventStreamsPerIP int `koanf:"EVENT_STREAMS_PER_IP"`
+	MaxEventStreams   int `koanf:"MAX_EVENT_STREAMS"`
+
+	SentryDSN         string `koanf:"SENTRY_DSN"`
+	SentryEnvironment string `koanf:"SENTRY_ENVIRONMENT"`
+
+	AuditSigningKey string        `koanf:"AUDIT_SIGNING_KEY"`
+	AuditRetention  time.Duration `koanf:"AUDIT_RETENTION"`
+
+	RateLimitPerMinute int    `koanf:"RATE_LIMIT_PER_MINUTE"`
+	RateLimitBurst     int    `koanf:"RATE_LIMIT_BURST"`
+	RateLimitRoutes    string `koanf:"RATE_LIMIT_ROUTES"`
+
+	TrustedProxies string `koanf:"TRUSTED_PROXIES"`
+	IPAllowlist    string `koanf:"IP_ALLOWLIST"`
+	IPDenylist     string `koanf:"IP_DENYLIST"`
+
+	RobotsFile      string `koanf:"ROBOTS_FILE"`
+	SecurityContact string `koanf:"SECURITY_CONTACT"`
+	SecurityPolicy  string `koanf:"SECURITY_POLICY"`
+
+	MaxPasteSize  int           `koanf:"MAX_PASTE_SIZE"`
+	MaxExpiry     time.Duration `koanf:"MAX_EXPIRY"`
+	DefaultExpiry time.Duration `koanf:"DEFAULT_EXPIRY"`
+	// MaxAttachmentSize caps binary pastes, which are refused when it is 0
+	MaxAttachmentSize int `koanf:"MAX_ATTACHMENT_SIZE"`
+	// PreviewSize is the size above which pastes are read truncated unless
+	// their full content is asked for. 0 never truncates them.
+	PreviewSize int `koanf:"PREVIEW_SIZE"`
+
+	AllowedLanguages string `koanf:"ALLOWED_LANGUAGES"`
+	DetectLanguage   bool   `koanf:"DETECT_LANGUAGE"`
+
+	ScanAction        string        `koanf:"SCAN_ACTION"`
+	ScanBlocklist     string        `koanf:"SCAN_BLOCKLIST"`
+	ScanSecrets       bool          `koanf:"SCAN_SECRETS"`
+	ScanSecretsAction string        `koanf:"SCAN_SECRETS_ACTION"`
+	ScanURL           string        `koanf:"SCAN_URL"`
+	ScanTimeout       time.Duration `koanf:"SCAN_TIMEOUT"`
+
+	// WebhookURL receives the events of the pastes from the outbox, which
+	// keeps them for OutboxRetention
+	WebhookURL         string        `koanf:"WEBHOOK_URL"`
+	WebhookSecret      string        `koanf:"WEBHOOK_SECRET"`
+	WebhookTimeout     time.Duration `koanf:"WEBHOOK_TIMEOUT"`
+	OutboxPollInterval time.Duration `koanf:"OUTBOX_POLL_INTERVAL"`
+	OutboxRetention    time.Duration `koanf:"OUTBOX_RETENTION"`
+	// EventBroker publishes the events to NATS or Kafka as well, at
+	// EventBrokerURL on the EventBrokerTopic subject prefix or topic
+	EventBroker      string `koanf:"EVENT_BROKER"`
+	EventBrokerURL   string `koanf:"EVENT_BROKER_URL"`
+	EventBrokerTopic string `koanf:"EVENT_BROKER_TOPIC"`
+
+	// AlertSlackWebhookURL, AlertDiscordWebhookURL and the Matrix room
+	// receive the alerts of the reported, quarantined and rejected pastes,
+	// the exceeded quotas and the penalized clients
+	AlertSlackWebhookURL   string `koanf:"ALERT_SLACK_WEBHOOK_URL"`
+	AlertDiscordWebhookURL string `koanf:"ALERT_DISCORD_WEBHOOK_URL"`
+	AlertMatrixHomeserver  string `koanf:"ALERT_MATRIX_HOMESERVER"`
+	AlertMatrixRoom        string `koanf:"ALERT_MATRIX_ROOM"`
+	AlertMatrixToken       string `koanf:"ALERT_MATRIX_TOKEN"`
+
+	ReportHideThreshold int `koanf:"REPORT_HIDE_THRESHOLD"`
+	ReportHourlyLimit   int `koanf:"REPORT_HOURLY_LIMIT"`
+
+	AbuseDetection      bool          `koanf:"ABUSE_DETECTION"`
+	AbuseAction         string        `koanf:"ABUSE_ACTION"`
+	AbuseBurstLimit     int           `koanf:"ABUSE_BURST_LIMIT"`
+	AbuseDuplicateLimit int           `koanf:"ABUSE_DUPLICATE_LIMIT"`
+	AbuseThreshold      int           `koanf:"ABUSE_THRESHOLD"`
+	AbuseWindow         time.Duration `koanf:"ABUSE_WINDOW"`
+	AbuseBanDuration    time.Duration `koanf:"ABUSE_BAN_DURATION"`
+	AbuseTarpitDelay    time.Duration `koanf:"ABUSE_TARPIT_DELAY"`
+
+	CaptchaProvider  string `koanf:"CAPTCHA_PROVIDER"`
+	CaptchaSiteKey   string `koanf:"CAPTCHA_SITE_KEY"`
+	CaptchaSecretKey string `koanf:"CAPTCHA_SECRET_KEY"`
+	CaptchaVerifyURL string `koanf:"CAPTCHA_VERIFY_URL"`
+
+	EmbedFrameAncestors string `koanf:"EMBED_FRAME_ANCESTORS"`
+
+	TCPUploadPort    string        `koanf:"TCP_UPLOAD_PORT"`
+	TCPUploadExpiry  time.Duration `koanf:"TCP_UPLOAD_EXPIRY"`
+	TCPUploadTimeout time.Duration `koanf:"TCP_UPLOAD_TIMEOUT"`
+
+	StaticMaxAge         int    `koanf:"STATIC_MAX_AGE"`
+	StaticImmutablePaths string `koanf:"STATIC_IMMUTABLE_PATHS"`
+
+	TLSEnabled  bool   `koanf:"TLS_ENABLED"`
+	TLSCertFile string `koanf:"TLS_CERT_FILE"`
+	TLSKeyFile  string `koanf:"TLS_KEY_FILE"`
+	// HTTPRedirectPort redirects plain HTTP to HTTPS when TLS is enabled,
+	// ACME_HTTP_PORT does it with ACME
+	HTTPRedirectPort string `koanf:"HTTP_REDIRECT_PORT"`
+
+	ACMEEnabled  bool   `koanf:"ACME_ENABLED"`
+	ACMEDomains  string `koanf:"ACME_DOMAINS"`
+	ACMEEmail    string `koanf:"ACME_EMAIL"`
+	ACMECacheDir string `koanf:"ACME_CACHE_DIR"`
+	ACMEHTTPPort string `koanf:"ACME_HTTP_PORT"`
+
+	HTTP2Enabled bool `koanf:"HTTP2_ENABLED"`
+	H2CEnabled   bool `koanf:"H2C_ENABLED"`
+
+	RedisAddr     string `koanf:"REDIS_ADDR"`
+	RedisPassword string `koanf:"REDIS_PASSWORD"`
+
+	QuotaHourlyPastes int64 `koanf:"QUOTA_HOURLY_PASTES"`
+	QuotaHourlyBytes  int64 `koanf:"QUOTA_HOURLY_BYTES"`
+	QuotaDailyPastes  int64 `koanf:"QUOTA_DAILY_PASTES"`
+	QuotaDailyBytes   int64 `koanf:"QUOTA_DAILY_BYTES"`
 }
 
 type App struct {
@@ -56,27 +224,377 @@ type LoggerConfig struct {
 
 type AuthConfig struct{}
 
+// Load reads the configuration from the defaults, the optional config file,
+// the environment and the optional config directory, validates it and stores
+// the result in Conf.
 func Load() *Config {
+	conf, err := Read()
+	if err != nil {
+		log.Fatal("Error loading config", zap.Error(err))
+	}
+	Conf = conf
+
+	return &Conf
+}
+
+// defaults are the values of the settings that are not configured
+var defaults = map[string]interface{}{
+	"WEBAPP_PORT":       "3000",
+	"DB_MAX_IDLE_CONNS": "10",
+	"DB_MAX_OPEN_CONNS": "50",
+	"DB_PORT":           "5432",
+	"DB_HOST":           "localhost",
+	"DB_USER":           "wastebin",
+	"DB_NAME":           "wastebin",
+	"LOG_LEVEL":         "INFO",
+	"LOG_MAX_SIZE":      "100",
+	"ACCESS_LOG":        "true",
+	"LOCAL_DB":          "false",
+	"ALLOWED_ORIGINS":   "*",
+
+	"DB_POOL_STATS_INTERVAL": "1m",
+	"DB_PREPARE_STATEMENTS":  "true",
+	"DB_BREAKER_THRESHOLD":   "5",
+	"DB_BREAKER_COOLDOWN":    "10s",
+	"DB_QUERY_TIMEOUT":       "10s",
+	"STATS_CACHE_INTERVAL":   "5m",
+	"SHUTDOWN_TIMEOUT":       "30s",
+	"API_TIMEOUT":            "5s",
+	"UPLOAD_TIMEOUT":         "30s",
+	"REQUEST_QUEUE_DEPTH":    "100",
+	"REQUEST_QUEUE_TIMEOUT":  "5s",
+	"PASTE_CACHE_SIZE":       "67108864",
+	"PASTE_CACHE_TTL":        "5s",
+	"EVENT_STREAMS_PER_IP":   "10",
+	"MAX_EVENT_STREAMS":      "1000",
+	"DB_POOL_WAIT_THRESHOLD": "100ms",
+
+	"MAX_PASTE_SIZE": "4194304",
+	"MAX_EXPIRY":     "8760h",
+	"PREVIEW_SIZE":   "1048576",
+
+	"SCAN_ACTION":  "reject",
+	"SCAN_TIMEOUT": "5s",
+
+	"WEBHOOK_TIMEOUT":      "10s",
+	"OUTBOX_POLL_INTERVAL": "5s",
+	"OUTBOX_RETENTION":     "168h",
+	"EVENT_BROKER_TOPIC":   "wastebin.events",
+
+	"REPORT_HIDE_THRESHOLD": "3",
+	"REPORT_HOURLY_LIMIT":   "10",
+
+	"ABUSE_ACTION":          "tarpit",
+	"ABUSE_BURST_LIMIT":     "10",
+	"ABUSE_DUPLICATE_LIMIT": "3",
+	"ABUSE_THRESHOLD":       "10",
+	"ABUSE_WINDOW":          "10m",
+	"ABUSE_BAN_DURATION":    "1h",
+	"ABUSE_TARPIT_DELAY":    "10s",
+
+	"RATE_LIMIT_PER_MINUTE": "100",
+	"RATE_LIMIT_BURST":      "100",
+
+	"EMBED_FRAME_ANCESTORS": "*",
+
+	"TCP_UPLOAD_EXPIRY":  "24h",
+	"TCP_UPLOAD_TIMEOUT": "5s",
+
+	"STATIC_MAX_AGE":         "3600",
+	"STATIC_IMMUTABLE_PATHS": "/_app/immutable/,/assets/",
+
+	"ACME_CACHE_DIR": "acme",
+	"ACME_HTTP_PORT": "80",
+}
+
+// Default returns the configuration with every setting at its default value,
+// ignoring the environment
+func Default() Config {
+	var conf Config
+
+	k := koanf.New(".")
+	k.Load(confmap.Provider(defaults, "."), nil)
+	k.Unmarshal("", &conf)
+
+	return conf
+}
+
+// Read reads and validates the configuration like Load without storing it in
+// Conf or exiting on errors
+func Read() (Config, error) {
+	var conf Config
+
 	k := koanf.New(".")
-	k.Load(confmap.Provider(map[string]interface{}{
-		"WEBAPP_PORT":       "3000",
-		"DB_MAX_IDLE_CONNS": "10",
-		"DB_MAX_OPEN_CONNS": "50",
-		"DB_PORT":           "5432",
-		"DB_HOST":           "localhost",
-		"DB_USER":           "wastebin",
-		"DB_NAME":           "wastebin",
-		"LOG_LEVEL":         "INFO",
-		"LOCAL_DB":          "false",
-	}, "."), nil)
-
-	k.Load(env.Provider("WASTEBIN_", ".", func(s string) string {
+	k.Load(confmap.Provider(defaults, "."), nil)
+
+	e := koanf.New(".")
+	e.Load(env.Provider("WASTEBIN_", ".", func(s string) string {
 		return strings.TrimPrefix(s, "WASTEBIN_")
 	}), nil)
+	if err := readSecretFiles(e); err != nil {
+		return conf, err
+	}
 
-	if err := k.Unmarshal("", &Conf); err != nil {
-		log.Fatal("Error loading config", zap.Error(err))
+	// The environment overrides the config file so that a single file can be
+	// shared by every deployment of an environment.
+	if path := e.String("CONFIG_FILE"); path != "" {
+		values, err := readProfile(path, e.String("PROFILE"))
+		if err != nil {
+			return conf, err
+		}
+		k.Load(confmap.Provider(values, "."), nil)
+	} else if profile := e.String("PROFILE"); profile != "" {
+		return conf, fmt.Errorf("profile %q selected without a config file", profile)
 	}
+	k.Merge(e)
 
-	return &Conf
+	// Values from a mounted config directory take precedence over the
+	// environment so that they can be changed without a restart.
+	if dir := k.String("CONFIG_DIR"); dir != "" {
+		values, err := readDir(dir)
+		if err != nil {
+			return conf, err
+		}
+		k.Load(confmap.Provider(values, "."), nil)
+	}
+
+	if err := k.Unmarshal("", &conf); err != nil {
+		return conf, err
+	}
+
+	return conf, conf.Validate()
+}
+
+// AllowedLanguageList returns the languages listed in ALLOWED_LANGUAGES in
+// lower case, none meaning any language is allowed
+func (c Config) AllowedLanguageList() []string {
+	languages := []string{}
+	for _, language := range strings.Split(c.AllowedLanguages, ",") {
+		if language = strings.ToLower(strings.TrimSpace(language)); language != "" {
+			languages = append(languages, language)
+		}
+	}
+	return languages
+}
+
+// ACMEDomainList returns the domains listed in ACME_DOMAINS
+func (c Config) ACMEDomainList() []string {
+	var domains []string
+	for _, domain := range strings.Split(c.ACMEDomains, ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// Validate checks that the settings are usable
+func (c Config) Validate() error {
+	var problems []string
+
+	if port, err := strconv.Atoi(c.WebappPort); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, fmt.Sprintf("WEBAPP_PORT %q is not a valid port", c.WebappPort))
+	}
+	if port, err := strconv.Atoi(c.GRPCPort); c.GRPCPort != "" && (err != nil || port < 1 || port > 65535) {
+		problems = append(problems, fmt.Sprintf("GRPC_PORT %q is not a valid port", c.GRPCPort))
+	}
+	if c.TCPUploadPort != "" {
+		if port, err := strconv.Atoi(c.TCPUploadPort); err != nil || port < 1 || port > 65535 {
+			problems = append(problems, fmt.Sprintf("TCP_UPLOAD_PORT %q is not a valid port", c.TCPUploadPort))
+		}
+		// The connections don't tell which host the client reached
+		if c.BaseURL == "" {
+			problems = append(problems, "BASE_URL must be set to upload pastes over TCP")
+		}
+		if c.TCPUploadExpiry < time.Minute || c.TCPUploadExpiry > c.MaxExpiry {
+			problems = append(problems, "TCP_UPLOAD_EXPIRY must be between 1m and MAX_EXPIRY")
+		}
+		if c.TCPUploadTimeout <= 0 {
+			problems = append(problems, "TCP_UPLOAD_TIMEOUT must be positive")
+		}
+	}
+	if u, err := url.Parse(c.BaseURL); c.BaseURL != "" && (err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" || u.RawQuery != "" || u.Fragment != "") {
+		problems = append(problems, fmt.Sprintf("BASE_URL %q is not an absolute http or https URL", c.BaseURL))
+	}
+	if _, err := c.Listeners(); err != nil {
+		problems = append(problems, fmt.Sprintf("LISTEN is invalid: %v", err))
+	}
+	if c.TLSEnabled && (c.TLSCertFile == "" || c.TLSKeyFile == "") {
+		problems = append(problems, "TLS_CERT_FILE and TLS_KEY_FILE must be set to enable TLS")
+	}
+	if c.TLSEnabled && c.ACMEEnabled {
+		problems = append(problems, "TLS_ENABLED and ACME_ENABLED cannot be used together")
+	}
+	if c.HTTPRedirectPort != "" {
+		if !c.TLSEnabled {
+			problems = append(problems, "HTTP_REDIRECT_PORT needs TLS to be enabled, ACME_HTTP_PORT redirects to HTTPS with ACME")
+		}
+		if port, err := strconv.Atoi(c.HTTPRedirectPort); err != nil || port < 1 || port > 65535 {
+			problems = append(problems, fmt.Sprintf("HTTP_REDIRECT_PORT %q is not a valid port", c.HTTPRedirectPort))
+		}
+	}
+	if c.HTTP2Enabled && !c.TLSEnabled && !c.ACMEEnabled {
+		problems = append(problems, "HTTP2_ENABLED needs TLS or ACME to be enabled, H2C_ENABLED serves HTTP/2 without TLS")
+	}
+	if c.ACMEEnabled {
+		if len(c.ACMEDomainList()) == 0 {
+			problems = append(problems, "ACME_DOMAINS must list the domains to obtain certificates for")
+		}
+		if c.ACMECacheDir == "" {
+			problems = append(problems, "ACME_CACHE_DIR must be set to keep the certificates")
+		}
+		if port, err := strconv.Atoi(c.ACMEHTTPPort); err != nil || port < 1 || port > 65535 {
+			problems = append(problems, fmt.Sprintf("ACME_HTTP_PORT %q is not a valid port", c.ACMEHTTPPort))
+		}
+	}
+	if !c.LocalDB && (c.DBPort < 1 || c.DBPort > 65535) {
+		problems = append(problems, fmt.Sprintf("DB_PORT %d is not a valid port", c.DBPort))
+	}
+	if _, err := zapcore.ParseLevel(c.LogLevel); err != nil {
+		problems = append(problems, fmt.Sprintf("LOG_LEVEL %q is not a valid level", c.LogLevel))
+	}
+	if c.MaxPasteSize < 1 {
+		problems = append(problems, "MAX_PASTE_SIZE must be positive")
+	}
+	if c.MaxExpiry < time.Minute {
+		problems = append(problems, "MAX_EXPIRY must be at least 1m")
+	}
+	if c.DefaultExpiry != 0 && (c.DefaultExpiry < time.Minute || c.DefaultExpiry > c.MaxExpiry) {
+		problems = append(problems, "DEFAULT_EXPIRY must be 0 or between 1m and MAX_EXPIRY")
+	}
+	switch c.ScanAction {
+	case "reject", "quarantine", "flag":
+	default:
+		problems = append(problems, fmt.Sprintf("SCAN_ACTION %q is not reject, quarantine or flag", c.ScanAction))
+	}
+	switch c.ScanSecretsAction {
+	case "", "reject", "quarantine", "flag":
+	default:
+		problems = append(problems, fmt.Sprintf("SCAN_SECRETS_ACTION %q is not reject, quarantine or flag", c.ScanSecretsAction))
+	}
+	if u, err := url.Parse(c.ScanURL); c.ScanURL != "" && (err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "") {
+		problems = append(problems, fmt.Sprintf("SCAN_URL %q is not an absolute http or https URL", c.ScanURL))
+	}
+	if u, err := url.Parse(c.WebhookURL); c.WebhookURL != "" && (err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "") {
+		problems = append(problems, fmt.Sprintf("WEBHOOK_URL %q is not an absolute http or https URL", c.WebhookURL))
+	}
+	if c.WebhookTimeout <= 0 {
+		problems = append(problems, "WEBHOOK_TIMEOUT must be positive")
+	}
+	if c.OutboxPollInterval <= 0 {
+		problems = append(problems, "OUTBOX_POLL_INTERVAL must be positive")
+	}
+	switch c.EventBroker {
+	case "":
+	case "nats", "kafka":
+		if c.EventBrokerURL == "" {
+			problems = append(problems, fmt.Sprintf("EVENT_BROKER_URL is required with EVENT_BROKER %s", c.EventBroker))
+		}
+		if c.EventBrokerTopic == "" {
+			problems = append(problems, fmt.Sprintf("EVENT_BROKER_TOPIC is required with EVENT_BROKER %s", c.EventBroker))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("EVENT_BROKER %q is not nats or kafka", c.EventBroker))
+	}
+	if u, err := url.Parse(c.EventBrokerURL); c.EventBroker == "kafka" && c.EventBrokerURL != "" && (err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "") {
+		problems = append(problems, fmt.Sprintf("EVENT_BROKER_URL %q is not the absolute http or https URL of a Kafka REST Proxy", c.EventBrokerURL))
+	}
+	for key, value := range map[string]string{
+		"ALERT_SLACK_WEBHOOK_URL":   c.AlertSlackWebhookURL,
+		"ALERT_DISCORD_WEBHOOK_URL": c.AlertDiscordWebhookURL,
+		"ALERT_MATRIX_HOMESERVER":   c.AlertMatrixHomeserver,
+	} {
+		if u, err := url.Parse(value); value != "" && (err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "") {
+			problems = append(problems, fmt.Sprintf("%s %q is not an absolute http or https URL", key, value))
+		}
+	}
+	if matrix := c.AlertMatrixHomeserver != "" || c.AlertMatrixRoom != "" || c.AlertMatrixToken != ""; matrix &&
+		(c.AlertMatrixHomeserver == "" || c.AlertMatrixRoom == "" || c.AlertMatrixToken == "") {
+		problems = append(problems, "ALERT_MATRIX_HOMESERVER, ALERT_MATRIX_ROOM and ALERT_MATRIX_TOKEN must be set together")
+	}
+	switch c.AbuseAction {
+	case "shadowban", "tarpit":
+	default:
+		problems = append(problems, fmt.Sprintf("ABUSE_ACTION %q is not shadowban or tarpit", c.AbuseAction))
+	}
+	if c.AbuseDetection && c.AbuseThreshold < 1 {
+		problems = append(problems, "ABUSE_THRESHOLD must be positive")
+	}
+	if strings.ContainsAny(c.EmbedFrameAncestors, ";,\r\n") {
+		problems = append(problems, fmt.Sprintf("EMBED_FRAME_ANCESTORS %q must be space separated sources", c.EmbedFrameAncestors))
+	}
+	switch c.CaptchaProvider {
+	case "":
+	case "hcaptcha", "turnstile":
+		if c.CaptchaSiteKey == "" || c.CaptchaSecretKey == "" {
+			problems = append(problems, "CAPTCHA_SITE_KEY and CAPTCHA_SECRET_KEY must be set to require a captcha")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("CAPTCHA_PROVIDER %q is not hcaptcha or turnstile", c.CaptchaProvider))
+	}
+	switch c.LogFormat {
+	case "", "json", "console":
+	default:
+		problems = append(problems, fmt.Sprintf("LOG_FORMAT %q is not json or console", c.LogFormat))
+	}
+	switch c.LogOutput {
+	case "", "stdout", "stderr", "syslog":
+	case "file":
+		if c.LogFile == "" {
+			problems = append(problems, "LOG_FILE must be set to log to a file")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("LOG_OUTPUT %q is not stdout, stderr, file or syslog", c.LogOutput))
+	}
+
+	for key, value := range map[string]int64{
+		"MAX_ATTACHMENT_SIZE":     int64(c.MaxAttachmentSize),
+		"PREVIEW_SIZE":            int64(c.PreviewSize),
+		"RATE_LIMIT_PER_MINUTE":   int64(c.RateLimitPerMinute),
+		"RATE_LIMIT_BURST":        int64(c.RateLimitBurst),
+		"STATIC_MAX_AGE":          int64(c.StaticMaxAge),
+		"LOG_MAX_SIZE":            int64(c.LogMaxSize),
+		"LOG_MAX_AGE":             int64(c.LogMaxAge),
+		"LOG_MAX_BACKUPS":         int64(c.LogMaxBackups),
+		"DB_POOL_STATS_INTERVAL":  int64(c.DBPoolStatsInterval),
+		"STATS_CACHE_INTERVAL":    int64(c.StatsCacheInterval),
+		"SHUTDOWN_TIMEOUT":        int64(c.ShutdownTimeout),
+		"API_TIMEOUT":             int64(c.APITimeout),
+		"UPLOAD_TIMEOUT":          int64(c.UploadTimeout),
+		"MAX_CONCURRENT_REQUESTS": int64(c.MaxConcurrentRequests),
+		"REQUEST_QUEUE_DEPTH":     int64(c.RequestQueueDepth),
+		"REQUEST_QUEUE_TIMEOUT":   int64(c.RequestQueueTimeout),
+		"PASTE_CACHE_SIZE":        int64(c.PasteCacheSize),
+		"PASTE_CACHE_TTL":         int64(c.PasteCacheTTL),
+		"EVENT_STREAMS_PER_IP":    int64(c.EventStreamsPerIP),
+		"MAX_EVENT_STREAMS":       int64(c.MaxEventStreams),
+		"DB_POOL_WAIT_THRESHOLD":  int64(c.DBPoolWaitThreshold),
+		"DB_BREAKER_THRESHOLD":    int64(c.DBBreakerThreshold),
+		"DB_BREAKER_COOLDOWN":     int64(c.DBBreakerCooldown),
+		"DB_QUERY_TIMEOUT":        int64(c.DBQueryTimeout),
+		"AUDIT_RETENTION":         int64(c.AuditRetention),
+		"SCAN_TIMEOUT":            int64(c.ScanTimeout),
+		"OUTBOX_RETENTION":        int64(c.OutboxRetention),
+		"REPORT_HIDE_THRESHOLD":   int64(c.ReportHideThreshold),
+		"REPORT_HOURLY_LIMIT":     int64(c.ReportHourlyLimit),
+		"ABUSE_BURST_LIMIT":       int64(c.AbuseBurstLimit),
+		"ABUSE_DUPLICATE_LIMIT":   int64(c.AbuseDuplicateLimit),
+		"ABUSE_WINDOW":            int64(c.AbuseWindow),
+		"ABUSE_BAN_DURATION":      int64(c.AbuseBanDuration),
+		"ABUSE_TARPIT_DELAY":      int64(c.AbuseTarpitDelay),
+		"QUOTA_HOURLY_PASTES":     c.QuotaHourlyPastes,
+		"QUOTA_HOURLY_BYTES":      c.QuotaHourlyBytes,
+		"QUOTA_DAILY_PASTES":      c.QuotaDailyPastes,
+		"QUOTA_DAILY_BYTES":       c.QuotaDailyBytes,
+	} {
+		if value < 0 {
+			problems = append(problems, fmt.Sprintf("%s must not be negative", key))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
 }
@@ -1,7 +1,14 @@
 package config_test
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/config"
 )
 
 func TestLoad(t *testing.T) {
@@ -9,3 +16,191 @@ func TestLoad(t *testing.T) {
 	// Check to see if the ENV vars are set
 
 }
+
+func TestLoadConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "LOG_LEVEL"), []byte("DEBUG\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "WASTEBIN_ALLOWED_ORIGINS"), []byte("https://example.com"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("WASTEBIN_CONFIG_DIR", dir)
+	t.Setenv("WASTEBIN_LOG_LEVEL", "WARN")
+
+	conf := config.Load()
+	if conf.LogLevel != "DEBUG" {
+		t.Errorf("expected the config dir to override the log level, got %q", conf.LogLevel)
+	}
+	if conf.AllowedOrigins != "https://example.com" {
+		t.Errorf("expected allowed origins from the config dir, got %q", conf.AllowedOrigins)
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "wastebin.yaml")
+	content := `
+LOG_LEVEL: INFO
+DB_HOST: db.internal
+profiles:
+  staging:
+    DB_HOST: staging.db.internal
+    LOG_LEVEL: DEBUG
+  prod:
+    extends: staging
+    DB_HOST: prod.db.internal
+`
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("WASTEBIN_CONFIG_FILE", file)
+	t.Setenv("WASTEBIN_PROFILE", "prod")
+	t.Setenv("WASTEBIN_DB_NAME", "pastes")
+
+	conf := config.Load()
+	if conf.DBHost != "prod.db.internal" {
+		t.Errorf("expected the profile to override the base settings, got %q", conf.DBHost)
+	}
+	if conf.LogLevel != "DEBUG" {
+		t.Errorf("expected the log level inherited from staging, got %q", conf.LogLevel)
+	}
+	if conf.DBName != "pastes" {
+		t.Errorf("expected the environment to override the config file, got %q", conf.DBName)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	conf := config.Default()
+	if err := conf.Validate(); err != nil {
+		t.Fatalf("expected the defaults to be valid, got %v", err)
+	}
+	if conf.DBPoolStatsInterval != time.Minute {
+		t.Errorf("expected the pool stats every minute by default, got %v", conf.DBPoolStatsInterval)
+	}
+
+	conf.WebappPort = "http"
+	conf.LogLevel = "LOUD"
+	conf.QuotaDailyBytes = -1
+	conf.BaseURL = "paste.example.com"
+	conf.ACMEEnabled = true
+	conf.ACMEDomains = " , "
+	conf.DefaultExpiry = 2 * conf.MaxExpiry
+	conf.ScanSecretsAction = "warn"
+	conf.EmbedFrameAncestors = "https://blog.example; script-src *"
+	conf.TCPUploadPort = "netcat"
+	conf.EventBroker = "kafka"
+	conf.EventBrokerURL = "broker:9092"
+	conf.AlertMatrixRoom = "!alerts:example.com"
+	conf.HTTPRedirectPort = "80"
+	err := conf.Validate()
+	if err == nil {
+		t.Fatal("expected an invalid configuration")
+	}
+	for _, key := range []string{"WEBAPP_PORT", "LOG_LEVEL", "QUOTA_DAILY_BYTES", "BASE_URL", "ACME_DOMAINS", "DEFAULT_EXPIRY", "SCAN_SECRETS_ACTION", "EMBED_FRAME_ANCESTORS", "TCP_UPLOAD_PORT", "EVENT_BROKER_URL", "ALERT_MATRIX_TOKEN", "HTTP_REDIRECT_PORT"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("expected %s to be reported in %q", key, err)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := config.Config{LogLevel: "INFO", AllowedOrigins: "*", DBPort: 5432}
+	new := config.Config{LogLevel: "DEBUG", AllowedOrigins: "*", DBPort: 5433}
+
+	changed := config.Diff(old, new)
+	if !reflect.DeepEqual(changed, []string{"DB_PORT", "LOG_LEVEL"}) {
+		t.Errorf("unexpected changed keys %v", changed)
+	}
+}
+
+func TestListeners(t *testing.T) {
+	conf := config.Default()
+	listeners, err := conf.Listeners()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(listeners, []config.Listener{{Network: "tcp", Address: ":3000"}}) {
+		t.Errorf("expected the webapp port by default, got %v", listeners)
+	}
+
+	conf.TLSEnabled = true
+	conf.Listen = "unix:///run/wastebin.sock, http://:8080,:8443"
+	listeners, err = conf.Listeners()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []config.Listener{
+		{Network: "unix", Address: "/run/wastebin.sock"},
+		{Network: "tcp", Address: ":8080"},
+		{Network: "tcp", Address: ":8443", TLS: true},
+	}
+	if !reflect.DeepEqual(listeners, expected) {
+		t.Errorf("unexpected listeners %v", listeners)
+	}
+
+	conf.TLSEnabled = false
+	conf.Listen = "https://:8443"
+	if _, err := conf.Listeners(); err == nil {
+		t.Error("expected HTTPS without TLS to be rejected")
+	}
+	conf.Listen = "ftp://:21"
+	if _, err := conf.Listeners(); err == nil {
+		t.Error("expected an unknown scheme to be rejected")
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "wastebin.toml")
+	content := `
+LOG_LEVEL = "WARN"
+DB_HOST = "db.internal"
+
+[profiles.prod]
+DB_HOST = "prod.db.internal"
+`
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("WASTEBIN_CONFIG_FILE", file)
+	t.Setenv("WASTEBIN_PROFILE", "prod")
+	t.Setenv("WASTEBIN_LOG_LEVEL", "ERROR")
+
+	conf := config.Load()
+	if conf.DBHost != "prod.db.internal" {
+		t.Errorf("expected the TOML profile to override the base settings, got %q", conf.DBHost)
+	}
+	if conf.LogLevel != "ERROR" {
+		t.Errorf("expected the environment to override the config file, got %q", conf.LogLevel)
+	}
+}
+
+func TestSecretFiles(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db_password")
+	if err := os.WriteFile(file, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("WASTEBIN_DB_PASSWORD_FILE", file)
+	t.Setenv("WASTEBIN_LOG_FILE", "wastebin.log")
+
+	conf, err := config.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.DBPassword != "s3cret" {
+		t.Errorf("expected the password read from the file, got %q", conf.DBPassword)
+	}
+	if conf.LogFile != "wastebin.log" {
+		t.Errorf("expected settings ending in _FILE left alone, got %q", conf.LogFile)
+	}
+
+	t.Setenv("WASTEBIN_DB_PASSWORD", "other")
+	if _, err := config.Read(); err == nil || !strings.Contains(err.Error(), "both DB_PASSWORD and DB_PASSWORD_FILE") {
+		t.Errorf("expected an error when both are set, got %v", err)
+	}
+
+	os.Unsetenv("WASTEBIN_DB_PASSWORD")
+	t.Setenv("WASTEBIN_ADMIN_TOKEN_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := config.Read(); err == nil || !strings.Contains(err.Error(), "ADMIN_TOKEN_FILE") {
+		t.Errorf("expected an error for a missing file, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Listener is an address the HTTP server listens on
+type Listener struct {
+	// Network is tcp or unix
+	Network string
+	Address string
+	// TLS serves HTTPS with the configured certificates
+	TLS bool
+}
+
+// String returns the listener in the form it is configured
+func (l Listener) String() string {
+	switch {
+	case l.Network == "unix":
+		return "unix://" + l.Address
+	case l.TLS:
+		return "https://" + l.Address
+	default:
+		return "http://" + l.Address
+	}
+}
+
+// Listeners returns the addresses of LISTEN, a comma separated list of
+// http://host:port, https://host:port and unix:///path addresses. Addresses
+// without a scheme use HTTPS when TLS or ACME is enabled. Without LISTEN the
+// server listens on WEBAPP_PORT.
+func (c Config) Listeners() ([]Listener, error) {
+	secure := c.TLSEnabled || c.ACMEEnabled
+	if strings.TrimSpace(c.Listen) == "" {
+		return []Listener{{Network: "tcp", Address: ":" + c.WebappPort, TLS: secure}}, nil
+	}
+
+	var listeners []Listener
+	for _, value := range strings.Split(c.Listen, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		scheme, address, found := strings.Cut(value, "://")
+		if !found {
+			scheme, address = "", value
+		}
+		listener := Listener{Network: "tcp", Address: address}
+		switch scheme {
+		case "":
+			listener.TLS = secure
+		case "http":
+		case "https":
+			if !secure {
+				return nil, fmt.Errorf("listener %q needs TLS or ACME to be enabled", value)
+			}
+			listener.TLS = true
+		case "unix":
+			listener.Network = "unix"
+		default:
+			return nil, fmt.Errorf("listener %q has an unknown scheme", value)
+		}
+		if address == "" {
+			return nil, fmt.Errorf("listener %q has no address", value)
+		}
+		listeners = append(listeners, listener)
+	}
+	if len(listeners) == 0 {
+		return nil, fmt.Errorf("LISTEN %q has no address", c.Listen)
+	}
+	return listeners, nil
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/toml"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/file"
+)
+
+// extendsKey names the profile a profile inherits from
+const extendsKey = "extends"
+
+// parser returns the parser of a config file by its extension, TOML for .toml,
+// JSON for .json and YAML otherwise
+func parser(path string) koanf.Parser {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		return toml.Parser()
+	case ".json":
+		return json.Parser()
+	default:
+		return yaml.Parser()
+	}
+}
+
+// readProfile reads a config file and returns its top level settings
+// overridden by the settings of profile and of the profiles it extends.
+func readProfile(path, profile string) (map[string]interface{}, error) {
+	f := koanf.New(".")
+	if err := f.Load(file.Provider(path), parser(path)); err != nil {
+		return nil, fmt.Errorf("reading config file %s: %w", path, err)
+	}
+
+	values := make(map[string]interface{})
+	for key, value := range f.All() {
+		if !strings.HasPrefix(key, "profiles.") {
+			values[key] = value
+		}
+	}
+
+	// Walk up the inheritance chain then apply it from the root down
+	var chain []map[string]interface{}
+	seen := make(map[string]bool)
+	for name := profile; name != ""; name = f.String("profiles." + name + "." + extendsKey) {
+		if seen[name] {
+			return nil, fmt.Errorf("profile %q extends itself", name)
+		}
+		seen[name] = true
+		if !f.Exists("profiles." + name) {
+			return nil, fmt.Errorf("unknown profile %q in config file %s", name, path)
+		}
+		chain = append(chain, f.Cut("profiles."+name).All())
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		for key, value := range chain[i] {
+			if key != extendsKey {
+				values[key] = value
+			}
+		}
+	}
+
+	var unknown []string
+	known := keys()
+	for key := range values {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown settings in config file %s: %s", path, strings.Join(unknown, ", "))
+	}
+
+	return values, nil
+}
+
+// keys returns the names of every setting
+func keys() map[string]bool {
+	known := make(map[string]bool)
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		known[t.Field(i).Tag.Get("koanf")] = true
+	}
+	return known
+}
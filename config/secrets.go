@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/confmap"
+)
+
+// secretFileSuffix marks the variables naming a file to read a setting from
+const secretFileSuffix = "_FILE"
+
+// readSecretFiles replaces every X_FILE variable of e, where X is a setting
+// and X_FILE is not, with X set to the content of the file it names. This
+// lets Docker and Kubernetes secrets be mounted instead of passed in the
+// environment.
+func readSecretFiles(e *koanf.Koanf) error {
+	known := keys()
+	secrets := make(map[string]interface{})
+	var problems []string
+	for _, key := range e.Keys() {
+		setting := strings.TrimSuffix(key, secretFileSuffix)
+		if setting == key || known[key] || !known[setting] {
+			continue
+		}
+		if e.Exists(setting) {
+			problems = append(problems, fmt.Sprintf("both %s and %s are set", setting, key))
+			continue
+		}
+		data, err := os.ReadFile(e.String(key))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		// Files written by editors and echo end with a newline
+		secrets[setting] = strings.TrimRight(string(data), "\r\n")
+		e.Delete(key)
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("reading secret files: %s", strings.Join(problems, "; "))
+	}
+	return e.Load(confmap.Provider(secrets, "."), nil)
+}
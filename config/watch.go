@@ -0,0 +1,123 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce is how long the watcher waits for further file events before
+// reloading, Kubernetes updates a mounted volume with several renames at once.
+const reloadDebounce = 500 * time.Millisecond
+
+// readDir reads a config directory in the layout used by Kubernetes ConfigMap
+// and Secret volumes, where every file name is a key and its content the value.
+func readDir(dir string) (map[string]interface{}, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	for _, entry := range entries {
+		// Skip the ..data and timestamped directories Kubernetes uses for atomic updates
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key := strings.TrimPrefix(entry.Name(), "WASTEBIN_")
+		values[key] = strings.TrimSpace(string(content))
+	}
+
+	return values, nil
+}
+
+// Diff returns the keys of the settings that differ between old and new.
+func Diff(old, new Config) []string {
+	var changed []string
+
+	oldValue := reflect.ValueOf(old)
+	newValue := reflect.ValueOf(new)
+	for i := 0; i < oldValue.NumField(); i++ {
+		if !reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			changed = append(changed, oldValue.Type().Field(i).Tag.Get("koanf"))
+		}
+	}
+
+	return changed
+}
+
+// Watch reloads the configuration whenever the config directory of conf
+// changes or a value is received from reload, and calls onChange with the
+// previous and the reloaded configuration when a setting changed. It blocks
+// until done is closed.
+func Watch(conf Config, done <-chan struct{}, reload <-chan struct{}, onChange func(old, new Config, changed []string), onError func(error)) error {
+	// Without a config directory the channels stay nil and only reload
+	// triggers a reload
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if conf.ConfigDir != "" {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return err
+		}
+		defer watcher.Close()
+
+		if err := watcher.Add(conf.ConfigDir); err != nil {
+			return err
+		}
+		events, errs = watcher.Events, watcher.Errors
+	}
+
+	current := conf
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+
+	for {
+		select {
+		case <-done:
+			return nil
+		case _, ok := <-events:
+			if !ok {
+				return nil
+			}
+			debounce.Reset(reloadDebounce)
+			continue
+		case err, ok := <-errs:
+			if !ok {
+				return nil
+			}
+			onError(err)
+			continue
+		case <-reload:
+		case <-debounce.C:
+		}
+
+		reloaded, err := Read()
+		if err != nil {
+			onError(err)
+			continue
+		}
+		if changed := Diff(current, reloaded); len(changed) > 0 {
+			onChange(current, reloaded, changed)
+			current = reloaded
+		}
+	}
+}
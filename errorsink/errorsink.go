@@ -0,0 +1,85 @@
+// Package errorsink reports errors, panics and failed requests to an error
+// tracker such as Sentry
+package errorsink
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Levels of the reported events
+const (
+	LevelError = "error"
+	LevelFatal = "fatal"
+)
+
+// Event is an error reported to a Sink
+type Event struct {
+	Timestamp time.Time
+	Level     string
+	Message   string
+	// Stack is the stack trace of a panic
+	Stack string
+	// Request is the request that failed, nil for errors outside of requests
+	Request *Request
+	// Extra holds the fields of logged errors
+	Extra map[string]interface{}
+}
+
+// Request is the context of a failed request
+type Request struct {
+	Method   string
+	URL      string
+	Status   int
+	ClientIP string
+	// RequestID is the X-Request-ID of the request
+	RequestID string
+	// TraceID identifies the request across services, taken from its
+	// traceparent header
+	TraceID string
+}
+
+// Sink receives the reported events. Capture must not block.
+type Sink interface {
+	Capture(event Event)
+	// Close sends the events still queued, waiting at most timeout
+	Close(timeout time.Duration)
+}
+
+// Reported marks a logged error that was already reported with more context,
+// so that LogHook skips it. It is not written to the log.
+var Reported = zapcore.Field{Key: "errorsink.reported", Type: zapcore.SkipType}
+
+// LogHook returns a hook for log.Logger.WithErrorHook reporting the logged
+// errors to sink
+func LogHook(sink Sink) func(entry zapcore.Entry, fields []zapcore.Field) {
+	return func(entry zapcore.Entry, fields []zapcore.Field) {
+		for _, field := range fields {
+			if field.Equals(Reported) {
+				return
+			}
+		}
+		sink.Capture(logEvent(entry, fields))
+	}
+}
+
+// logEvent converts a logged entry and its fields to an Event
+func logEvent(entry zapcore.Entry, fields []zapcore.Field) Event {
+	level := LevelError
+	if entry.Level > zapcore.ErrorLevel {
+		level = LevelFatal
+	}
+
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	return Event{
+		Timestamp: entry.Time,
+		Level:     level,
+		Message:   entry.Message,
+		Stack:     entry.Stack,
+		Extra:     encoder.Fields,
+	}
+}
@@ -0,0 +1,111 @@
+package errorsink_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/errorsink"
+	"github.com/coolguy1771/wastebin/log"
+	"go.uber.org/zap"
+)
+
+type recordingSink struct {
+	mu     sync.Mutex
+	events []errorsink.Event
+}
+
+func (s *recordingSink) Capture(event errorsink.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+func (s *recordingSink) Close(time.Duration) {}
+
+func TestLogHook(t *testing.T) {
+	var output strings.Builder
+	logger, err := log.New(&output, "INFO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &recordingSink{}
+	logger = logger.WithErrorHook(errorsink.LogHook(sink))
+
+	logger.Info("Starting")
+	logger.Warn("Slow query")
+	logger.Error("Error reading paste", zap.String("uuid", "1234"))
+	logger.Error("Recovered from panic", errorsink.Reported)
+
+	if len(sink.events) != 1 {
+		t.Fatalf("expected 1 event, got %+v", sink.events)
+	}
+	event := sink.events[0]
+	if event.Message != "Error reading paste" || event.Level != errorsink.LevelError || event.Extra["uuid"] != "1234" {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if lines := strings.Count(output.String(), "\n"); lines != 4 || strings.Contains(output.String(), "errorsink") {
+		t.Errorf("expected every entry logged without the reported marker, got %s", output.String())
+	}
+}
+
+func TestSentry(t *testing.T) {
+	type request struct {
+		path string
+		auth string
+		body map[string]interface{}
+	}
+	requests := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Error(err)
+		}
+		requests <- request{path: r.URL.Path, auth: r.Header.Get("X-Sentry-Auth"), body: body}
+	}))
+	defer server.Close()
+
+	dsn := strings.Replace(server.URL, "://", "://public@", 1) + "/sentry/42"
+	sentry, err := errorsink.NewSentry(dsn, "staging", log.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sentry.Capture(errorsink.Event{
+		Timestamp: time.Now(),
+		Level:     errorsink.LevelError,
+		Message:   "panic: boom",
+		Stack:     "goroutine 1",
+		Request: &errorsink.Request{
+			Method:   "GET",
+			URL:      "http://localhost/api/v1/paste/1234",
+			Status:   500,
+			ClientIP: "203.0.113.7",
+			TraceID:  "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+	})
+	sentry.Close(5 * time.Second)
+
+	received := <-requests
+	if received.path != "/sentry/api/42/store/" || !strings.Contains(received.auth, "sentry_key=public") {
+		t.Errorf("unexpected request to %s with auth %q", received.path, received.auth)
+	}
+	body := received.body
+	tags, _ := body["tags"].(map[string]interface{})
+	extra, _ := body["extra"].(map[string]interface{})
+	if body["message"] != "panic: boom" || body["environment"] != "staging" ||
+		tags["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || tags["status_code"] != "500" || extra["stack"] != "goroutine 1" {
+		t.Errorf("unexpected event %v", body)
+	}
+
+	for _, dsn := range []string{"", "ftp://key@host/1", "https://host/1", "https://key@host/"} {
+		if _, err := errorsink.NewSentry(dsn, "", log.Default()); err == nil {
+			t.Errorf("expected an error for DSN %q", dsn)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package errorsink
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coolguy1771/wastebin/log"
+	"go.uber.org/zap"
+)
+
+// sentryQueueSize is the number of events waiting to be sent, more are dropped
+const sentryQueueSize = 100
+
+// sentryTimeout bounds the time spent sending one event
+const sentryTimeout = 10 * time.Second
+
+// Sentry sends the events to the store endpoint of a Sentry project
+type Sentry struct {
+	endpoint    string
+	auth        string
+	environment string
+	serverName  string
+	client      *http.Client
+	logger      *log.Logger
+
+	queue     chan Event
+	closing   chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSentry sends the events to the project of the DSN, tagged with the
+// environment when it is set. Failures to send are logged as warnings.
+func NewSentry(dsn, environment string, logger *log.Logger) (*Sentry, error) {
+	endpoint, key, err := parseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	serverName, _ := os.Hostname()
+
+	s := &Sentry{
+		endpoint:    endpoint,
+		auth:        fmt.Sprintf("Sentry sentry_version=7, sentry_client=wastebin/1.0, sentry_key=%s", key),
+		environment: environment,
+		serverName:  serverName,
+		client:      &http.Client{Timeout: sentryTimeout},
+		logger:      logger,
+		queue:       make(chan Event, sentryQueueSize),
+		closing:     make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// parseDSN returns the store endpoint and public key of a Sentry DSN, which
+// looks like https://key@host/project
+func parseDSN(dsn string) (string, string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid Sentry DSN: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" || u.User == nil || u.User.Username() == "" {
+		return "", "", errors.New("invalid Sentry DSN: expected scheme://key@host/project")
+	}
+	prefix, project := path.Split(strings.TrimSuffix(u.Path, "/"))
+	if project == "" {
+		return "", "", errors.New("invalid Sentry DSN: missing the project ID")
+	}
+	endpoint := url.URL{Scheme: u.Scheme, Host: u.Host, Path: path.Join(prefix, "api", project, "store") + "/"}
+	return endpoint.String(), u.User.Username(), nil
+}
+
+// Capture queues the event, dropping it when the queue is full. Events
+// captured after Close are not sent.
+func (s *Sentry) Capture(event Event) {
+	select {
+	case s.queue <- event:
+	default:
+	}
+}
+
+// Close sends the queued events, waiting at most timeout
+func (s *Sentry) Close(timeout time.Duration) {
+	s.closeOnce.Do(func() { close(s.closing) })
+	select {
+	case <-s.done:
+	case <-time.After(timeout):
+	}
+}
+
+// run sends the queued events until the sink is closed and the queue empty
+func (s *Sentry) run() {
+	defer close(s.done)
+	for {
+		select {
+		case event := <-s.queue:
+			s.sendLogged(event)
+		case <-s.closing:
+			for {
+				select {
+				case event := <-s.queue:
+					s.sendLogged(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// sendLogged sends the event, logging the failure
+func (s *Sentry) sendLogged(event Event) {
+	if err := s.send(event); err != nil {
+		s.logger.Warn("Error sending event to Sentry", zap.Error(err))
+	}
+}
+
+// sentryEvent is the payload of the store endpoint
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Platform    string                 `json:"platform"`
+	Logger      string                 `json:"logger"`
+	Message     string                 `json:"message"`
+	Environment string                 `json:"environment,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Request     *sentryRequest         `json:"request,omitempty"`
+	User        map[string]string      `json:"user,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+}
+
+type sentryRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// send posts one event to Sentry
+func (s *Sentry) send(event Event) error {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	payload := sentryEvent{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   event.Timestamp.UTC().Format(time.RFC3339Nano),
+		Level:       event.Level,
+		Platform:    "go",
+		Logger:      "wastebin",
+		Message:     event.Message,
+		Environment: s.environment,
+		ServerName:  s.serverName,
+		Tags:        map[string]string{},
+		Extra:       event.Extra,
+	}
+	if event.Stack != "" {
+		if payload.Extra == nil {
+			payload.Extra = map[string]interface{}{}
+		}
+		payload.Extra["stack"] = event.Stack
+	}
+	if r := event.Request; r != nil {
+		payload.Request = &sentryRequest{Method: r.Method, URL: r.URL}
+		payload.User = map[string]string{"ip_address": r.ClientIP}
+		payload.Tags["status_code"] = fmt.Sprint(r.Status)
+		if r.RequestID != "" {
+			payload.Tags["request_id"] = r.RequestID
+		}
+		if r.TraceID != "" {
+			payload.Tags["trace_id"] = r.TraceID
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sentryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,393 @@
+// Package grpcapi serves the paste API over gRPC as described by
+// proto/wastebin/v1/paste.proto. It shares the storage, limits and quotas of
+// the HTTP API so pastes created through one are served by the other.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/coolguy1771/wastebin/audit"
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/handlers"
+	"github.com/coolguy1771/wastebin/language"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/models"
+	wastebinv1 "github.com/coolguy1771/wastebin/proto/wastebin/v1"
+	"github.com/coolguy1771/wastebin/quota"
+	"github.com/coolguy1771/wastebin/scan"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// Service implements the PasteService
+type Service struct {
+	wastebinv1.UnimplementedPasteServiceServer
+
+	config  *config.Config
+	logger  *log.Logger
+	db      *gorm.DB
+	quota   *quota.Quota
+	scanner scan.Scanner
+}
+
+// NewServer creates a gRPC server serving the PasteService, counting the
+// pastes towards quotas. The content of new pastes is checked with scanner
+// unless it is nil.
+func NewServer(conf *config.Config, logger *log.Logger, db *gorm.DB, quotas *quota.Quota, scanner scan.Scanner) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		logCalls(logger),
+		recoverPanics(logger),
+	))
+	wastebinv1.RegisterPasteServiceServer(server, &Service{
+		config:  conf,
+		logger:  logger,
+		db:      db,
+		quota:   quotas,
+		scanner: scanner,
+	})
+	return server
+}
+
+// CreatePaste stores a new paste
+func (s *Service) CreatePaste(ctx context.Context, req *wastebinv1.CreatePasteRequest) (*wastebinv1.CreatePasteResponse, error) {
+	var problems []string
+	limits := handlers.Limits(s.config)
+	if req.ExpiresMinutes == 0 {
+		req.ExpiresMinutes = limits.DefaultExpiryMinutes
+	}
+	if req.Content == "" {
+		problems = append(problems, "Content cannot be empty")
+	} else if len(req.Content) > limits.MaxSize {
+		problems = append(problems, fmt.Sprintf("Content cannot be larger than %d bytes", limits.MaxSize))
+	}
+	if req.ExpiresMinutes < limits.MinExpiryMinutes || req.ExpiresMinutes > limits.MaxExpiryMinutes {
+		problems = append(problems, fmt.Sprintf("Expiry must be between %d and %d minutes", limits.MinExpiryMinutes, limits.MaxExpiryMinutes))
+	}
+	if len(req.Language) > handlers.MaxLanguageLength {
+		problems = append(problems, fmt.Sprintf("Language cannot be longer than %d characters", handlers.MaxLanguageLength))
+	} else if !limits.AllowsLanguage(req.Language) {
+		problems = append(problems, fmt.Sprintf("Language %q is not allowed", req.Language))
+	} else if req.Language == "" && s.config.DetectLanguage && req.Content != "" {
+		if detected := language.Detect(req.Content); limits.AllowsLanguage(detected) {
+			req.Language = detected
+		}
+	}
+	if len(req.Title) > handlers.MaxTitleLength {
+		problems = append(problems, fmt.Sprintf("Title cannot be longer than %d characters", handlers.MaxTitleLength))
+	}
+	if len(req.Description) > handlers.MaxDescriptionLength {
+		problems = append(problems, fmt.Sprintf("Description cannot be longer than %d characters", handlers.MaxDescriptionLength))
+	}
+	tags, err := models.NormalizeTags(req.Tags)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	visibility, ok := visibilityFromProto(req.Visibility)
+	if !ok {
+		problems = append(problems, "Visibility must be public, unlisted or private")
+	}
+	id := uuid.New()
+	if req.Id != "" {
+		if id, err = uuid.Parse(req.Id); err != nil {
+			problems = append(problems, "Invalid UUID")
+		}
+	}
+	if len(problems) > 0 {
+		return nil, status.Error(codes.InvalidArgument, strings.Join(problems, "; "))
+	}
+
+	if req.Id != "" {
+		var existing int64
+		if err := s.db.Model(&models.Paste{}).Where("uuid = ?", id).Count(&existing).Error; err != nil {
+			return nil, err
+		}
+		if existing > 0 {
+			return nil, status.Error(codes.AlreadyExists, "Paste already exists")
+		}
+	}
+
+	// Check the content for abuse like the HTTP API does
+	var findings []scan.Finding
+	if s.scanner != nil {
+		if findings, err = s.scanner.Scan(ctx, req.Content); err != nil {
+			s.logger.Error("Error scanning paste content", zap.Error(err))
+		}
+	}
+	action := scan.Strictest(findings)
+	if action == scan.ActionReject {
+		reasons := scan.Reasons(findings)
+		s.recordAudit(ctx, audit.ActionPasteReject, reasons)
+		return nil, status.Error(codes.InvalidArgument, "Content rejected: "+reasons)
+	}
+
+	paste := models.Paste{
+		Content:         req.Content,
+		Burn:            req.Burn,
+		Language:        req.Language,
+		UUID:            id,
+		ExpiryTimestamp: time.Now().Add(time.Duration(req.ExpiresMinutes) * time.Minute),
+		Title:           req.Title,
+		Description:     req.Description,
+		Tags:            tags,
+		Visibility:      visibility,
+		Quarantined:     action == scan.ActionQuarantine,
+	}
+	ownerToken, err := paste.SetOwnerToken()
+	if err != nil {
+		return nil, err
+	}
+	paste.Derive()
+
+	reservation, err := s.quota.Reserve("ip:"+clientIP(ctx), paste.Size, time.Now())
+	var exceeded *quota.ExceededError
+	if errors.As(err, &exceeded) {
+		return nil, status.Errorf(codes.ResourceExhausted, "%s, resets at %s", exceeded.Error(), exceeded.Reset.Format(time.RFC3339))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.CreatePaste(s.db, &paste); err != nil {
+		if err := s.quota.Release(reservation); err != nil {
+			s.logger.Error("Error releasing paste quota", zap.Error(err))
+		}
+		return nil, err
+	}
+	if len(findings) > 0 {
+		s.recordFindings(ctx, paste.UUID, findings, action)
+	}
+	return &wastebinv1.CreatePasteResponse{Paste: pasteToProto(paste), OwnerToken: ownerToken}, nil
+}
+
+// GetPaste returns a paste, deleting it when it expired or should be burned after reading
+func (s *Service) GetPaste(ctx context.Context, req *wastebinv1.GetPasteRequest) (*wastebinv1.GetPasteResponse, error) {
+	paste, err := s.findPaste(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(paste.ExpiryTimestamp) {
+		if _, err := storage.DeletePaste(s.db, paste.UUID, models.EventPasteExpired); err != nil {
+			return nil, err
+		}
+		return nil, status.Error(codes.NotFound, "Paste expired")
+	}
+	if paste.Tags, err = storage.PasteTags(s.db, paste.UUID); err != nil {
+		return nil, err
+	}
+
+	if paste.Burn {
+		// Only the read deleting the paste gets it
+		deleted, err := storage.DeletePaste(s.db, paste.UUID, models.EventPasteBurned)
+		if err != nil {
+			return nil, err
+		}
+		if deleted == 0 {
+			return nil, status.Error(codes.NotFound, "Paste not found")
+		}
+		s.recordAudit(ctx, audit.ActionPasteBurn, paste.UUID.String())
+	} else if err := storage.CountView(s.db, paste.UUID); err != nil {
+		s.logger.Error("Error counting paste view", zap.Error(err))
+	} else {
+		paste.Views++
+	}
+	return &wastebinv1.GetPasteResponse{Paste: pasteToProto(paste)}, nil
+}
+
+// DeletePaste deletes a paste with its owner token or the admin token
+func (s *Service) DeletePaste(ctx context.Context, req *wastebinv1.DeletePasteRequest) (*wastebinv1.DeletePasteResponse, error) {
+	paste, err := s.findPaste(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	token := bearerToken(ctx)
+	admin := handlers.TokenMatches(token, s.config.AdminToken)
+	if !admin && !paste.OwnedBy(token) {
+		return nil, status.Error(codes.PermissionDenied, "Only the owner of the paste can delete it")
+	}
+	deleted, err := storage.DeletePaste(s.db, paste.UUID, models.EventPasteDeleted)
+	if err != nil {
+		return nil, err
+	}
+	if deleted == 0 {
+		return nil, status.Error(codes.NotFound, "Paste not found")
+	}
+	s.recordAudit(ctx, audit.ActionPasteDelete, paste.UUID.String())
+	return &wastebinv1.DeletePasteResponse{}, nil
+}
+
+// ListPastes lists the public pastes with a tag, the newest first
+func (s *Service) ListPastes(ctx context.Context, req *wastebinv1.ListPastesRequest) (*wastebinv1.ListPastesResponse, error) {
+	tag := strings.ToLower(strings.TrimSpace(req.Tag))
+	if tag == "" {
+		return nil, status.Error(codes.InvalidArgument, "A tag to filter on is required")
+	}
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = storage.DefaultListLimit
+	}
+	if limit < 1 || limit > storage.MaxListLimit {
+		return nil, status.Errorf(codes.InvalidArgument, "Limit must be between 1 and %d", storage.MaxListLimit)
+	}
+
+	summaries, err := storage.ListPastesByTag(s.db, tag, time.Now(), limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := &wastebinv1.ListPastesResponse{}
+	for _, summary := range summaries {
+		resp.Pastes = append(resp.Pastes, summaryToProto(summary))
+	}
+	return resp, nil
+}
+
+// findPaste returns the paste with the id when the caller may read it.
+// Private and embargoed pastes need their owner token or the admin token,
+// quarantined ones the admin token.
+func (s *Service) findPaste(ctx context.Context, id string) (models.Paste, error) {
+	var paste models.Paste
+	pasteUUID, err := uuid.Parse(id)
+	if err != nil {
+		return paste, status.Error(codes.NotFound, "Paste not found")
+	}
+	err = s.db.First(&paste, "uuid = ?", pasteUUID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return paste, status.Error(codes.NotFound, "Paste not found")
+	}
+	if err != nil {
+		return paste, err
+	}
+
+	token := bearerToken(ctx)
+	admin := handlers.TokenMatches(token, s.config.AdminToken)
+	if paste.Quarantined && !admin {
+		return paste, status.Error(codes.NotFound, "Paste not found")
+	}
+	if paste.Visibility == models.VisibilityPrivate || !paste.Published(time.Now()) {
+		if !admin && !paste.OwnedBy(token) {
+			return paste, status.Error(codes.NotFound, "Paste not found")
+		}
+	}
+	return paste, nil
+}
+
+// recordFindings keeps the findings of a quarantined or flagged paste for
+// admin review
+func (s *Service) recordFindings(ctx context.Context, paste uuid.UUID, findings []scan.Finding, action scan.Action) {
+	records := make([]models.ScanFinding, 0, len(findings))
+	for _, finding := range findings {
+		records = append(records, finding.Record(paste))
+	}
+	if err := storage.AddFindings(s.db, records); err != nil {
+		s.logger.Error("Error recording scan findings", zap.Error(err))
+	}
+	if action == scan.ActionQuarantine {
+		s.recordAudit(ctx, audit.ActionPasteQuarantine, paste.String())
+	} else {
+		s.recordAudit(ctx, audit.ActionPasteFlag, paste.String())
+	}
+}
+
+func (s *Service) recordAudit(ctx context.Context, action, target string) {
+	if err := audit.Record(s.db, action, clientIP(ctx), target); err != nil {
+		s.logger.Error("Error recording audit event", zap.String("action", action), zap.Error(err))
+	}
+}
+
+// bearerToken returns the bearer token of the authorization metadata
+func bearerToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, auth := range md.Get("authorization") {
+		if strings.HasPrefix(auth, "Bearer ") {
+			return strings.TrimPrefix(auth, "Bearer ")
+		}
+	}
+	return ""
+}
+
+// clientIP returns the IP address of the caller
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+func visibilityFromProto(v wastebinv1.Visibility) (models.Visibility, bool) {
+	switch v {
+	case wastebinv1.Visibility_VISIBILITY_UNSPECIFIED, wastebinv1.Visibility_VISIBILITY_UNLISTED:
+		return models.VisibilityUnlisted, true
+	case wastebinv1.Visibility_VISIBILITY_PUBLIC:
+		return models.VisibilityPublic, true
+	case wastebinv1.Visibility_VISIBILITY_PRIVATE:
+		return models.VisibilityPrivate, true
+	}
+	return "", false
+}
+
+func visibilityToProto(v models.Visibility) wastebinv1.Visibility {
+	switch v {
+	case models.VisibilityPublic:
+		return wastebinv1.Visibility_VISIBILITY_PUBLIC
+	case models.VisibilityPrivate:
+		return wastebinv1.Visibility_VISIBILITY_PRIVATE
+	}
+	return wastebinv1.Visibility_VISIBILITY_UNLISTED
+}
+
+func pasteToProto(p models.Paste) *wastebinv1.Paste {
+	paste := &wastebinv1.Paste{
+		Id:          p.UUID.String(),
+		Content:     p.Content,
+		Language:    p.Language,
+		Title:       p.Title,
+		Description: p.Description,
+		Burn:        p.Burn,
+		Visibility:  visibilityToProto(p.Visibility),
+		Tags:        p.Tags,
+		Size:        p.Size,
+		Checksum:    p.Checksum,
+		Views:       p.Views,
+		CreatedAt:   timestamppb.New(p.CreatedAt),
+		ExpiresAt:   timestamppb.New(p.ExpiryTimestamp),
+	}
+	if p.ParentID != nil {
+		paste.ForkedFrom = p.ParentID.String()
+	}
+	return paste
+}
+
+func summaryToProto(p models.PasteSummary) *wastebinv1.Paste {
+	paste := &wastebinv1.Paste{
+		Id:          p.UUID.String(),
+		Language:    p.Language,
+		Title:       p.Title,
+		Description: p.Description,
+		Visibility:  wastebinv1.Visibility_VISIBILITY_PUBLIC,
+		Tags:        p.Tags,
+		Size:        p.Size,
+		CreatedAt:   timestamppb.New(p.CreatedAt),
+		ExpiresAt:   timestamppb.New(p.ExpiryTimestamp),
+	}
+	if p.ParentID != nil {
+		paste.ForkedFrom = p.ParentID.String()
+	}
+	return paste
+}
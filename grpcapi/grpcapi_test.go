@@ -0,0 +1,123 @@
+package grpcapi_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/grpcapi"
+	"github.com/coolguy1771/wastebin/log"
+	wastebinv1 "github.com/coolguy1771/wastebin/proto/wastebin/v1"
+	"github.com/coolguy1771/wastebin/quota"
+	"github.com/coolguy1771/wastebin/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPasteService(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:grpc?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Migrate(db, log.Default()); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := config.Default()
+	conf.AdminToken = "admin"
+	quotas := quota.New(db, log.Default(), quota.Limits{}, quota.Limits{})
+	server := grpcapi.NewServer(&conf, log.Default(), db, quotas, nil)
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := wastebinv1.NewPasteServiceClient(conn)
+	ctx := context.Background()
+
+	created, err := client.CreatePaste(ctx, &wastebinv1.CreatePasteRequest{
+		Content:        "Paste A",
+		ExpiresMinutes: 10,
+		Visibility:     wastebinv1.Visibility_VISIBILITY_PUBLIC,
+		Tags:           []string{"Go"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := client.GetPaste(ctx, &wastebinv1.GetPasteRequest{Id: created.Paste.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Paste.Content != "Paste A" || got.Paste.Size != 7 || got.Paste.Views != 1 || len(got.Paste.Tags) != 1 || got.Paste.Tags[0] != "go" {
+		t.Fatalf("unexpected paste %v", got.Paste)
+	}
+
+	listed, err := client.ListPastes(ctx, &wastebinv1.ListPastesRequest{Tag: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed.Pastes) != 1 || listed.Pastes[0].Id != created.Paste.Id || listed.Pastes[0].Content != "" {
+		t.Fatalf("unexpected listed pastes %v", listed.Pastes)
+	}
+
+	if _, err := client.CreatePaste(ctx, &wastebinv1.CreatePasteRequest{ExpiresMinutes: 0}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected %s creating an invalid paste, got %v", codes.InvalidArgument, err)
+	}
+
+	private, err := client.CreatePaste(ctx, &wastebinv1.CreatePasteRequest{
+		Content:        "Paste B",
+		ExpiresMinutes: 10,
+		Visibility:     wastebinv1.Visibility_VISIBILITY_PRIVATE,
+	})
+	if err != nil || private.OwnerToken == "" {
+		t.Fatalf("unexpected private paste %v: %v", private, err)
+	}
+	if _, err := client.GetPaste(ctx, &wastebinv1.GetPasteRequest{Id: private.Paste.Id}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected %s reading a private paste without its token, got %v", codes.NotFound, err)
+	}
+	owner := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+private.OwnerToken)
+	if _, err := client.GetPaste(owner, &wastebinv1.GetPasteRequest{Id: private.Paste.Id}); err != nil {
+		t.Fatalf("expected the owner to read the private paste, got %v", err)
+	}
+
+	if _, err := client.DeletePaste(ctx, &wastebinv1.DeletePasteRequest{Id: private.Paste.Id}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected %s deleting a private paste without its token, got %v", codes.NotFound, err)
+	}
+	if _, err := client.DeletePaste(ctx, &wastebinv1.DeletePasteRequest{Id: created.Paste.Id}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected %s deleting a paste without a token, got %v", codes.PermissionDenied, err)
+	}
+	if _, err := client.DeletePaste(owner, &wastebinv1.DeletePasteRequest{Id: private.Paste.Id}); err != nil {
+		t.Fatalf("expected the owner to delete the private paste, got %v", err)
+	}
+	admin := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer admin")
+	if _, err := client.DeletePaste(admin, &wastebinv1.DeletePasteRequest{Id: created.Paste.Id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetPaste(ctx, &wastebinv1.GetPasteRequest{Id: created.Paste.Id}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected %s after deleting the paste, got %v", codes.NotFound, err)
+	}
+
+	// The quotas are shared, so changing their limits applies at once
+	quotas.SetLimits(quota.Limits{Pastes: 1}, quota.Limits{})
+	if _, err := client.CreatePaste(ctx, &wastebinv1.CreatePasteRequest{Content: "Paste C", ExpiresMinutes: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreatePaste(ctx, &wastebinv1.CreatePasteRequest{Content: "Paste D", ExpiresMinutes: 10}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected %s over the quota, got %v", codes.ResourceExhausted, err)
+	}
+}
@@ -0,0 +1,48 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coolguy1771/wastebin/log"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// logCalls logs every call with its status code and duration. Errors that
+// are not gRPC statuses are logged and hidden behind an Internal status.
+func logCalls(logger *log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		if _, ok := status.FromError(err); !ok {
+			logger.Error("Error handling gRPC call", zap.String("method", info.FullMethod), zap.Error(err))
+			err = status.Error(codes.Internal, "Internal error")
+		}
+		logger.Info("gRPC call",
+			zap.String("method", info.FullMethod),
+			zap.String("code", status.Code(err).String()),
+			zap.Duration("duration", time.Since(start)),
+			zap.String("ip", clientIP(ctx)),
+		)
+		return resp, err
+	}
+}
+
+// recoverPanics turns the panics of a call into an Internal status so that
+// they don't crash the server
+func recoverPanics(logger *log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("Panic handling gRPC call", zap.String("method", info.FullMethod), zap.String("panic", fmt.Sprint(r)))
+				err = status.Error(codes.Internal, "Internal error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/coolguy1771/wastebin/abuse"
+	"github.com/coolguy1771/wastebin/alert"
+	"github.com/coolguy1771/wastebin/audit"
+	"github.com/coolguy1771/wastebin/clientip"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Penalties of the abusive clients
+const (
+	// abuseShadowBan answers creations as if they succeeded without storing them
+	abuseShadowBan = "shadowban"
+	// abuseTarpit answers creations slowly with 429
+	abuseTarpit = "tarpit"
+)
+
+// HoneypotField is the form field the frontend hides from people, only bots
+// fill it
+const HoneypotField = "website"
+
+// SetAbuseDetector scores the clients creating pastes with detector to
+// penalize the abusive ones
+func (h *Handler) SetAbuseDetector(detector *abuse.Detector) {
+	h.abuse = detector
+}
+
+// DetectAbuse records the creations of every client and penalizes the ones
+// behaving like bots with the configured ABUSE_ACTION. The admin is trusted.
+func (h *Handler) DetectAbuse(c *fiber.Ctx) error {
+	if h.abuse == nil || h.config.AdminToken != "" && hasBearerToken(c, h.config.AdminToken) {
+		return c.Next()
+	}
+	key := clientip.Get(c)
+	now := time.Now()
+
+	if !h.abuse.Penalized(key, now) {
+		creation := abuse.Creation{
+			UserAgent: c.Get(fiber.HeaderUserAgent),
+			Honeypot:  pasteValue(c, HoneypotField) != "",
+		}
+		if text := pasteValue(c, "text"); text != "" {
+			sum := sha256.Sum256([]byte(text))
+			creation.ContentHash = hex.EncodeToString(sum[:])
+		}
+		signals, penalized := h.abuse.Observe(key, creation, now)
+		if len(signals) > 0 {
+			h.requestLogger(c).Warn("Suspicious paste creation", zap.String("client", key), zap.Strings("signals", signals))
+		}
+		if !penalized {
+			return c.Next()
+		}
+		h.recordAudit(c, audit.ActionClientPenalized, strings.Join(signals, ","))
+		h.sendAlert(alert.Alert{Kind: audit.ActionClientPenalized, Title: "Client penalized for abusive paste creation", Text: "Signals: " + strings.Join(signals, ", ")})
+	}
+
+	h.abuse.Blocked()
+	if h.config.AbuseAction == abuseShadowBan {
+		// Pretend the paste was created so the bot doesn't adapt
+		id := uuid.New()
+		return respond(c, Created{UUID: id, URL: h.pasteURL(c, id)})
+	}
+	select {
+	case <-time.After(h.config.AbuseTarpitDelay):
+	case <-h.closing:
+	}
+	return fail(c, fiber.StatusTooManyRequests, "Too many requests")
+}
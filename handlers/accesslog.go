@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/coolguy1771/wastebin/clientip"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// AccessLog logs every request with its response once it is handled, unless
+// the access log is disabled
+func (h *Handler) AccessLog(c *fiber.Ctx) error {
+	if !h.config.AccessLog {
+		return c.Next()
+	}
+
+	start := time.Now()
+	err := c.Next()
+	latency := time.Since(start)
+
+	// Streamed bodies, such as the paste events, are only sent afterwards
+	bytes := -1
+	if !c.Response().IsBodyStream() {
+		bytes = len(c.Response().Body())
+	}
+	h.requestLogger(c).Info("Request",
+		zap.String("method", c.Method()),
+		zap.String("route", c.Route().Path),
+		zap.String("path", c.Path()),
+		zap.Int("status", responseStatus(c, err)),
+		zap.Int("bytes", bytes),
+		zap.Duration("latency", latency),
+		zap.String("client", clientip.Get(c)),
+		zap.String("user_agent", c.Get(fiber.HeaderUserAgent)),
+	)
+	return err
+}
+
+// responseStatus returns the status of the response to the request, which the
+// error handler sets from err after the middlewares return
+func responseStatus(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+	return fiber.StatusInternalServerError
+}
@@ -0,0 +1,69 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/handlers"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAccessLog(t *testing.T) {
+	var output strings.Builder
+	logger, err := log.New(&output, "INFO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &config.Config{AllowedOrigins: "*", AccessLog: true}
+	h := handlers.New(conf, logger, nil)
+	app := fiber.New()
+	app.Use(h.RequestID, h.AccessLog)
+	app.Get("/paste/:uuid", func(c *fiber.Ctx) error { return c.SendString("hello") })
+	app.Get("/gone", func(c *fiber.Ctx) error { return fiber.ErrGone })
+
+	req := httptest.NewRequest("GET", "/paste/1234", nil)
+	req.Header.Set(fiber.HeaderUserAgent, "curl/8.0")
+	req.Header.Set(fiber.HeaderXRequestID, "abc")
+	if _, err := app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.Test(httptest.NewRequest("GET", "/gone", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 access log entries, got %s", output.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	for key, expected := range map[string]interface{}{
+		"method": "GET", "route": "/paste/:uuid", "path": "/paste/1234", "status": float64(200),
+		"bytes": float64(5), "user_agent": "curl/8.0", "request_id": "abc",
+	} {
+		if entry[key] != expected {
+			t.Errorf("expected %s %v, got %v", key, expected, entry[key])
+		}
+	}
+	if _, ok := entry["latency"]; !ok {
+		t.Errorf("expected the latency logged, got %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"status":410`) {
+		t.Errorf("expected the status of the error logged, got %s", lines[1])
+	}
+
+	conf.AccessLog = false
+	output.Reset()
+	if _, err := app.Test(httptest.NewRequest("GET", "/gone", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if output.Len() != 0 {
+		t.Errorf("expected no access log when disabled, got %s", output.String())
+	}
+}
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/coolguy1771/wastebin/abuse"
+	"github.com/coolguy1771/wastebin/alert"
+	"github.com/coolguy1771/wastebin/audit"
+	"github.com/coolguy1771/wastebin/breaker"
+	"github.com/coolguy1771/wastebin/clientip"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/outbox"
+	"github.com/coolguy1771/wastebin/stats"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// auditPurgeInterval is how often the audit events older than the retention
+// are deleted
+const auditPurgeInterval = time.Hour
+
+// TokenMatches reports whether token is the expected one, comparing them in
+// constant time. Nothing matches an empty expected token.
+func TokenMatches(token, expected string) bool {
+	return expected != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+// hasBearerToken reports whether the request is authorized with token
+func hasBearerToken(c *fiber.Ctx, token string) bool {
+	return TokenMatches(c.Get(fiber.HeaderAuthorization), "Bearer "+token)
+}
+
+// RequireAdmin only lets requests authorized with the admin token through.
+// The admin routes are disabled when no admin token is configured.
+func (h *Handler) RequireAdmin(c *fiber.Ctx) error {
+	token := h.config.AdminToken
+	if token == "" {
+		return fiber.ErrNotFound
+	}
+	if !hasBearerToken(c, token) {
+		h.recordThrottledAudit(c, audit.ActionAuthFailure, c.Method()+" "+c.Path())
+		return fail(c, fiber.StatusUnauthorized, "Invalid admin token")
+	}
+	h.recordAudit(c, audit.ActionAdminRequest, c.Method()+" "+c.Path())
+	return c.Next()
+}
+
+// recordAudit stores an audit event, failing to do so does not fail the request
+func (h *Handler) recordAudit(c *fiber.Ctx, action, target string) {
+	if err := audit.Record(h.db, action, clientip.Get(c), target); err != nil {
+		h.requestLogger(c).Error("Error recording audit event", zap.String("action", action), zap.Error(err))
+	}
+	h.purgeAudit(time.Now())
+}
+
+// recordThrottledAudit stores an audit event that clients can trigger at will
+// at most once a minute per client and action
+func (h *Handler) recordThrottledAudit(c *fiber.Ctx, action, target string) {
+	if h.auditThrottle.Allow(action+"|"+clientip.Get(c), time.Now()) {
+		h.recordAudit(c, action, target)
+	}
+}
+
+// sendAlert notifies the alert channels, if any, of an event needing the
+// attention of the admin. Client addresses are left out of the alerts as the
+// channels are third-party services.
+func (h *Handler) sendAlert(a alert.Alert) {
+	if h.alerts != nil {
+		h.alerts.Notify(a)
+	}
+}
+
+// RecordRateLimited records in the audit log that a request was rate limited
+func (h *Handler) RecordRateLimited(c *fiber.Ctx) {
+	h.recordThrottledAudit(c, audit.ActionRateLimited, c.Method()+" "+c.Path())
+}
+
+// purgeAudit deletes the audit events older than the configured retention,
+// at most once per auditPurgeInterval
+func (h *Handler) purgeAudit(now time.Time) {
+	if h.config.AuditRetention <= 0 {
+		return
+	}
+	h.auditPurgeMu.Lock()
+	if now.Sub(h.lastAuditPurge) < auditPurgeInterval {
+		h.auditPurgeMu.Unlock()
+		return
+	}
+	h.lastAuditPurge = now
+	h.auditPurgeMu.Unlock()
+
+	purged, err := audit.Purge(h.db, now.Add(-h.config.AuditRetention))
+	if err != nil {
+		h.logger.Error("Error purging the audit log", zap.Error(err))
+		return
+	}
+	if purged > 0 {
+		h.logger.Info("Purged old audit events", zap.Int64("purged", purged))
+	}
+}
+
+// parseTimeQuery parses an optional RFC 3339 query parameter
+func parseTimeQuery(c *fiber.Ctx, key string, fallback time.Time) (time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return fallback, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// overviewTopTalkers is the number of clients listed in the overview
+const overviewTopTalkers = 10
+
+// overviewGrowthDays is the number of days the storage growth is reported for
+const overviewGrowthDays = 30
+
+// Overview is the operational summary of the instance
+type Overview struct {
+	Traffic      stats.Snapshot     `json:"traffic"`
+	Storage      storage.Usage      `json:"storage"`
+	Runtime      stats.RuntimeStats `json:"runtime"`
+	DatabasePool stats.PoolStats    `json:"database_pool"`
+	// Abuse is set when the abuse detection is enabled
+	Abuse *abuse.Stats `json:"abuse,omitempty"`
+	// DatabaseBreaker is set when the database circuit breaker is enabled
+	DatabaseBreaker *breaker.Stats `json:"database_breaker,omitempty"`
+	// Events is set when the events of the pastes are dispatched
+	Events *outbox.Stats `json:"events,omitempty"`
+}
+
+// GetOverview returns the traffic served by this instance, the storage usage
+// and the state of the runtime and the database connection pool
+func (h *Handler) GetOverview(c *fiber.Ctx) error {
+	fields, err := parseFields(c, Overview{})
+	if err != nil {
+		return fail(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	usage, err := storage.GetUsage(h.dbFor(c), time.Now().AddDate(0, 0, -overviewGrowthDays))
+	if err != nil {
+		h.requestLogger(c).Error("Error retrieving the storage usage", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error retrieving the storage usage")
+	}
+
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return fail(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	overview := Overview{
+		Traffic:      h.stats.Snapshot(overviewTopTalkers),
+		Storage:      usage,
+		Runtime:      stats.Runtime(),
+		DatabasePool: stats.Pool(sqlDB.Stats()),
+	}
+	if h.abuse != nil {
+		abuseStats := h.abuse.Stats(time.Now())
+		overview.Abuse = &abuseStats
+	}
+	if h.breaker != nil {
+		breakerStats := h.breaker.Stats()
+		overview.DatabaseBreaker = &breakerStats
+	}
+	if h.outbox != nil {
+		eventStats, err := h.outbox.Stats()
+		if err != nil {
+			h.requestLogger(c).Error("Error counting the pending events", zap.Error(err))
+			return fail(c, fiber.StatusInternalServerError, "Error counting the pending events")
+		}
+		overview.Events = &eventStats
+	}
+	return sendFields(c, overview, fields)
+}
+
+// ExportAudit returns the audit events between the from and to query
+// parameters as a signed bundle
+func (h *Handler) ExportAudit(c *fiber.Ctx) error {
+	if h.config.AuditSigningKey == "" {
+		return fail(c, fiber.StatusServiceUnavailable, "No audit signing key configured")
+	}
+	key, err := audit.ParseSigningKey(h.config.AuditSigningKey)
+	if err != nil {
+		h.requestLogger(c).Error("Error parsing the audit signing key", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Invalid audit signing key")
+	}
+
+	from, err := parseTimeQuery(c, "from", time.Time{})
+	if err != nil {
+		return fail(c, fiber.StatusBadRequest, "Invalid from time format")
+	}
+	to, err := parseTimeQuery(c, "to", time.Now())
+	if err != nil {
+		return fail(c, fiber.StatusBadRequest, "Invalid to time format")
+	}
+
+	var bundle bytes.Buffer
+	if err := audit.Export(&bundle, h.dbFor(c), from, to, key); err != nil {
+		h.requestLogger(c).Error("Error exporting the audit log", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error exporting the audit log")
+	}
+	h.recordAudit(c, audit.ActionAuditExport, from.Format(time.RFC3339)+"/"+to.Format(time.RFC3339))
+
+	c.Attachment("audit-" + to.UTC().Format("20060102T150405Z") + ".zip")
+	return c.Send(bundle.Bytes())
+}
+
+// AuditEvents is a page of audit events, NextBefore is set when there are more
+type AuditEvents struct {
+	Events     []models.AuditEvent `json:"events"`
+	NextBefore uint                `json:"next_before,omitempty"`
+}
+
+// QueryAudit returns the audit events matching the action, actor, from and to
+// query parameters, newest first. The next page is read with before set to
+// the next_before of the response.
+func (h *Handler) QueryAudit(c *fiber.Ctx) error {
+	filter := audit.Filter{
+		Action: c.Query("action"),
+		Actor:  c.Query("actor"),
+		Limit:  audit.DefaultQueryLimit,
+	}
+	var err error
+	if limit := c.Query("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			filter.Limit = 0
+		}
+	}
+	if filter.From, err = parseTimeQuery(c, "from", time.Time{}); err != nil {
+		return fail(c, fiber.StatusBadRequest, "Invalid from time format")
+	}
+	if filter.To, err = parseTimeQuery(c, "to", time.Time{}); err != nil {
+		return fail(c, fiber.StatusBadRequest, "Invalid to time format")
+	}
+	if before := c.Query("before"); before != "" {
+		id, err := strconv.ParseUint(before, 10, 32)
+		if err != nil {
+			return fail(c, fiber.StatusBadRequest, "Invalid before event ID")
+		}
+		filter.Before = uint(id)
+	}
+	if filter.Limit < 1 || filter.Limit > audit.MaxQueryLimit {
+		return fail(c, fiber.StatusBadRequest, fmt.Sprintf("Limit must be between 1 and %d", audit.MaxQueryLimit))
+	}
+
+	events, err := audit.Query(h.dbFor(c), filter)
+	if err != nil {
+		h.requestLogger(c).Error("Error querying the audit log", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error querying the audit log")
+	}
+	response := AuditEvents{Events: events}
+	if len(events) == filter.Limit {
+		response.NextBefore = events[len(events)-1].ID
+	}
+	return respond(c, response)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Limits of the annotations of a paste
+const (
+	MaxAnnotationLength = 1000
+	MaxAnnotations      = 100
+)
+
+// annotationRequest is an annotation to leave, its line is checked against
+// the paste
+type annotationRequest struct {
+	Line json.Number `json:"line" form:"line"`
+	Note string      `json:"note" form:"note" validate:"required,max=1000"`
+}
+
+// AnnotatePaste leaves the note of the form or JSON body on the line it gives
+// of a paste. Anyone who can read the paste can annotate it.
+func (h *Handler) AnnotatePaste(c *fiber.Ctx) error {
+	paste, ok, err := h.findStoredPaste(c, c.Params("uuid"), "annotated")
+	if !ok {
+		return err
+	}
+	if paste.Attachment() {
+		return fail(c, fiber.StatusBadRequest, "Attachments cannot be annotated")
+	}
+
+	var req annotationRequest
+	if err := Bind(c, &req, maxFormSize); err != nil {
+		return sendBindError(c, err)
+	}
+	var errs fieldErrors
+	lines := countLines(paste.Content)
+	line, err := strconv.Atoi(req.Line.String())
+	if err != nil {
+		errs.add("line", "Line must be a number")
+	} else if line < 1 || line > lines {
+		errs.add("line", fmt.Sprintf("Line must be between 1 and %d", lines))
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	errs.check(req)
+	if len(errs) > 0 {
+		return errs.send(c)
+	}
+
+	annotation := models.Annotation{PasteUUID: paste.UUID, Line: line, Note: req.Note}
+	err = storage.AddAnnotation(h.dbFor(c), &annotation, MaxAnnotations)
+	if errors.Is(err, storage.ErrTooManyAnnotations) {
+		return fail(c, fiber.StatusConflict, fmt.Sprintf("A paste cannot have more than %d annotations", MaxAnnotations))
+	}
+	if err != nil {
+		h.requestLogger(c).Error("Error annotating paste", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error annotating paste")
+	}
+	return respond(c, annotation)
+}
+
+// countLines returns the number of lines of content, a trailing newline
+// doesn't start another one
+func countLines(content string) int {
+	return strings.Count(strings.TrimSuffix(content, "\n"), "\n") + 1
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxFormSize bounds the bodies of the requests carrying a few short values
+const maxFormSize = 64 << 10
+
+// Bind decodes the body of the request into out, a pointer to a request
+// struct. The format is negotiated from the Content-Type: JSON bodies are
+// decoded with the json tags of out and URL encoded or multipart forms with
+// its form tags. Requests without a body leave out as it is. Bodies over
+// maxSize bytes, 0 leaving the limit to the server, other content types and
+// malformed bodies are refused with a *fiber.Error to send back.
+func Bind(c *fiber.Ctx, out interface{}, maxSize int) error {
+	body := c.Body()
+	if len(body) == 0 {
+		return nil
+	}
+	if maxSize > 0 && len(body) > maxSize {
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("Request body cannot be larger than %d bytes", maxSize))
+	}
+
+	mediaType, _, err := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, "Request body must be JSON or a form")
+	}
+	switch mediaType {
+	case fiber.MIMEApplicationJSON:
+		if err := c.BodyParser(out); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid JSON body: "+err.Error())
+		}
+	case fiber.MIMEApplicationForm, fiber.MIMEMultipartForm:
+		if err := c.BodyParser(out); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid form body: "+err.Error())
+		}
+	default:
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, "Request body must be JSON or a form")
+	}
+	return nil
+}
+
+// sendBindError answers a request whose body Bind refused
+func sendBindError(c *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if !errors.As(err, &fiberErr) {
+		fiberErr = fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return fail(c, fiberErr.Code, fiberErr.Message)
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/coolguy1771/wastebin/captcha"
+	"github.com/coolguy1771/wastebin/clientip"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// CaptchaSettings tells clients how to render the captcha widget
+type CaptchaSettings struct {
+	Provider string `json:"provider" example:"turnstile"`
+	SiteKey  string `json:"site_key" example:"0x4AAAAAAAC3DHQFLr1GavRN"`
+	// Field is the form field the solved challenge is sent in
+	Field string `json:"field" example:"cf-turnstile-response"`
+}
+
+// SetCaptcha requires the challenges verified by verifier to be solved to
+// create pastes anonymously
+func (h *Handler) SetCaptcha(verifier *captcha.Verifier) {
+	h.captcha = verifier
+}
+
+// RequireCaptcha only lets requests through with a solved captcha challenge,
+// sent in the form field of the widget or the X-Captcha-Response header, when
+// a captcha is configured. Requests with the admin token are let through.
+func (h *Handler) RequireCaptcha(c *fiber.Ctx) error {
+	if h.captcha == nil || h.config.AdminToken != "" && hasBearerToken(c, h.config.AdminToken) {
+		return c.Next()
+	}
+	response := c.FormValue(h.captcha.ResponseField())
+	if response == "" {
+		response = c.Get("X-Captcha-Response")
+	}
+
+	err := h.captcha.Verify(c.UserContext(), response, clientip.Get(c))
+	var failed *captcha.FailedError
+	switch {
+	case err == nil:
+		return c.Next()
+	case errors.Is(err, captcha.ErrMissing), errors.As(err, &failed):
+		return fail(c, fiber.StatusForbidden, err.Error())
+	default:
+		h.requestLogger(c).Error("Error verifying captcha", zap.Error(err))
+		return fail(c, fiber.StatusServiceUnavailable, "Captcha verification is unavailable")
+	}
+}
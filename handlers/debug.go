@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+// RequireDebug guards the diagnostics endpoints, which are open in development
+// and require the admin token otherwise
+func (h *Handler) RequireDebug(c *fiber.Ctx) error {
+	if h.config.Dev {
+		return c.Next()
+	}
+	return h.RequireAdmin(c)
+}
+
+// AddDebugRoutes serves the pprof profiles under /pprof and the expvar
+// variables under /vars of the router
+func AddDebugRoutes(router fiber.Router) {
+	router.Get("/vars", httpHandler(expvar.Handler()))
+	router.Get("/pprof/cmdline", httpHandler(http.HandlerFunc(pprof.Cmdline)))
+	router.Get("/pprof/profile", httpHandler(http.HandlerFunc(pprof.Profile)))
+	router.Get("/pprof/symbol", httpHandler(http.HandlerFunc(pprof.Symbol)))
+	router.Post("/pprof/symbol", httpHandler(http.HandlerFunc(pprof.Symbol)))
+	router.Get("/pprof/trace", httpHandler(http.HandlerFunc(pprof.Trace)))
+	// The index serves the other profiles by name, such as heap and goroutine
+	router.Get("/pprof/*", httpHandler(http.HandlerFunc(pprof.Index)))
+}
+
+// httpHandler serves a net/http handler on a fiber route
+func httpHandler(h http.Handler) fiber.Handler {
+	handler := fasthttpadaptor.NewFastHTTPHandler(h)
+	return func(c *fiber.Ctx) error {
+		handler(c.Context())
+		return nil
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// DiffLine is a line of a hunk. Op is " " for a line in both pastes, "-" for
+// a line only in the first one and "+" for a line only in the second one.
+type DiffLine struct {
+	Op   string `json:"op" example:"+"`
+	Text string `json:"text" example:"fmt.Println(\"hello\")"`
+}
+
+// DiffHunk is a group of changes with their context, numbered like a unified diff
+type DiffHunk struct {
+	FromLine  int        `json:"from_line" example:"1"`
+	FromCount int        `json:"from_count" example:"3"`
+	ToLine    int        `json:"to_line" example:"1"`
+	ToCount   int        `json:"to_count" example:"4"`
+	Lines     []DiffLine `json:"lines"`
+}
+
+// PasteDiff lists the changes between the content of two pastes
+type PasteDiff struct {
+	From  uuid.UUID  `json:"from"`
+	To    uuid.UUID  `json:"to"`
+	Hunks []DiffHunk `json:"hunks"`
+}
+
+// DiffPastes compares the content of two pastes. It responds with a unified
+// diff, or with the hunks as JSON when format=json is set or JSON is preferred
+// by the Accept header.
+func (h *Handler) DiffPastes(c *fiber.Ctx) error {
+	from, ok, err := h.findStoredPaste(c, c.Params("a"), "diffed")
+	if !ok {
+		return err
+	}
+	to, ok, err := h.findStoredPaste(c, c.Params("b"), "diffed")
+	if !ok {
+		return err
+	}
+	if from.Attachment() || to.Attachment() {
+		return fail(c, fiber.StatusBadRequest, "Attachments cannot be diffed")
+	}
+
+	a := splitLines(from.Content)
+	b := splitLines(to.Content)
+
+	format := c.Query("format")
+	if format == "" && c.Accepts(fiber.MIMETextPlain, fiber.MIMEApplicationJSON) == fiber.MIMEApplicationJSON {
+		format = "json"
+	}
+	switch format {
+	case "json":
+		return respond(c, PasteDiff{From: from.UUID, To: to.UUID, Hunks: diffHunks(a, b)})
+	case "", "text":
+		text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        a,
+			B:        b,
+			FromFile: from.UUID.String(),
+			ToFile:   to.UUID.String(),
+			Context:  diffContext,
+		})
+		if err != nil {
+			return err
+		}
+		c.Type("text/plain")
+		return c.SendString(text)
+	default:
+		return fail(c, fiber.StatusBadRequest, "Format must be text or json")
+	}
+}
+
+// splitLines splits text into lines that all end with a newline, unlike
+// difflib.SplitLines which adds an empty line after a trailing newline
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+// diffHunks groups the changes between the lines a and b into hunks
+func diffHunks(a, b []string) []DiffHunk {
+	hunks := []DiffHunk{}
+	matcher := difflib.NewMatcher(a, b)
+	for _, group := range matcher.GetGroupedOpCodes(diffContext) {
+		first, last := group[0], group[len(group)-1]
+		hunk := DiffHunk{
+			FromLine:  first.I1 + 1,
+			FromCount: last.I2 - first.I1,
+			ToLine:    first.J1 + 1,
+			ToCount:   last.J2 - first.J1,
+			Lines:     []DiffLine{},
+		}
+		// Like unified diffs, an empty range is numbered after the line before it
+		if hunk.FromCount == 0 {
+			hunk.FromLine--
+		}
+		if hunk.ToCount == 0 {
+			hunk.ToLine--
+		}
+
+		for _, op := range group {
+			if op.Tag == 'e' {
+				hunk.Lines = appendDiffLines(hunk.Lines, " ", a[op.I1:op.I2])
+				continue
+			}
+			if op.Tag == 'r' || op.Tag == 'd' {
+				hunk.Lines = appendDiffLines(hunk.Lines, "-", a[op.I1:op.I2])
+			}
+			if op.Tag == 'r' || op.Tag == 'i' {
+				hunk.Lines = appendDiffLines(hunk.Lines, "+", b[op.J1:op.J2])
+			}
+		}
+		hunks = append(hunks, hunk)
+	}
+	return hunks
+}
+
+func appendDiffLines(lines []DiffLine, op string, texts []string) []DiffLine {
+	for _, text := range texts {
+		lines = append(lines, DiffLine{Op: op, Text: strings.TrimSuffix(text, "\n")})
+	}
+	return lines
+}
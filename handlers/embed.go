@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Sizes in pixels of the iframes embedding pastes
+const (
+	defaultEmbedWidth  = 640
+	defaultEmbedHeight = 360
+	minEmbedSize       = 100
+)
+
+// embedStyle keeps the embedded pastes readable without loading anything
+const embedStyle = `body{margin:0;font:13px/1.5 ui-monospace,SFMono-Regular,Menlo,Consolas,monospace;background:#fff;color:#24292f}` +
+	`header,footer{padding:6px 12px;background:#f6f8fa;font-family:system-ui,sans-serif}` +
+	`pre{margin:0;padding:12px;overflow:auto}a{color:#0969da}`
+
+// GetPasteEmbed renders a paste as a standalone HTML page without scripts
+// for iframes. The frames allowed to embed it are set with
+// EMBED_FRAME_ANCESTORS. The paste is neither burned nor counted as viewed.
+func (h *Handler) GetPasteEmbed(c *fiber.Ctx) error {
+	paste, ok, err := h.findStoredPaste(c, c.Params("uuid"), "embedded")
+	if !ok {
+		return err
+	}
+	if paste.Attachment() {
+		return fail(c, fiber.StatusBadRequest, "Attachments cannot be embedded")
+	}
+
+	title := paste.Title
+	if title == "" {
+		title = "Paste " + paste.UUID.String()
+	}
+	var page strings.Builder
+	page.WriteString(`<!DOCTYPE html><html><head><meta charset="utf-8"><title>` + html.EscapeString(title) + `</title>`)
+	page.WriteString(`<style>` + embedStyle + `</style></head><body>`)
+	page.WriteString(`<header>` + html.EscapeString(title))
+	if paste.Language != "" {
+		page.WriteString(` · ` + html.EscapeString(paste.Language))
+	}
+	page.WriteString(`</header><pre><code>` + html.EscapeString(paste.Content) + `</code></pre>`)
+	page.WriteString(`<footer><a href="` + html.EscapeString(h.pasteURL(c, paste.UUID)) + `" target="_blank" rel="noopener">View on Wastebin</a></footer>`)
+	page.WriteString(`</body></html>`)
+
+	c.Set(fiber.HeaderContentSecurityPolicy, "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors "+h.config.EmbedFrameAncestors)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Type("html")
+	return c.SendString(page.String())
+}
+
+// oEmbed is the oEmbed response of a paste
+type oEmbed struct {
+	Version      string `json:"version"`
+	Type         string `json:"type"`
+	ProviderName string `json:"provider_name"`
+	ProviderURL  string `json:"provider_url"`
+	Title        string `json:"title,omitempty"`
+	HTML         string `json:"html"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	CacheAge     int64  `json:"cache_age,omitempty"`
+}
+
+// OEmbed describes how to embed the paste linked by the url query parameter
+// following the oEmbed specification, for blogs and chat unfurlers. Only JSON
+// is supported, and pastes that can't be embedded answer 404.
+func (h *Handler) OEmbed(c *fiber.Ctx) error {
+	if format := c.Query("format"); format != "" && format != "json" {
+		return fail(c, fiber.StatusNotImplemented, "Only the json format is supported")
+	}
+	width, err := embedSize(c.Query("maxwidth"), defaultEmbedWidth)
+	if err != nil {
+		return fail(c, fiber.StatusBadRequest, "maxwidth "+err.Error())
+	}
+	height, err := embedSize(c.Query("maxheight"), defaultEmbedHeight)
+	if err != nil {
+		return fail(c, fiber.StatusBadRequest, "maxheight "+err.Error())
+	}
+
+	pasteUUID, ok := h.linkedPaste(c, c.Query("url"))
+	if !ok {
+		return fail(c, fiber.StatusNotFound, "url is not the link of a paste")
+	}
+	var paste models.Paste
+	err = h.dbFor(c).Select("uuid", "title", "burn", "expiry_timestamp", "visibility", "publish_at", "quarantined", "content_type").Limit(1).Find(&paste, "uuid = ?", pasteUUID).Error
+	if err != nil {
+		h.requestLogger(c).Error("Error retrieving paste metadata", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error retrieving paste")
+	}
+	// Embeds are public, so only the pastes anyone can read are described
+	now := time.Now()
+	if paste.UUID == uuid.Nil || paste.Burn || now.After(paste.ExpiryTimestamp) || paste.Visibility == models.VisibilityPrivate || !paste.Published(now) || paste.Quarantined || paste.Attachment() {
+		return fail(c, fiber.StatusNotFound, "Paste not found")
+	}
+
+	// oEmbed consumers read the description itself, so it isn't enveloped
+	src := h.pasteURL(c, paste.UUID) + "/embed"
+	return c.JSON(oEmbed{
+		Version:      "1.0",
+		Type:         "rich",
+		ProviderName: "Wastebin",
+		ProviderURL:  h.baseURL(c) + "/",
+		Title:        paste.Title,
+		HTML: fmt.Sprintf(`<iframe src="%s" width="%d" height="%d" sandbox="allow-popups" loading="lazy" style="border:0"></iframe>`,
+			html.EscapeString(src), width, height),
+		Width:    width,
+		Height:   height,
+		CacheAge: int64(time.Until(paste.ExpiryTimestamp).Seconds()),
+	})
+}
+
+// embedSize returns the size of an embed limited by the max query value
+func embedSize(max string, size int) (int, error) {
+	if max == "" {
+		return size, nil
+	}
+	limit, err := strconv.Atoi(max)
+	if err != nil || limit < minEmbedSize {
+		return 0, fmt.Errorf("must be a number of pixels of at least %d", minEmbedSize)
+	}
+	if limit < size {
+		return limit, nil
+	}
+	return size, nil
+}
+
+// linkedPaste returns the paste linked by a URL of its page on this server
+func (h *Handler) linkedPaste(c *fiber.Ctx, link string) (uuid.UUID, bool) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	base, err := url.Parse(h.baseURL(c))
+	if err != nil || !strings.EqualFold(u.Host, base.Host) {
+		return uuid.Nil, false
+	}
+	path := strings.TrimPrefix(u.Path, base.Path)
+	if !strings.HasPrefix(path, "/paste/") {
+		return uuid.Nil, false
+	}
+	pasteUUID, err := uuid.Parse(strings.TrimSuffix(strings.TrimPrefix(path, "/paste/"), "/raw"))
+	return pasteUUID, err == nil
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// FieldError describes why a field of a request is invalid
+type FieldError struct {
+	Field   string `json:"field" example:"text"`
+	Message string `json:"message" example:"Content cannot be empty"`
+}
+
+// fieldErrors collects the validation failures of a request
+type fieldErrors []FieldError
+
+func (e *fieldErrors) add(field, message string) {
+	*e = append(*e, FieldError{Field: field, Message: message})
+}
+
+func (e fieldErrors) has(field string) bool {
+	for _, err := range e {
+		if err.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// send responds with 400 and every failure. The message is the first failure
+// for the clients that only read a single message.
+func (e fieldErrors) send(c *fiber.Ctx) error {
+	return failWith(c, fiber.StatusBadRequest, &Error{Message: e[0].Message, Fields: e})
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/coolguy1771/wastebin/clientip"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Types of the events streamed to the clients following a paste
+const (
+	eventUpdated  = "updated"
+	eventExtended = "extended"
+	eventBurned   = "burned"
+	eventDeleted  = "deleted"
+	eventExpired  = "expired"
+)
+
+// eventKeepAlive is how often idle event streams send a comment, so proxies
+// don't time them out and disconnected clients are noticed
+const eventKeepAlive = 15 * time.Second
+
+// eventPoll is how often a followed paste is looked at, to notice changes
+// made through other instances. Changes made through this one are sent at once.
+const eventPoll = 5 * time.Second
+
+// pasteEvent is a change of a paste sent to the clients following it
+type pasteEvent struct {
+	kind string
+	meta models.PasteMeta
+}
+
+// pasteEvents delivers the changes of pastes to the streams following them
+type pasteEvents struct {
+	mu          sync.Mutex
+	subscribers map[uuid.UUID][]chan pasteEvent
+	// polls stops the poll of each followed paste, which is shared by its
+	// streams and runs while it has any
+	polls map[uuid.UUID]chan struct{}
+	// streams counts the open streams per client
+	streams map[string]int
+	total   int
+}
+
+// subscribe registers a stream of client following the paste and returns the
+// channel its events are sent to and a function to unregister it. The first
+// stream of a paste starts poll, which must return once stop is closed after
+// the last one unregistered. It returns false when client already has
+// perClient streams open or there are limit streams in total, 0 not limiting
+// them.
+func (e *pasteEvents) subscribe(id uuid.UUID, client string, perClient, limit int, poll func(stop <-chan struct{})) (<-chan pasteEvent, func(), bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if perClient > 0 && e.streams[client] >= perClient || limit > 0 && e.total >= limit {
+		return nil, nil, false
+	}
+	if e.subscribers == nil {
+		e.subscribers = make(map[uuid.UUID][]chan pasteEvent)
+		e.polls = make(map[uuid.UUID]chan struct{})
+		e.streams = make(map[string]int)
+	}
+	if _, ok := e.polls[id]; !ok {
+		stop := make(chan struct{})
+		e.polls[id] = stop
+		go poll(stop)
+	}
+	ch := make(chan pasteEvent, 8)
+	e.subscribers[id] = append(e.subscribers[id], ch)
+	e.streams[client]++
+	e.total++
+
+	return ch, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		e.total--
+		e.streams[client]--
+		if e.streams[client] == 0 {
+			delete(e.streams, client)
+		}
+
+		subscribers := e.subscribers[id]
+		for i, subscriber := range subscribers {
+			if subscriber == ch {
+				subscribers = append(subscribers[:i], subscribers[i+1:]...)
+				break
+			}
+		}
+		if len(subscribers) == 0 {
+			delete(e.subscribers, id)
+			close(e.polls[id])
+			delete(e.polls, id)
+		} else {
+			e.subscribers[id] = subscribers
+		}
+	}, true
+}
+
+// publish sends an event to the streams following the paste. Streams too slow
+// to take it miss it, they get the next change of the paste.
+func (e *pasteEvents) publish(kind string, meta models.PasteMeta) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, ch := range e.subscribers[meta.UUID] {
+		select {
+		case ch <- pasteEvent{kind: kind, meta: meta}:
+		default:
+		}
+	}
+}
+
+// PasteEvents streams the changes of a paste as server-sent events until it
+// is burned, deleted or expires
+func (h *Handler) PasteEvents(c *fiber.Ctx) error {
+	pasteUUID, err := uuid.Parse(c.Params("uuid"))
+	if err != nil {
+		return fail(c, fiber.StatusNotFound, err.Error())
+	}
+
+	var paste models.Paste
+	if err := h.dbFor(c).Omit("content", "data", "thumbnail").First(&paste, "uuid = ?", pasteUUID).Error; err != nil {
+		return fail(c, fiber.StatusNotFound, err.Error())
+	}
+	if !h.canRead(c, paste) || time.Now().After(paste.ExpiryTimestamp) {
+		return fail(c, fiber.StatusNotFound, gorm.ErrRecordNotFound.Error())
+	}
+
+	// Subscribe before answering so no change made meanwhile is missed
+	meta := paste.Meta()
+	events, unsubscribe, ok := h.events.subscribe(paste.UUID, clientip.Get(c), h.config.EventStreamsPerIP, h.config.MaxEventStreams, func(stop <-chan struct{}) {
+		h.pollPaste(meta, stop)
+	})
+	if !ok {
+		return fail(c, fiber.StatusTooManyRequests, "Too many event streams")
+	}
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set("X-Accel-Buffering", "no")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		h.streamEvents(w, meta, events)
+	})
+	return nil
+}
+
+// streamEvents writes the events of a paste to w until the paste is gone,
+// the client disconnects or the server stops
+func (h *Handler) streamEvents(w *bufio.Writer, meta models.PasteMeta, events <-chan pasteEvent) {
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	drain := time.NewTicker(eventPoll)
+	defer drain.Stop()
+	expiry := time.NewTimer(time.Until(meta.ExpiryTimestamp))
+	defer expiry.Stop()
+
+	// Tell the client the stream is open
+	if writeEvent(w, ": connected\n\n") != nil {
+		return
+	}
+	for {
+		var event pasteEvent
+		select {
+		case event = <-events:
+			// The poll also sees the changes made through this instance
+			if event.kind == eventUpdated && event.meta.Views <= meta.Views ||
+				event.kind == eventExtended && !event.meta.ExpiryTimestamp.After(meta.ExpiryTimestamp) {
+				continue
+			}
+		case <-expiry.C:
+			event = pasteEvent{kind: eventExpired, meta: meta}
+		case <-drain.C:
+			// Clients reconnect to another instance while this one drains
+			if h.draining.Load() {
+				return
+			}
+			continue
+		case <-keepAlive.C:
+			if writeEvent(w, ": keep-alive\n\n") != nil {
+				return
+			}
+			continue
+		case <-h.closing:
+			return
+		}
+
+		data, err := json.Marshal(event.meta)
+		if err != nil {
+			h.logger.Error("Error encoding paste event", zap.Error(err))
+			return
+		}
+		if writeEvent(w, fmt.Sprintf("event: %s\ndata: %s\n\n", event.kind, data)) != nil {
+			return
+		}
+		switch event.kind {
+		case eventExtended:
+			if !expiry.Stop() {
+				<-expiry.C
+			}
+			expiry.Reset(time.Until(event.meta.ExpiryTimestamp))
+		case eventUpdated:
+		default:
+			return
+		}
+		meta = event.meta
+	}
+}
+
+// pollPaste looks at the paste every eventPoll until stop is closed and
+// publishes its changes since meta was read, to the streams of this instance
+func (h *Handler) pollPaste(meta models.PasteMeta, stop <-chan struct{}) {
+	poll := time.NewTicker(eventPoll)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-poll.C:
+		case <-stop:
+			return
+		case <-h.closing:
+			return
+		}
+
+		var paste models.Paste
+		err := h.db.Omit("content", "data", "thumbnail").First(&paste, "uuid = ?", meta.UUID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.events.publish(eventDeleted, meta)
+			continue
+		}
+		if err != nil {
+			h.logger.Error("Error polling paste for events", zap.Error(err))
+			continue
+		}
+		switch {
+		case paste.ExpiryTimestamp.After(meta.ExpiryTimestamp):
+			meta = paste.Meta()
+			h.events.publish(eventExtended, meta)
+		case paste.Views != meta.Views:
+			meta = paste.Meta()
+			h.events.publish(eventUpdated, meta)
+		}
+	}
+}
+
+// writeEvent writes and flushes a chunk of the event stream, failing once
+// the client is gone
+func writeEvent(w *bufio.Writer, chunk string) error {
+	if _, err := w.WriteString(chunk); err != nil {
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,73 @@
+package handlers_test
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/handlers"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPasteEventsLimits(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:events_limits?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Migrate(db, log.Default()); err != nil {
+		t.Fatal(err)
+	}
+	paste := models.Paste{UUID: uuid.New(), Content: "Paste A", ExpiryTimestamp: time.Now().Add(time.Hour)}
+	if err := db.Create(&paste).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name string
+		conf config.Config
+	}{
+		{"per client", config.Config{EventStreamsPerIP: 1}},
+		{"in total", config.Config{MaxEventStreams: 1}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			h := handlers.New(&test.conf, log.Default(), db)
+			app := fiber.New(fiber.Config{DisableStartupMessage: true})
+			app.Get("/api/v1/paste/:uuid/events", h.PasteEvents)
+			// Streams need a real connection to stay open
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			go app.Listener(listener)
+			defer app.Shutdown()
+			// Ending the streams first lets the server shut down
+			defer h.Close()
+
+			url := "http://" + listener.Addr().String() + "/api/v1/paste/" + paste.UUID.String() + "/events"
+			first, err := http.Get(url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer first.Body.Close()
+			if first.StatusCode != http.StatusOK {
+				t.Fatalf("expected the first stream to open, got %d", first.StatusCode)
+			}
+			second, err := http.Get(url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			second.Body.Close()
+			if second.StatusCode != http.StatusTooManyRequests {
+				t.Errorf("expected %d over the limit, got %d", http.StatusTooManyRequests, second.StatusCode)
+			}
+		})
+	}
+}
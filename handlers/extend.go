@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coolguy1771/wastebin/audit"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Extended is the new expiry of an extended paste
+type Extended struct {
+	UUID            uuid.UUID `json:"uuid" example:"2b2c0c14-9d0a-4a51-8a5d-6a5a4e1c4f43"`
+	ExpiryTimestamp time.Time `json:"expiry_timestamp" example:"2021-01-01T00:00:00Z"`
+}
+
+// extendError is the status and message of a refused extension
+type extendError struct {
+	status  int
+	message string
+}
+
+func (e *extendError) Error() string {
+	return e.message
+}
+
+// ExtendPaste moves the expiry of a paste to the expires form value in
+// minutes from now, by default the default expiry or the longest one when
+// there is none. It needs the owner token or the admin token as a bearer
+// token.
+func (h *Handler) ExtendPaste(c *fiber.Ctx) error {
+	paste, err := h.extendPaste(c)
+	var refused *extendError
+	if errors.As(err, &refused) {
+		return fail(c, refused.status, refused.message)
+	}
+	if err != nil {
+		return fail(c, fiber.StatusInternalServerError, "Error extending the paste")
+	}
+	return respond(c, Extended{UUID: paste.UUID, ExpiryTimestamp: paste.ExpiryTimestamp})
+}
+
+// extendPaste checks and extends the paste of the request and returns it
+// with its new expiry. Refused extensions are returned as extendError.
+func (h *Handler) extendPaste(c *fiber.Ctx) (models.Paste, error) {
+	var paste models.Paste
+	notFound := &extendError{fiber.StatusNotFound, gorm.ErrRecordNotFound.Error()}
+	pasteUUID, err := uuid.Parse(c.Params("uuid"))
+	if err != nil {
+		return paste, notFound
+	}
+	err = h.dbFor(c).Omit("content", "data", "thumbnail").First(&paste, "uuid = ?", pasteUUID).Error
+	if err != nil || time.Now().After(paste.ExpiryTimestamp) {
+		return paste, notFound
+	}
+
+	admin := h.config.AdminToken != "" && hasBearerToken(c, h.config.AdminToken)
+	auth := c.Get(fiber.HeaderAuthorization)
+	owner := strings.HasPrefix(auth, "Bearer ") && paste.OwnedBy(strings.TrimPrefix(auth, "Bearer "))
+	if !admin && (!owner || paste.Quarantined) {
+		return paste, &extendError{fiber.StatusForbidden, "Only the owner of the paste can extend it"}
+	}
+
+	extension := h.defaultExtension()
+	if value := c.FormValue("expires"); value != "" {
+		limits := Limits(h.config)
+		minutes, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || minutes < limits.MinExpiryMinutes || minutes > limits.MaxExpiryMinutes {
+			return paste, &extendError{fiber.StatusBadRequest, fmt.Sprintf("Expiry must be between %d and %d minutes", limits.MinExpiryMinutes, limits.MaxExpiryMinutes)}
+		}
+		extension = time.Duration(minutes) * time.Minute
+	}
+	expiry := time.Now().Add(extension)
+	if !expiry.After(paste.ExpiryTimestamp) {
+		return paste, &extendError{fiber.StatusBadRequest, "The paste already expires later"}
+	}
+
+	if err := storage.ExtendPaste(h.dbFor(c), paste.UUID, expiry); err != nil {
+		h.requestLogger(c).Error("Error extending paste", zap.Error(err))
+		return paste, err
+	}
+	paste.ExpiryTimestamp = expiry
+	h.pastes.forget(paste.UUID)
+	h.recordAudit(c, audit.ActionPasteExtend, paste.UUID.String())
+	h.events.publish(eventExtended, paste.Meta())
+	return paste, nil
+}
+
+// defaultExtension is how long a paste is kept when it is extended without
+// an expiry
+func (h *Handler) defaultExtension() time.Duration {
+	if h.config.DefaultExpiry > 0 {
+		return h.config.DefaultExpiry
+	}
+	return h.config.MaxExpiry
+}
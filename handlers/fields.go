@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseFields reads the comma separated fields query parameter and returns
+// the JSON names of the requested fields of v. Fields match their JSON name
+// ignoring case and underscores, so expiryTimestamp selects expiry_timestamp.
+func parseFields(c *fiber.Ctx, v interface{}) ([]string, error) {
+	query := c.Query("fields")
+	if query == "" {
+		return nil, nil
+	}
+
+	names := make(map[string]string)
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			names[normalizeField(name)] = name
+		}
+	}
+
+	var fields []string
+	for _, field := range strings.Split(query, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		name, ok := names[normalizeField(field)]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+func normalizeField(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// sendFields responds with v limited to fields, or all of v without fields
+func sendFields(c *fiber.Ctx, v interface{}, fields []string) error {
+	if len(fields) == 0 {
+		return respond(c, v)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+
+	selected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			selected[field] = value
+		}
+	}
+	return respond(c, selected)
+}
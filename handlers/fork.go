@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/coolguy1771/wastebin/clientip"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ForkPaste creates a new paste from the content and metadata of an existing
+// one, recording which paste it was forked from. The fork expires after the
+// optional expires form value in minutes, by default after as long as the
+// original was kept, and has the visibility of the original unless the
+// visibility form value says otherwise.
+func (h *Handler) ForkPaste(c *fiber.Ctx) error {
+	parent, ok, err := h.findStoredPaste(c, c.Params("uuid"), "forked")
+	if !ok {
+		return err
+	}
+
+	var errs fieldErrors
+	limits := Limits(h.config)
+	minLifetime := time.Duration(limits.MinExpiryMinutes) * time.Minute
+	maxLifetime := time.Duration(limits.MaxExpiryMinutes) * time.Minute
+	lifetime := parent.ExpiryTimestamp.Sub(parent.CreatedAt)
+	if value := c.FormValue("expires"); value != "" {
+		minutes, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || minutes < limits.MinExpiryMinutes || minutes > limits.MaxExpiryMinutes {
+			errs.add("expires", fmt.Sprintf("Expiry must be between %d and %d minutes", limits.MinExpiryMinutes, limits.MaxExpiryMinutes))
+		}
+		lifetime = time.Duration(minutes) * time.Minute
+	}
+	if lifetime < minLifetime {
+		lifetime = minLifetime
+	} else if lifetime > maxLifetime {
+		lifetime = maxLifetime
+	}
+	visibility := models.Visibility(c.FormValue("visibility", string(parent.Visibility)))
+	if !visibility.Valid() {
+		errs.add("visibility", "Visibility must be public, unlisted or private")
+	}
+	if len(errs) > 0 {
+		return errs.send(c)
+	}
+
+	fork := models.Paste{
+		Content:         parent.Content,
+		ContentType:     parent.ContentType,
+		Data:            parent.Data,
+		Language:        parent.Language,
+		UUID:            uuid.New(),
+		ExpiryTimestamp: time.Now().Add(lifetime),
+		Title:           parent.Title,
+		Description:     parent.Description,
+		Visibility:      visibility,
+		ParentID:        &parent.UUID,
+	}
+	if fork.Tags, err = storage.PasteTags(h.dbFor(c), parent.UUID); err != nil {
+		return fail(c, fiber.StatusInternalServerError, err.Error())
+	}
+	ownerToken, err := fork.SetOwnerToken()
+	if err != nil {
+		return fail(c, fiber.StatusInternalServerError, err.Error())
+	}
+	fork.Derive()
+
+	reservation, ok, err := h.reserveQuota(c, "ip:"+clientip.Get(c), fork.Size)
+	if !ok {
+		return err
+	}
+	if err := h.savePaste(&fork, reservation); err != nil {
+		h.requestLogger(c).Error("Error saving forked paste to database", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return respond(c, Created{
+		UUID:       fork.UUID,
+		URL:        h.pasteURL(c, fork.UUID),
+		OwnerToken: ownerToken,
+		ForkedFrom: &parent.UUID,
+	})
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coolguy1771/wastebin/abuse"
+	"github.com/coolguy1771/wastebin/alert"
+	"github.com/coolguy1771/wastebin/audit"
+	"github.com/coolguy1771/wastebin/breaker"
+	"github.com/coolguy1771/wastebin/captcha"
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/errorsink"
+	"github.com/coolguy1771/wastebin/health"
+	"github.com/coolguy1771/wastebin/idempotency"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/outbox"
+	"github.com/coolguy1771/wastebin/quota"
+	"github.com/coolguy1771/wastebin/scan"
+	"github.com/coolguy1771/wastebin/stats"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"gorm.io/gorm"
+)
+
+// Handler serves the HTTP API using the dependencies it was created with
+type Handler struct {
+	config *config.Config
+	logger *log.Logger
+	db     *gorm.DB
+	stats  *stats.Collector
+	quota  *quota.Quota
+
+	idempotency *idempotency.Store
+	health      *health.Registry
+	errorSink   errorsink.Sink
+	scanner     scan.Scanner
+	captcha     *captcha.Verifier
+	abuse       *abuse.Detector
+	breaker     *breaker.Breaker
+	outbox      *outbox.Dispatcher
+	alerts      *alert.Notifier
+
+	auditThrottle  *audit.Throttle
+	auditPurgeMu   sync.Mutex
+	lastAuditPurge time.Time
+
+	statsCache statsCache
+
+	started  atomic.Bool
+	draining atomic.Bool
+	// shuttingDown refuses new requests while inFlight are being finished
+	shuttingDown atomic.Bool
+	inFlight     atomic.Int64
+	waiters      pasteWaiters
+	events       pasteEvents
+	pastes       pasteCache
+
+	// closing is closed when the handler is closed to end the event streams
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	// cors holds the active CORS middleware so that the allowed origins can be
+	// swapped when the configuration is reloaded
+	cors atomic.Pointer[fiber.Handler]
+}
+
+// New creates a Handler
+func New(conf *config.Config, logger *log.Logger, db *gorm.DB) *Handler {
+	h := &Handler{
+		config: conf,
+		logger: logger,
+		db:     db,
+		stats:  stats.NewCollector(),
+		quota: quota.New(db, logger,
+			quota.Limits{Pastes: conf.QuotaHourlyPastes, Bytes: conf.QuotaHourlyBytes},
+			quota.Limits{Pastes: conf.QuotaDailyPastes, Bytes: conf.QuotaDailyBytes},
+		),
+		idempotency: idempotency.New(db, logger),
+		closing:     make(chan struct{}),
+
+		auditThrottle: audit.NewThrottle(time.Minute),
+	}
+	h.SetAllowedOrigins(conf.AllowedOrigins)
+	return h
+}
+
+// Close ends the event streams so the server can shut down
+func (h *Handler) Close() {
+	h.closeOnce.Do(func() { close(h.closing) })
+}
+
+// SetBreaker reports the state of the database circuit breaker in the admin
+// overview
+func (h *Handler) SetBreaker(b *breaker.Breaker) {
+	h.breaker = b
+}
+
+// SetOutbox reports the deliveries of the paste events in the admin overview
+func (h *Handler) SetOutbox(d *outbox.Dispatcher) {
+	h.outbox = d
+}
+
+// SetAlerts sends the moderation and abuse alerts to n
+func (h *Handler) SetAlerts(n *alert.Notifier) {
+	h.alerts = n
+}
+
+// SetQuotas replaces the hourly and daily paste quotas
+func (h *Handler) SetQuotas(hourly, daily quota.Limits) {
+	h.quota.SetLimits(hourly, daily)
+}
+
+// Quota returns the paste quotas, to be shared with the other APIs creating
+// pastes so that SetQuotas applies to them as well
+func (h *Handler) Quota() *quota.Quota {
+	return h.quota
+}
+
+// SetAllowedOrigins replaces the origins allowed by the CORS middleware
+func (h *Handler) SetAllowedOrigins(origins string) {
+	handler := cors.New(cors.Config{
+		AllowOrigins: origins,
+	})
+	h.cors.Store(&handler)
+}
+
+// CORS applies the currently configured CORS middleware
+func (h *Handler) CORS(c *fiber.Ctx) error {
+	return (*h.cors.Load())(c)
+}
+
+// CollectStats records the request for the admin overview
+func (h *Handler) CollectStats(c *fiber.Ctx) error {
+	return h.stats.Handler(c)
+}
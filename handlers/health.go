@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/coolguy1771/wastebin/health"
+	"github.com/gofiber/fiber/v2"
+)
+
+// readyTimeout bounds the dependency checks of a readiness probe
+const readyTimeout = 2 * time.Second
+
+// Health is the state of the server reported by the probes
+type Health struct {
+	Status string `json:"status" example:"ready"`
+	// Checks has the outcome of every dependency check of a readiness probe
+	Checks map[string]health.Result `json:"checks,omitempty"`
+}
+
+// SetHealth sets the registry of the checks driving the readiness probe
+func (h *Handler) SetHealth(registry *health.Registry) {
+	h.health = registry
+}
+
+// MarkStarted records that the startup tasks such as migrations are complete
+func (h *Handler) MarkStarted() {
+	h.started.Store(true)
+}
+
+// StartupProbe reports OK once the server finished its startup tasks
+func (h *Handler) StartupProbe(c *fiber.Ctx) error {
+	if !h.started.Load() {
+		return respond(c.Status(fiber.StatusServiceUnavailable), Health{Status: "starting"})
+	}
+	return respond(c, Health{Status: "started"})
+}
+
+// LivenessProbe reports OK while the process serves requests. It doesn't
+// check the dependencies so that their outages don't restart the server.
+func (h *Handler) LivenessProbe(c *fiber.Ctx) error {
+	return respond(c, Health{Status: "alive"})
+}
+
+// ReadinessProbe reports OK when the server can handle requests: it started,
+// isn't draining and its dependencies are reachable
+func (h *Handler) ReadinessProbe(c *fiber.Ctx) error {
+	var checks map[string]health.Result
+	ready := h.started.Load() && !h.draining.Load()
+	if h.health != nil {
+		ctx, cancel := context.WithTimeout(c.UserContext(), readyTimeout)
+		defer cancel()
+		checks = h.health.Check(ctx)
+		ready = ready && health.Healthy(checks)
+	}
+
+	response := Health{Status: "ready", Checks: checks}
+	switch {
+	case !h.started.Load():
+		response.Status = "starting"
+	case h.draining.Load():
+		response.Status = "draining"
+	case !ready:
+		response.Status = "unavailable"
+	}
+	if !ready {
+		return respond(c.Status(fiber.StatusServiceUnavailable), response)
+	}
+	return respond(c, response)
+}
+
+// PreStop begins draining the server ahead of the termination signal.
+// It is only enabled when a lifecycle token is configured.
+func (h *Handler) PreStop(c *fiber.Ctx) error {
+	token := h.config.LifecycleToken
+	if token == "" {
+		return fiber.ErrNotFound
+	}
+	if !hasBearerToken(c, token) {
+		return fail(c, fiber.StatusUnauthorized, "Invalid lifecycle token")
+	}
+
+	if !h.draining.Swap(true) {
+		h.requestLogger(c).Info("Draining the server before shutdown")
+	}
+	return respond(c, Health{Status: "draining"})
+}
+
+// shutdownPollInterval is how often Shutdown checks whether the requests
+// being handled are done
+const shutdownPollInterval = 10 * time.Millisecond
+
+// Drain counts the requests being handled so that shutting down waits for
+// them. It closes keep-alive connections while the server is draining so
+// clients reconnect to another instance, and refuses new requests once it is
+// shutting down.
+func (h *Handler) Drain(c *fiber.Ctx) error {
+	// Counted before checking for the shutdown so Shutdown can't miss it
+	h.inFlight.Add(1)
+	defer h.inFlight.Add(-1)
+	if h.shuttingDown.Load() {
+		c.Context().SetConnectionClose()
+		return fail(c, fiber.StatusServiceUnavailable, "Server is shutting down")
+	}
+	if h.draining.Load() {
+		c.Context().SetConnectionClose()
+	}
+	return c.Next()
+}
+
+// Shutdown refuses new requests and waits until the requests being handled
+// are done or ctx is. It returns the number of requests still running.
+func (h *Handler) Shutdown(ctx context.Context) int64 {
+	h.draining.Store(true)
+	h.shuttingDown.Store(true)
+
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	for {
+		running := h.inFlight.Load()
+		if running == 0 {
+			return 0
+		}
+		select {
+		case <-ctx.Done():
+			return running
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/handlers"
+	"github.com/coolguy1771/wastebin/health"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestStartupProbe(t *testing.T) {
+	h := handlers.New(&config.Config{AllowedOrigins: "*"}, log.Default(), nil)
+	app := fiber.New()
+	app.Get("/health/startup", h.StartupProbe)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/health/startup", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Errorf("expected %d before startup, got %d", fiber.StatusServiceUnavailable, resp.StatusCode)
+	}
+
+	h.MarkStarted()
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/health/startup", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("expected %d after startup, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestPreStop(t *testing.T) {
+	h := handlers.New(&config.Config{AllowedOrigins: "*", LifecycleToken: "secret"}, log.Default(), nil)
+	app := fiber.New()
+	app.Post("/health/prestop", h.PreStop)
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/health/prestop", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("expected %d without a token, got %d", fiber.StatusUnauthorized, resp.StatusCode)
+	}
+
+	req := httptest.NewRequest("POST", "/health/prestop", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("expected %d with the token, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestReadinessProbe(t *testing.T) {
+	h := handlers.New(&config.Config{AllowedOrigins: "*"}, log.Default(), nil)
+	app := fiber.New()
+	app.Get("/health/live", h.LivenessProbe)
+	app.Get("/health/ready", h.ReadinessProbe)
+
+	var dbErr error
+	registry := health.New()
+	registry.Register("database", time.Second, func(ctx context.Context) error {
+		return dbErr
+	})
+	h.SetHealth(registry)
+	h.MarkStarted()
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/health/ready", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("expected %d with the database reachable, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+
+	dbErr = errors.New("connection refused")
+	resp, err = app.Test(httptest.NewRequest("GET", "/health/ready", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body handlers.Health
+	if err := json.NewDecoder(resp.Body).Decode(&handlers.Response{Data: &body}); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusServiceUnavailable || body.Status != "unavailable" || body.Checks["database"].Error != "connection refused" {
+		t.Errorf("unexpected readiness with the database down %d: %+v", resp.StatusCode, body)
+	}
+
+	// The process stays alive while the database is down
+	resp, err = app.Test(httptest.NewRequest("GET", "/health/live", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("expected %d from the liveness probe, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	h := handlers.New(&config.Config{AllowedOrigins: "*"}, log.Default(), nil)
+	app := fiber.New()
+	app.Use(h.Drain)
+	started, release := make(chan struct{}), make(chan struct{})
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		close(started)
+		<-release
+		return c.SendString("done")
+	})
+	app.Get("/fast", func(c *fiber.Ctx) error {
+		return c.SendString("done")
+	})
+
+	slow := make(chan int)
+	go func() {
+		resp, err := app.Test(httptest.NewRequest("GET", "/slow", nil), -1)
+		if err != nil {
+			t.Error(err)
+			slow <- 0
+			return
+		}
+		slow <- resp.StatusCode
+	}()
+	<-started
+
+	// The running request is waited for until the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if running := h.Shutdown(ctx); running != 1 {
+		t.Errorf("expected 1 request still running, got %d", running)
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/fast", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusServiceUnavailable || !resp.Close {
+		t.Errorf("expected new requests refused with the connection closed, got %d", resp.StatusCode)
+	}
+
+	done := make(chan int64)
+	go func() { done <- h.Shutdown(context.Background()) }()
+	close(release)
+	if code := <-slow; code != fiber.StatusOK {
+		t.Errorf("expected the running request to finish, got %d", code)
+	}
+	if running := <-done; running != 0 {
+		t.Errorf("expected no request left running, got %d", running)
+	}
+}
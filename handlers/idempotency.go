@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coolguy1771/wastebin/clientip"
+	"github.com/coolguy1771/wastebin/idempotency"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// maxIdempotencyKeyLength bounds the Idempotency-Key header
+const maxIdempotencyKeyLength = 255
+
+// Idempotent handles requests sent with an Idempotency-Key header once:
+// retries with the same key and request get the stored response. Only
+// successes and client errors that a retry would get again are stored, so
+// that server errors, timeouts, conflicts and rate limits can be retried.
+// Owner tokens are not stored at all.
+func (h *Handler) Idempotent(c *fiber.Ctx) error {
+	key := c.Get("Idempotency-Key")
+	if key == "" {
+		return c.Next()
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return fail(c, fiber.StatusBadRequest, "Idempotency key is too long")
+	}
+
+	client := "ip:" + clientip.Get(c)
+	stored, err := h.idempotency.Begin(client, key, requestHash(c), time.Now())
+	switch {
+	case errors.Is(err, idempotency.ErrMismatch):
+		return fail(c, fiber.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, idempotency.ErrInProgress):
+		return fail(c, fiber.StatusConflict, err.Error())
+	case err != nil:
+		h.requestLogger(c).Error("Error reading idempotency key", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error reading idempotency key")
+	case stored != nil:
+		c.Set("Idempotent-Replayed", "true")
+		c.Type("json")
+		return c.Status(stored.Status).SendString(stored.Body)
+	}
+
+	if err := c.Next(); err != nil {
+		h.abortIdempotency(client, key)
+		return err
+	}
+	if status := c.Response().StatusCode(); !replayable(status) {
+		h.abortIdempotency(client, key)
+	} else if err := h.idempotency.Complete(client, key, status, redactOwnerToken(c.Response().Body())); err != nil {
+		h.requestLogger(c).Error("Error storing idempotent response", zap.Error(err))
+	}
+	return nil
+}
+
+// replayable reports whether a response with status is stored for retries
+func replayable(status int) bool {
+	switch status {
+	case fiber.StatusRequestTimeout, fiber.StatusConflict, fiber.StatusTooManyRequests:
+		return false
+	}
+	return status >= 200 && status < 300 || status >= 400 && status < 500
+}
+
+func (h *Handler) abortIdempotency(client, key string) {
+	if err := h.idempotency.Abort(client, key); err != nil {
+		h.logger.Error("Error releasing idempotency key", zap.Error(err))
+	}
+}
+
+// requestHash hashes what a paste is created from: the body and, for raw
+// uploads, the options of the query string and the X-Paste-* headers
+func requestHash(c *fiber.Ctx) string {
+	var headers []string
+	c.Request().Header.VisitAll(func(key, value []byte) {
+		if name := string(key); strings.HasPrefix(strings.ToLower(name), "x-paste-") {
+			headers = append(headers, strings.ToLower(name)+": "+string(value))
+		}
+	})
+	sort.Strings(headers)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n%s\n", c.Method(), c.OriginalURL(), c.Get(fiber.HeaderContentType))
+	for _, header := range headers {
+		fmt.Fprintln(hash, header)
+	}
+	hash.Write([]byte("\n"))
+	hash.Write(c.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// redactOwnerToken removes the owner token from a response so that it is
+// only ever sent to the first request
+func redactOwnerToken(body []byte) []byte {
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(body, &response); err != nil {
+		return body
+	}
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(response["data"], &data); err != nil {
+		return body
+	}
+	if _, ok := data["owner_token"]; !ok {
+		return body
+	}
+	delete(data, "owner_token")
+	response["data"], _ = json.Marshal(data)
+	redacted, _ := json.Marshal(response)
+	return redacted
+}
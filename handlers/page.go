@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"html"
+	"io/fs"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Limits of the content previewed to crawlers
+const (
+	previewLines  = 3
+	previewLength = 200
+)
+
+// crawlers are the user agents of the link previews of chats and social
+// networks, lowercased
+var crawlers = []string{
+	"slackbot", "discordbot", "twitterbot", "facebookexternalhit", "linkedinbot",
+	"telegrambot", "whatsapp", "mattermost", "skypeuripreview", "redditbot",
+	"embedly", "googlebot", "bingbot", "applebot",
+}
+
+// isCrawler reports whether the user agent is a link preview crawler
+func isCrawler(userAgent string) bool {
+	userAgent = strings.ToLower(userAgent)
+	for _, crawler := range crawlers {
+		if strings.Contains(userAgent, crawler) {
+			return true
+		}
+	}
+	return false
+}
+
+// PastePage serves the index.html page of the frontend files with the title
+// and description of the paste as meta tags, so link previews describe the
+// paste. Crawlers get a page with only the meta tags, describing pastes
+// without a description with the first lines of their content.
+func (h *Handler) PastePage(files fs.FS) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		crawler := isCrawler(c.Get(fiber.HeaderUserAgent))
+		meta := h.pasteMeta(c, c.Params("uuid"), crawler)
+
+		// The page embeds the paste metadata, which changes when it is deleted
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		c.Type("html")
+		if crawler && meta != "" {
+			return c.SendString(`<!DOCTYPE html><html><head><meta charset="utf-8" />` + meta + `</head><body></body></html>`)
+		}
+
+		page, err := fs.ReadFile(files, "index.html")
+		if err != nil {
+			return err
+		}
+		if meta != "" {
+			page = bytes.Replace(page, []byte("</head>"), []byte(meta+"</head>"), 1)
+		}
+		return c.Send(page)
+	}
+}
+
+// pasteMeta returns the OpenGraph and Twitter card meta tags of a paste.
+// Burn after reading pastes are not consumed by link previews, only their
+// metadata is read. With preview, pastes without a title or description are
+// described by their content. Private pastes have no preview.
+func (h *Handler) pasteMeta(c *fiber.Ctx, id string, preview bool) string {
+	pasteUUID, err := uuid.Parse(id)
+	if err != nil {
+		return ""
+	}
+
+	columns := []string{"title", "description", "language", "burn", "expiry_timestamp", "visibility", "publish_at", "quarantined", "content_type"}
+	if preview {
+		columns = append(columns, "content")
+	}
+	var paste models.Paste
+	err = h.dbFor(c).Select(columns).Limit(1).Find(&paste, "uuid = ?", pasteUUID).Error
+	if err != nil {
+		h.requestLogger(c).Error("Error retrieving paste metadata", zap.Error(err))
+		return ""
+	}
+	if time.Now().After(paste.ExpiryTimestamp) || paste.Visibility == models.VisibilityPrivate || !paste.Published(time.Now()) || paste.Quarantined {
+		return ""
+	}
+
+	var meta strings.Builder
+	// Let oEmbed consumers discover how to embed the paste
+	if !paste.Burn && !paste.Attachment() {
+		oembed := h.baseURL(c) + "/services/oembed?url=" + url.QueryEscape(h.pasteURL(c, pasteUUID))
+		meta.WriteString(`<link rel="alternate" type="application/json+oembed" href="` + html.EscapeString(oembed) + `" />`)
+	}
+
+	title, description := paste.Title, paste.Description
+	if preview && !paste.Burn {
+		if title == "" {
+			title = "Paste"
+			if paste.Language != "" {
+				title += " (" + paste.Language + ")"
+			}
+		}
+		if description == "" && !paste.Attachment() {
+			description = contentPreview(paste.Content)
+		}
+	}
+	if title == "" && description == "" {
+		return meta.String()
+	}
+
+	meta.WriteString(`<meta property="og:type" content="article" />`)
+	meta.WriteString(`<meta property="og:site_name" content="Wastebin" />`)
+	meta.WriteString(`<meta property="og:url" content="` + html.EscapeString(h.pasteURL(c, pasteUUID)) + `" />`)
+	meta.WriteString(`<meta name="twitter:card" content="summary" />`)
+	if title != "" {
+		meta.WriteString(`<meta property="og:title" content="` + html.EscapeString(title) + `" />`)
+		meta.WriteString(`<meta name="twitter:title" content="` + html.EscapeString(title) + `" />`)
+		meta.WriteString(`<title>` + html.EscapeString(title) + `</title>`)
+	}
+	if description != "" {
+		meta.WriteString(`<meta name="description" content="` + html.EscapeString(description) + `" />`)
+		meta.WriteString(`<meta property="og:description" content="` + html.EscapeString(description) + `" />`)
+		meta.WriteString(`<meta name="twitter:description" content="` + html.EscapeString(description) + `" />`)
+	}
+	return meta.String()
+}
+
+// contentPreview returns the first lines of content on one line, truncated
+// to previewLength characters
+func contentPreview(content string) string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+		if len(lines) == previewLines {
+			break
+		}
+	}
+	preview := strings.Join(lines, " ")
+	if utf8.RuneCountInString(preview) <= previewLength {
+		return preview
+	}
+	return string([]rune(preview)[:previewLength-1]) + "…"
+}
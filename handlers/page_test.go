@@ -0,0 +1,141 @@
+package handlers_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/handlers"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPastePage(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:page?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Migrate(db, log.Default()); err != nil {
+		t.Fatal(err)
+	}
+
+	paste := models.Paste{
+		Content:         "Paste A",
+		Burn:            true,
+		UUID:            uuid.New(),
+		ExpiryTimestamp: time.Now().Add(time.Hour),
+		Title:           `Deploy <script>`,
+		Description:     "Rolls out staging",
+	}
+	if err := db.Create(&paste).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("<html><head></head><body></body></html>"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	h := handlers.New(&config.Config{AllowedOrigins: "*", BaseURL: "https://paste.example.com/"}, log.Default(), db)
+	app := fiber.New()
+	app.Get("/paste/:uuid", h.PastePage(os.DirFS(dir)))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/paste/"+paste.UUID.String(), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	for _, tag := range []string{
+		`<meta property="og:title" content="Deploy &lt;script&gt;" />`,
+		`<meta property="og:description" content="Rolls out staging" />`,
+		`<meta property="og:url" content="https://paste.example.com/paste/` + paste.UUID.String() + `" />`,
+	} {
+		if !strings.Contains(string(body), tag) {
+			t.Errorf("expected %s in %s", tag, body)
+		}
+	}
+
+	// Link previews must not burn the paste
+	var count int64
+	db.Model(&models.Paste{}).Where("uuid = ?", paste.UUID).Count(&count)
+	if count != 1 {
+		t.Error("expected the burn after reading paste to be kept")
+	}
+}
+
+func TestPastePageCrawler(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:page_crawler?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Migrate(db, log.Default()); err != nil {
+		t.Fatal(err)
+	}
+
+	paste := models.Paste{
+		Content:         "\n#!/bin/sh\nset -e\nkubectl apply -f <staging>\necho done\n",
+		Language:        "bash",
+		UUID:            uuid.New(),
+		ExpiryTimestamp: time.Now().Add(time.Hour),
+	}
+	secret := models.Paste{
+		Content:         "Burn me",
+		Burn:            true,
+		UUID:            uuid.New(),
+		ExpiryTimestamp: time.Now().Add(time.Hour),
+	}
+	if err := db.Create([]*models.Paste{&paste, &secret}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("<html><head></head><body>SPA</body></html>"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	h := handlers.New(&config.Config{AllowedOrigins: "*", BaseURL: "https://paste.example.com/"}, log.Default(), db)
+	app := fiber.New()
+	app.Get("/paste/:uuid", h.PastePage(os.DirFS(dir)))
+
+	get := func(id uuid.UUID, userAgent string) string {
+		req := httptest.NewRequest("GET", "/paste/"+id.String(), nil)
+		req.Header.Set("User-Agent", userAgent)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	body := get(paste.UUID, "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)")
+	if strings.Contains(body, "SPA") {
+		t.Errorf("expected crawlers to get the meta tags only, got %s", body)
+	}
+	for _, tag := range []string{
+		`<meta property="og:title" content="Paste (bash)" />`,
+		`<meta property="og:description" content="#!/bin/sh set -e kubectl apply -f &lt;staging&gt;" />`,
+		`<meta name="twitter:card" content="summary" />`,
+	} {
+		if !strings.Contains(body, tag) {
+			t.Errorf("expected %s in %s", tag, body)
+		}
+	}
+
+	if body := get(paste.UUID, "Mozilla/5.0"); !strings.Contains(body, "SPA") || strings.Contains(body, "og:description") {
+		t.Errorf("expected browsers to get the frontend without a content preview, got %s", body)
+	}
+	if body := get(secret.UUID, "Discordbot/2.0"); strings.Contains(body, "Burn me") {
+		t.Errorf("expected burn after reading pastes not to be previewed, got %s", body)
+	}
+}
@@ -1,134 +1,433 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/alert"
+	"github.com/coolguy1771/wastebin/audit"
+	"github.com/coolguy1771/wastebin/captcha"
+	"github.com/coolguy1771/wastebin/clientip"
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/language"
 	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/quota"
+	"github.com/coolguy1771/wastebin/scan"
 	"github.com/coolguy1771/wastebin/storage"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
-func GetRawPaste(c *fiber.Ctx) error {
+// MinExpiryMinutes is the shortest lifetime of a paste, the longest and the
+// size of pastes are configured
+const MinExpiryMinutes = 1
+
+// Longest optional texts accepted, as checked by the validate tags of
+// models.CreatePasteRequest
+const (
+	MaxLanguageLength    = 64
+	MaxTitleLength       = 256
+	MaxDescriptionLength = 1024
+)
+
+// Created describes a paste that was created
+type Created struct {
+	UUID uuid.UUID `json:"uuid" example:"2b2c0c14-9d0a-4a51-8a5d-6a5a4e1c4f43"`
+	URL  string    `json:"url" example:"https://paste.example.com/paste/2b2c0c14-9d0a-4a51-8a5d-6a5a4e1c4f43"`
+	// OwnerToken deletes and extends the paste, and reads it when it is private
+	OwnerToken string `json:"owner_token,omitempty"`
+	// Warning tells what the content scanners found in the paste
+	Warning string `json:"warning,omitempty"`
+	// ForkedFrom is the paste a fork was created from
+	ForkedFrom *uuid.UUID `json:"forked_from,omitempty"`
+}
+
+// PasteLimits describes the pastes accepted by CreatePaste so that clients
+// can check a paste before uploading it
+type PasteLimits struct {
+	MaxSize          int   `json:"max_size" example:"4194304"`
+	MinExpiryMinutes int64 `json:"min_expiry_minutes" example:"1"`
+	MaxExpiryMinutes int64 `json:"max_expiry_minutes" example:"525600"`
+	// DefaultExpiryMinutes is used when no expiry is given, expires is
+	// required when it is 0
+	DefaultExpiryMinutes int64 `json:"default_expiry_minutes" example:"1440"`
+	// Languages is empty when any language is accepted
+	Languages []string `json:"languages"`
+	Burn      bool     `json:"burn" example:"true"`
+	Permanent bool     `json:"permanent" example:"false"`
+	// MaxAttachmentSize is 0 when binary pastes are refused
+	MaxAttachmentSize int `json:"max_attachment_size" example:"10485760"`
+	// Captcha is set when creating pastes anonymously needs a captcha
+	Captcha *CaptchaSettings `json:"captcha,omitempty"`
+}
+
+// Limits returns the limits of the pastes accepted with conf
+func Limits(conf *config.Config) PasteLimits {
+	limits := PasteLimits{
+		MaxSize:              conf.MaxPasteSize,
+		MaxAttachmentSize:    conf.MaxAttachmentSize,
+		MinExpiryMinutes:     MinExpiryMinutes,
+		MaxExpiryMinutes:     int64(conf.MaxExpiry / time.Minute),
+		DefaultExpiryMinutes: int64(conf.DefaultExpiry / time.Minute),
+		Languages:            conf.AllowedLanguageList(),
+		Burn:                 true,
+		Permanent:            false,
+	}
+	if conf.CaptchaProvider != "" {
+		limits.Captcha = &CaptchaSettings{
+			Provider: conf.CaptchaProvider,
+			SiteKey:  conf.CaptchaSiteKey,
+			Field:    captcha.ResponseField(conf.CaptchaProvider),
+		}
+	}
+	return limits
+}
+
+// AllowsLanguage reports whether pastes may be in language, which is case
+// insensitive. Pastes without a language are always allowed.
+func (l PasteLimits) AllowsLanguage(language string) bool {
+	if language == "" || len(l.Languages) == 0 {
+		return true
+	}
+	language = strings.ToLower(language)
+	for _, allowed := range l.Languages {
+		if allowed == language {
+			return true
+		}
+	}
+	return false
+}
+
+// detectLanguage guesses the language of a paste created without one when
+// detection is enabled, as long as the guess is allowed
+func detectLanguage(conf *config.Config, limits PasteLimits, content string) string {
+	if !conf.DetectLanguage {
+		return ""
+	}
+	if detected := language.Detect(content); limits.AllowsLanguage(detected) {
+		return detected
+	}
+	return ""
+}
+
+// GetPasteLimits returns the limits of the pastes accepted by CreatePaste
+func (h *Handler) GetPasteLimits(c *fiber.Ctx) error {
+	return respond(c, Limits(h.config))
+}
+
+func (h *Handler) GetRawPaste(c *fiber.Ctx) error {
 	pasteUUID, err := uuid.Parse(c.Params("uuid"))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(map[string]string{"error": err.Error()})
+		return fail(c, fiber.StatusNotFound, err.Error())
 	}
 
-	// Retrieve the paste from the database
-	paste := models.Paste{}
-	if err := storage.DBConn.First(&paste, "uuid = ?", pasteUUID).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(map[string]string{"error": err.Error()})
+	// Retrieve the paste from the cache or the database
+	paste, err := h.findPaste(c, pasteUUID)
+	if err != nil {
+		return fail(c, fiber.StatusNotFound, err.Error())
+	}
+	// Private pastes are hidden from whoever doesn't own them
+	if !h.canRead(c, paste) {
+		return fail(c, fiber.StatusNotFound, gorm.ErrRecordNotFound.Error())
 	}
 
 	// Check if the paste has expired
 	if time.Now().After(paste.ExpiryTimestamp) {
-		if err := storage.DBConn.Where("uuid = ?", pasteUUID).Delete(&paste).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(map[string]string{"error": err.Error()})
+		if c.Method() == fiber.MethodHead {
+			return c.SendStatus(fiber.StatusNotFound)
 		}
-		return c.JSON(map[string]string{"message": "Paste expired and deleted"})
+		h.pastes.forget(pasteUUID)
+		if _, err := storage.DeletePaste(h.dbFor(c), pasteUUID, models.EventPasteExpired); err != nil {
+			return fail(c, fiber.StatusInternalServerError, err.Error())
+		}
+		return respond(c, Message{Message: "Paste expired and deleted"})
 	}
 
-	// Check if the paste should be deleted after reading
-	if paste.Burn {
-		if err := storage.DBConn.Delete(&paste).Error; err != nil {
-			log.Error("Error deleting paste after reading", zap.Error(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(map[string]string{"error": "Error deleting paste after reading"})
+	// Delete the paste if it should be deleted after reading, otherwise count the view
+	if err := h.readPaste(c, &paste); errors.Is(err, gorm.ErrRecordNotFound) {
+		return fail(c, fiber.StatusNotFound, err.Error())
+	} else if err != nil {
+		h.requestLogger(c).Error("Error deleting paste after reading", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error deleting paste after reading")
+	}
+	setPasteHeaders(c, paste)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+
+	// Attachments are served with their declared type, sandboxed so that
+	// HTML or SVG ones can't run scripts on this origin. Images are displayed
+	// by browsers, other attachments are downloaded.
+	if paste.Attachment() {
+		c.Set(fiber.HeaderContentType, paste.ContentType)
+		c.Set(fiber.HeaderContentSecurityPolicy, "sandbox; default-src 'none'")
+		if paste.Image() {
+			c.Set(fiber.HeaderContentDisposition, "inline")
+		} else {
+			c.Attachment(paste.UUID.String() + attachmentExtension(paste.ContentType))
 		}
+		return sendContent(c, paste.Data, !paste.Burn)
 	}
 
 	// Set the Content-Type header to the appropriate MIME type for the paste's file extension
 	c.Type("text/plain")
 
-	// Send the raw paste as the response
-	return c.SendString(paste.Content)
+	// Send the raw paste as the response, large pastes being read in ranges
+	// unless they burn
+	return sendContent(c, []byte(paste.Content), !paste.Burn)
 }
 
 // GetPaste retrieves a paste by its UUID.
 // If the paste has expired or is set to be deleted after reading, it is deleted from the database.
-func GetPaste(c *fiber.Ctx) error {
+// HEAD requests leave the paste as it is.
+func (h *Handler) GetPaste(c *fiber.Ctx) error {
 	// Read the paste UUID from the URL parameter
 	pasteUUID, err := uuid.Parse(c.Params("uuid"))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(map[string]string{"error": err.Error()})
+		return fail(c, fiber.StatusNotFound, err.Error())
+	}
+	// Validate the requested fields before a burn paste is deleted
+	fields, err := parseFields(c, models.Paste{})
+	if err != nil {
+		return fail(c, fiber.StatusBadRequest, err.Error())
+	}
+	wait, err := parseWait(c)
+	if err != nil {
+		return fail(c, fiber.StatusBadRequest, err.Error())
 	}
-	log.Debug("Retrieving paste", zap.String("uuid", pasteUUID.String()))
+	h.requestLogger(c).Debug("Retrieving paste", zap.String("uuid", pasteUUID.String()))
 
-	// Retrieve the paste from the database
-	paste := models.Paste{}
-	if err := storage.DBConn.First(&paste, "uuid = ?", pasteUUID).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(map[string]string{"error": err.Error()})
+	// Retrieve the paste from the cache or the database, waiting for it to be
+	// created if asked to
+	paste, err := h.findPaste(c, pasteUUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) && wait > 0 {
+		paste, err = h.waitForPaste(pasteUUID, wait)
+	}
+	if err != nil {
+		return fail(c, fiber.StatusNotFound, err.Error())
+	}
+	h.requestLogger(c).Debug("Retrieved paste", zap.String("uuid", pasteUUID.String()))
+	// Private pastes are hidden from whoever doesn't own them
+	if !h.canRead(c, paste) {
+		return fail(c, fiber.StatusNotFound, gorm.ErrRecordNotFound.Error())
 	}
-	log.Debug("Retrieved paste", zap.String("uuid", pasteUUID.String()))
 
 	// Check if the paste has expired
 	if time.Now().After(paste.ExpiryTimestamp) {
-		if err := storage.DBConn.Delete(&paste).Error; err != nil {
-			log.Error("Error deleting expired paste from the database", zap.Error(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(map[string]string{"error": "Error deleting expired paste from the database"})
+		if c.Method() == fiber.MethodHead {
+			return c.SendStatus(fiber.StatusNotFound)
 		}
-		return c.JSON(map[string]string{"message": "Paste expired and deleted"})
+		h.pastes.forget(pasteUUID)
+		if _, err := storage.DeletePaste(h.dbFor(c), pasteUUID, models.EventPasteExpired); err != nil {
+			h.requestLogger(c).Error("Error deleting expired paste from the database", zap.Error(err))
+			return fail(c, fiber.StatusInternalServerError, "Error deleting expired paste from the database")
+		}
+		return respond(c, Message{Message: "Paste expired and deleted"})
 	}
 
-	// Check if the paste should be deleted after reading
-	if paste.Burn {
-		if err := storage.DBConn.Delete(&paste).Error; err != nil {
-			log.Error("Error deleting paste after reading", zap.Error(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(map[string]string{"error": "Error deleting paste after reading"})
-		}
+	if paste.Tags, err = storage.PasteTags(h.dbFor(c), pasteUUID); err != nil {
+		h.requestLogger(c).Error("Error retrieving paste tags", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error retrieving paste tags")
+	}
+	if paste.Annotations, err = storage.PasteAnnotations(h.dbFor(c), pasteUUID); err != nil {
+		h.requestLogger(c).Error("Error retrieving paste annotations", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error retrieving paste annotations")
+	}
+
+	// Delete the paste if it should be deleted after reading, otherwise count the view
+	if err := h.readPaste(c, &paste); errors.Is(err, gorm.ErrRecordNotFound) {
+		return fail(c, fiber.StatusNotFound, err.Error())
+	} else if err != nil {
+		h.requestLogger(c).Error("Error deleting paste after reading", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error deleting paste after reading")
 	}
-	log.Info("Returning paste", zap.String("uuid", pasteUUID.String()))
+	setPasteHeaders(c, paste)
+	// Large pastes are previewed unless their full content is asked for.
+	// Burn after reading pastes can't be read again, so they are never cut.
+	if !paste.Burn && c.Query("full") != "true" {
+		h.truncatePaste(c, &paste)
+	}
+	h.requestLogger(c).Info("Returning paste", zap.String("uuid", pasteUUID.String()))
 	// Return the paste content
-	return c.JSON(paste)
+	return sendFields(c, paste, fields)
 }
 
-func CreatePaste(c *fiber.Ctx) error {
-	log.Info("CreatePaste called")
+func (h *Handler) CreatePaste(c *fiber.Ctx) error {
+	h.requestLogger(c).Info("CreatePaste called")
+	// Validate every field before answering so that clients can fix all of
+	// them at once
+	var errs fieldErrors
+	limits := Limits(h.config)
+
+	// The settings of the client fill in the omitted fields
+	settings, err := h.clientSettings(c)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		h.requestLogger(c).Error("Error reading client settings", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error reading the settings")
+	}
+
 	// Parse the request body
-	expireTime, err := strconv.ParseInt(c.FormValue("expires"), 10, 64)
+	expires := pasteValue(c, "expires")
+	if expires == "" && settings.Expires > 0 {
+		expires = strconv.FormatInt(settings.Expires, 10)
+	}
+	if expires == "" && limits.DefaultExpiryMinutes > 0 {
+		expires = strconv.FormatInt(limits.DefaultExpiryMinutes, 10)
+	}
+	expireTime, err := strconv.ParseInt(expires, 10, 64)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(map[string]string{"error": err.Error()})
+		errs.add("expires", "Expiry must be a number of minutes")
+	} else if expireTime < limits.MinExpiryMinutes || expireTime > limits.MaxExpiryMinutes {
+		errs.add("expires", fmt.Sprintf("Expiry must be between %d and %d minutes", limits.MinExpiryMinutes, limits.MaxExpiryMinutes))
 	}
+	burn := pasteValue(c, "burn")
 	req := models.CreatePasteRequest{
-		Content:  c.FormValue("text"),
-		Burn:     c.FormValue("burn") == "true",
-		Language: c.FormValue("extension"),
+		Content:     pasteValue(c, "text"),
+		Burn:        burn == "true" || burn == "" && settings.Burn,
+		Language:    pasteValue(c, "extension"),
+		Title:       pasteValue(c, "title"),
+		Description: pasteValue(c, "description"),
 		// Convert the expires value to an int64 and add it to the current time
 		ExpiryTime: time.Now().Add(time.Duration(expireTime) * time.Minute).Format(time.RFC3339),
 	}
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(map[string]string{"error": err.Error()})
+	if !isRawUpload(c) {
+		if err := Bind(c, &req, 0); err != nil {
+			return sendBindError(c, err)
+		}
 	}
-	log.Info("CreatePaste request", zap.Any("request", req))
-	if req.ExpiryTime == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(map[string]string{"error": "Expiry time cannot be empty"})
+	h.requestLogger(c).Info("CreatePaste request", zap.Any("request", req))
+
+	var expiryTimestamp time.Time
+	if !errs.has("expires") {
+		if req.ExpiryTime == "" {
+			errs.add("expires", "Expiry time cannot be empty")
+		} else if expiryTimestamp, err = time.Parse(time.RFC3339, req.ExpiryTime); err != nil {
+			// Parse the expiry time in the RFC 3339 format
+			errs.add("expires", "Invalid expiry time format")
+		} else if expiryTimestamp.Before(time.Now()) {
+			errs.add("expires", "Expiry time must be in the future")
+		} else if expiryTimestamp.After(time.Now().Add(time.Duration(limits.MaxExpiryMinutes) * time.Minute)) {
+			errs.add("expires", fmt.Sprintf("Expiry must be within %d minutes", limits.MaxExpiryMinutes))
+		}
 	}
-	// Parse the expiry time in the RFC 3339 format
-	expiryTimestamp, err := time.Parse(time.RFC3339, req.ExpiryTime)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(map[string]string{"error": "Invalid expiry time format"})
+
+	// Attachments are binary content served with their declared MIME type,
+	// capped separately from text
+	contentType := pasteValue(c, "content_type")
+	maxSize := limits.MaxSize
+	if contentType != "" {
+		maxSize = limits.MaxAttachmentSize
+		if maxSize == 0 {
+			errs.add("content_type", "Attachments are not accepted")
+		} else if mediaType, params, err := mime.ParseMediaType(contentType); err != nil {
+			errs.add("content_type", "Invalid MIME type")
+		} else {
+			contentType = mime.FormatMediaType(mediaType, params)
+		}
+	}
+
+	// A file uploaded with a multipart form replaces the text value
+	contentField := "text"
+	if file, err := c.FormFile("file"); err == nil {
+		contentField = "file"
+		if req.Content, err = readUpload(file, maxSize); err != nil {
+			h.requestLogger(c).Error("Error reading uploaded file", zap.Error(err))
+			return fail(c, fiber.StatusInternalServerError, "Error reading uploaded file")
+		}
+		if lang := fileLanguage(file.Filename); req.Language == "" && contentType == "" && len(lang) <= MaxLanguageLength && limits.AllowsLanguage(lang) {
+			req.Language = lang
+		}
 	}
-	if expiryTimestamp.Before(time.Now()) {
-		return c.Status(fiber.StatusBadRequest).JSON(map[string]string{"error": "Expiry time must be in the future"})
+
+	if req.Language == "" && contentType == "" {
+		req.Language = settings.Language
 	}
 
 	// Validate the other fields
 	if req.Content == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(map[string]string{"error": "Content cannot be empty"})
+		errs.add(contentField, "Content cannot be empty")
+	} else if len(req.Content) > maxSize && !errs.has("content_type") {
+		errs.add(contentField, fmt.Sprintf("Content cannot be larger than %d bytes", maxSize))
+	}
+	errs.check(req)
+	if !errs.has("extension") {
+		if !limits.AllowsLanguage(req.Language) {
+			errs.add("extension", fmt.Sprintf("Language %q is not allowed", req.Language))
+		} else if req.Language == "" && contentType == "" {
+			req.Language = detectLanguage(h.config, limits, req.Content)
+		}
+	}
+	tags, err := parseTags(pasteValue(c, "tags"))
+	if err != nil {
+		errs.add("tags", err.Error())
+	}
+	defaultVisibility := models.VisibilityUnlisted
+	if settings.Visibility != "" {
+		defaultVisibility = settings.Visibility
+	}
+	visibility := models.Visibility(pasteValue(c, "visibility", string(defaultVisibility)))
+	if !visibility.Valid() {
+		errs.add("visibility", "Visibility must be public, unlisted or private")
+	}
+	// Embargoed pastes are only readable by their owner until they are published
+	var publishAt *time.Time
+	if value := pasteValue(c, "publish_at"); value != "" {
+		if t, err := time.Parse(time.RFC3339, value); err != nil {
+			errs.add("publish_at", "Invalid publication time format")
+		} else if t.Before(time.Now()) {
+			errs.add("publish_at", "Publication time must be in the future")
+		} else if !expiryTimestamp.IsZero() && !t.Before(expiryTimestamp) {
+			errs.add("publish_at", "Publication time must be before the expiry time")
+		} else {
+			publishAt = &t
+		}
 	}
 
-	log.Debug("Paste request body has been validated", zap.Any("request", req))
+	// Use the UUID chosen by the client so that it can be shared before the
+	// paste is uploaded, otherwise generate one
+	pasteUUID, err := uuid.Parse(pasteValue(c, "uuid"))
+	chosenUUID := pasteValue(c, "uuid") != ""
+	if chosenUUID && err != nil {
+		errs.add("uuid", "Invalid UUID")
+	}
 
-	// Generate a UUID for the paste
-	pasteUUID, err := uuid.NewRandom()
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(map[string]string{"error": err.Error()})
+	if len(errs) > 0 {
+		return errs.send(c)
+	}
+
+	// Check the content for abuse
+	findings, action := h.scanContent(c, req.Content)
+	if action == scan.ActionReject {
+		reasons := scan.Reasons(findings)
+		h.recordAudit(c, audit.ActionPasteReject, reasons)
+		h.sendAlert(alert.Alert{Kind: audit.ActionPasteReject, Title: "Paste rejected by the content scanner", Text: "Findings: " + reasons, Key: clientip.Get(c)})
+		errs.add("text", "Content rejected: "+reasons)
+		return errs.send(c)
 	}
-	log.Info("Generated UUID", zap.String("uuid", pasteUUID.String()))
+
+	if chosenUUID {
+		var existing int64
+		if err := h.dbFor(c).Model(&models.Paste{}).Where("uuid = ?", pasteUUID).Count(&existing).Error; err != nil {
+			return fail(c, fiber.StatusInternalServerError, err.Error())
+		}
+		if existing > 0 {
+			return fail(c, fiber.StatusConflict, "Paste already exists")
+		}
+	} else {
+		if pasteUUID, err = uuid.NewRandom(); err != nil {
+			return fail(c, fiber.StatusInternalServerError, err.Error())
+		}
+		h.requestLogger(c).Info("Generated UUID", zap.String("uuid", pasteUUID.String()))
+	}
+
+	h.requestLogger(c).Debug("Paste request body has been validated", zap.Any("request", req))
 
 	// Save the paste to the database
 	paste := models.Paste{
@@ -137,36 +436,210 @@ func CreatePaste(c *fiber.Ctx) error {
 		Language:        req.Language,
 		UUID:            pasteUUID,
 		ExpiryTimestamp: expiryTimestamp,
+		Title:           req.Title,
+		Description:     req.Description,
+		Tags:            tags,
+		Visibility:      visibility,
+		PublishAt:       publishAt,
+		Quarantined:     action == scan.ActionQuarantine,
+	}
+	if contentType != "" {
+		paste.ContentType = contentType
+		paste.Data = []byte(paste.Content)
+		paste.Content = ""
+	}
+	// The owner token, only returned to the creator, deletes and extends the
+	// paste and reads it while it is private or embargoed
+	ownerToken, err := paste.SetOwnerToken()
+	if err != nil {
+		return fail(c, fiber.StatusInternalServerError, err.Error())
 	}
-	log.Debug("created paste object", zap.Any("paste", paste))
+	paste.Derive()
+	h.requestLogger(c).Debug("created paste object", zap.Any("paste", paste))
 
-	if err := storage.DBConn.Create(&paste).Error; err != nil {
-		log.Error("Error saving paste to database", zap.Error(err))
-		return c.Status(fiber.StatusInternalServerError).JSON(map[string]string{"error": err.Error()})
+	// Reserve the paste in the creation quotas of the client
+	reservation, ok, err := h.reserveQuota(c, "ip:"+clientip.Get(c), paste.Size)
+	if !ok {
+		return err
+	}
+	if err := h.savePaste(&paste, reservation); err != nil {
+		h.requestLogger(c).Error("Error saving paste to database", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, err.Error())
+	}
+	if len(findings) > 0 {
+		h.recordFindings(c, paste.UUID, findings, action)
 	}
-	log.Info("Paste saved to database", zap.String("uuid", pasteUUID.String()))
 	// Return the UUID of the newly created paste in the response body
-	response := map[string]string{
-		"message": "Paste created",
-		"uuid":    pasteUUID.String(),
+	response := Created{
+		UUID:       pasteUUID,
+		URL:        h.pasteURL(c, pasteUUID),
+		OwnerToken: ownerToken,
+	}
+	if len(findings) > 0 {
+		response.Warning = scanWarning(findings, action)
 	}
-	return c.JSON(response)
+	return respond(c, response)
 }
 
-func DeletePaste(c *fiber.Ctx) error {
-	// Read the paste UUID from the URL query string
-	pasteUUID, err := uuid.Parse(c.Query("uuid"))
+// readPaste records that a paste was read: burn after reading pastes are
+// deleted and the views of the others are counted. HEAD requests don't read
+// the paste. Of concurrent reads of a burn after reading paste, only the one
+// deleting it succeeds, the others get gorm.ErrRecordNotFound.
+func (h *Handler) readPaste(c *fiber.Ctx, paste *models.Paste) error {
+	if c.Method() == fiber.MethodHead {
+		return nil
+	}
+	if paste.Burn {
+		deleted, err := storage.DeletePaste(h.db, paste.UUID, models.EventPasteBurned)
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		h.recordAudit(c, audit.ActionPasteBurn, paste.UUID.String())
+		h.events.publish(eventBurned, paste.Meta())
+		return nil
+	}
+	// A view that couldn't be counted doesn't fail the request
+	if err := storage.CountView(h.dbFor(c), paste.UUID); err != nil {
+		h.requestLogger(c).Error("Error counting paste view", zap.Error(err))
+		return nil
+	}
+	paste.Views++
+	h.events.publish(eventUpdated, paste.Meta())
+	return nil
+}
+
+// setPasteHeaders describes the paste in the response headers, so that HEAD
+// requests tell when it was created and expires and whether it changed
+func setPasteHeaders(c *fiber.Ctx, paste models.Paste) {
+	c.Set(fiber.HeaderLastModified, paste.CreatedAt.UTC().Format(http.TimeFormat))
+	c.Set(fiber.HeaderExpires, paste.ExpiryTimestamp.UTC().Format(http.TimeFormat))
+	if paste.Checksum != "" {
+		c.Set(fiber.HeaderETag, `"`+paste.Checksum+`"`)
+	}
+}
+
+// attachmentExtension returns the file extension of the MIME type, if known
+func attachmentExtension(contentType string) string {
+	extensions, err := mime.ExtensionsByType(contentType)
+	if err != nil || len(extensions) == 0 {
+		return ""
+	}
+	return extensions[0]
+}
+
+// GetPasteMeta returns the metadata of a paste without its content. Unlike
+// reading the paste it neither burns it nor counts a view.
+func (h *Handler) GetPasteMeta(c *fiber.Ctx) error {
+	pasteUUID, err := uuid.Parse(c.Params("uuid"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(map[string]string{"error": err.Error()})
+		return fail(c, fiber.StatusNotFound, err.Error())
 	}
-	// Delete the paste from the database
+	fields, err := parseFields(c, models.PasteMeta{})
+	if err != nil {
+		return fail(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	var paste models.Paste
+	if err := h.dbFor(c).Omit("content", "data", "thumbnail").First(&paste, "uuid = ?", pasteUUID).Error; err != nil {
+		return fail(c, fiber.StatusNotFound, err.Error())
+	}
+	if !h.canRead(c, paste) || time.Now().After(paste.ExpiryTimestamp) {
+		return fail(c, fiber.StatusNotFound, gorm.ErrRecordNotFound.Error())
+	}
+
+	setPasteHeaders(c, paste)
+	return sendFields(c, paste.Meta(), fields)
+}
+
+// findStoredPaste returns the paste with the id for a request that reads it
+// without consuming it, so burn after reading pastes are refused as they
+// cannot be action. When the paste can't be used, the response has been sent
+// and its error is returned.
+func (h *Handler) findStoredPaste(c *fiber.Ctx, id, action string) (models.Paste, bool, error) {
 	var paste models.Paste
-	if err := storage.DBConn.First(&paste, "uuid = ?", pasteUUID).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(map[string]string{"error": err.Error()})
+	pasteUUID, err := uuid.Parse(id)
+	if err != nil {
+		return paste, false, fail(c, fiber.StatusNotFound, err.Error())
+	}
+	if err := h.dbFor(c).First(&paste, "uuid = ?", pasteUUID).Error; err != nil {
+		return paste, false, fail(c, fiber.StatusNotFound, err.Error())
+	}
+	if !h.canRead(c, paste) || time.Now().After(paste.ExpiryTimestamp) {
+		return paste, false, fail(c, fiber.StatusNotFound, gorm.ErrRecordNotFound.Error())
+	}
+	// Using it would read a burn after reading paste without burning it
+	if paste.Burn {
+		return paste, false, fail(c, fiber.StatusBadRequest, "Burn after reading pastes cannot be "+action)
+	}
+	return paste, true, nil
+}
+
+// reserveQuota reserves a paste of size bytes in the quotas of the client
+// identified by key. When the quotas are exceeded, the response has been
+// sent and its error is returned.
+func (h *Handler) reserveQuota(c *fiber.Ctx, key string, size int64) (*quota.Reservation, bool, error) {
+	reservation, err := h.quota.Reserve(key, size, time.Now())
+	if err == nil {
+		return reservation, true, nil
 	}
-	if err := storage.DBConn.Where("uuid = ?", pasteUUID).Delete(&paste).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(map[string]string{"error": err.Error()})
+	var exceeded *quota.ExceededError
+	if errors.As(err, &exceeded) {
+		h.requestLogger(c).Warn("Paste quota exceeded", zap.String("client", key), zap.String("window", exceeded.Window))
+		h.recordThrottledAudit(c, audit.ActionQuotaExceeded, exceeded.Window)
+		h.sendAlert(alert.Alert{Kind: audit.ActionQuotaExceeded, Title: "Paste quota exceeded", Text: "Window: " + exceeded.Window, Key: key})
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(time.Until(exceeded.Reset).Seconds()))))
+		return nil, false, failWith(c, fiber.StatusTooManyRequests, &Error{Message: "Quota exceeded", Details: exceeded})
+	}
+	h.requestLogger(c).Error("Error reserving paste quota", zap.Error(err))
+	return nil, false, fail(c, fiber.StatusInternalServerError, err.Error())
+}
+
+// savePaste stores a new paste and wakes the requests waiting for it. The
+// quota reserved for it is released when it cannot be stored.
+func (h *Handler) savePaste(paste *models.Paste, reservation *quota.Reservation) error {
+	if err := storage.CreatePaste(h.db, paste); err != nil {
+		if err := h.quota.Release(reservation); err != nil {
+			h.logger.Error("Error releasing paste quota", zap.Error(err))
+		}
+		return err
+	}
+	h.logger.Info("Paste saved to database", zap.String("uuid", paste.UUID.String()))
+	h.waiters.notify(paste.UUID)
+	return nil
+}
+
+// DeletePaste deletes a paste with its owner token or the admin token
+func (h *Handler) DeletePaste(c *fiber.Ctx) error {
+	// Read the paste UUID from the URL path
+	pasteUUID, err := uuid.Parse(c.Params("uuid"))
+	if err != nil {
+		return fail(c, fiber.StatusBadRequest, err.Error())
+	}
+	var paste models.Paste
+	if err := h.dbFor(c).First(&paste, "uuid = ?", pasteUUID).Error; err != nil {
+		return fail(c, fiber.StatusNotFound, err.Error())
+	}
+	if !h.canRead(c, paste) {
+		return fail(c, fiber.StatusNotFound, "Paste not found")
+	}
+	// Only the owner of the paste or an admin can delete it
+	admin := h.config.AdminToken != "" && hasBearerToken(c, h.config.AdminToken)
+	auth := c.Get(fiber.HeaderAuthorization)
+	owner := strings.HasPrefix(auth, "Bearer ") && paste.OwnedBy(strings.TrimPrefix(auth, "Bearer "))
+	if !admin && !owner {
+		return fail(c, fiber.StatusForbidden, "Only the owner of the paste can delete it")
+	}
+
+	// Delete the paste from the database
+	if _, err := storage.DeletePaste(h.dbFor(c), pasteUUID, models.EventPasteDeleted); err != nil {
+		return fail(c, fiber.StatusInternalServerError, err.Error())
 	}
+	h.pastes.forget(pasteUUID)
+	h.recordAudit(c, audit.ActionPasteDelete, pasteUUID.String())
+	h.events.publish(eventDeleted, paste.Meta())
 
-	return c.JSON(map[string]string{"message": "Paste deleted"})
+	return respond(c, Message{Message: "Paste deleted"})
 }
@@ -1,9 +1,21 @@
 package handlers_test
 
 import (
-	"bytes"
-	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/handlers"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 func TestCreatePaste(t *testing.T) {
@@ -20,6 +32,116 @@ func TestGetPaste(t *testing.T) {
 	// Get Non Existent Paste
 }
 
+func TestBurnPaste(t *testing.T) {
+	// Concurrent writes need a database file, in memory they fail as locked
+	db, err := gorm.Open(sqlite.Open("file:"+filepath.Join(t.TempDir(), "burn.db")+"?_busy_timeout=5000&_txlock=immediate"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Migrate(db, log.Default()); err != nil {
+		t.Fatal(err)
+	}
+	paste := models.Paste{UUID: uuid.New(), Content: "Paste A", Burn: true, ExpiryTimestamp: time.Now().Add(time.Hour)}
+	if err := db.Create(&paste).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	h := handlers.New(&config.Config{}, log.Default(), db)
+	app := fiber.New()
+	app.Get("/api/v1/paste/:uuid", h.GetPaste)
+
+	const readers = 32
+	codes := make(chan int, readers)
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/api/v1/paste/"+paste.UUID.String(), nil), -1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			codes <- resp.StatusCode
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	read := 0
+	for code := range codes {
+		switch code {
+		case fiber.StatusOK:
+			read++
+		case fiber.StatusNotFound:
+		default:
+			t.Errorf("unexpected status %d reading a burned paste", code)
+		}
+	}
+	if read != 1 {
+		t.Errorf("expected the paste read once, read %d times", read)
+	}
+
+	var burned int64
+	db.Model(&models.OutboxEvent{}).Where("type = ?", models.EventPasteBurned).Count(&burned)
+	if burned != 1 {
+		t.Errorf("expected one burn event, got %d", burned)
+	}
+}
+
 func TestDeletePaste(t *testing.T) {
-	// TODO
+	db, err := gorm.Open(sqlite.Open("file:delete_paste?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Migrate(db, log.Default()); err != nil {
+		t.Fatal(err)
+	}
+
+	private := models.Paste{UUID: uuid.New(), Content: "Paste A", Visibility: models.VisibilityPrivate, ExpiryTimestamp: time.Now().Add(time.Hour)}
+	ownerToken, err := private.SetOwnerToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	public := models.Paste{UUID: uuid.New(), Content: "Paste B", ExpiryTimestamp: time.Now().Add(time.Hour)}
+	if err := db.Create([]*models.Paste{&private, &public}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	h := handlers.New(&config.Config{AdminToken: "admin"}, log.Default(), db)
+	app := fiber.New()
+	app.Delete("/api/v1/paste/:uuid", h.DeletePaste)
+	remove := func(id uuid.UUID, token string) int {
+		req := httptest.NewRequest(fiber.MethodDelete, "/api/v1/paste/"+id.String(), nil)
+		if token != "" {
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	if code := remove(private.UUID, ""); code != fiber.StatusNotFound {
+		t.Errorf("expected %d deleting a private paste anonymously, got %d", fiber.StatusNotFound, code)
+	}
+	if code := remove(public.UUID, ""); code != fiber.StatusForbidden {
+		t.Errorf("expected %d deleting a public paste anonymously, got %d", fiber.StatusForbidden, code)
+	}
+	if code := remove(private.UUID, "wrong"); code != fiber.StatusNotFound {
+		t.Errorf("expected %d deleting a private paste with a wrong token, got %d", fiber.StatusNotFound, code)
+	}
+	if code := remove(private.UUID, ownerToken); code != fiber.StatusOK {
+		t.Errorf("expected the owner to delete the paste, got %d", code)
+	}
+	if code := remove(public.UUID, "admin"); code != fiber.StatusOK {
+		t.Errorf("expected the admin to delete the paste, got %d", code)
+	}
+
+	var count int64
+	db.Model(&models.Paste{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected the pastes deleted, %d left", count)
+	}
 }
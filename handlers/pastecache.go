@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+)
+
+// pasteCache keeps the pastes read recently in memory, least recently used
+// first out, and merges the concurrent reads of a paste into one query so
+// that a burst of requests for a shared link doesn't query the database for
+// every one. Burn after reading pastes are never shared between requests
+// and only published pastes are cached.
+type pasteCache struct {
+	group singleflight.Group
+
+	mu      sync.Mutex
+	lru     list.List
+	entries map[uuid.UUID]*list.Element
+	size    int64
+}
+
+type cachedPaste struct {
+	paste   models.Paste
+	expires time.Time
+	size    int64
+}
+
+// findPaste returns the paste, from the cache when it was read less than
+// PASTE_CACHE_TTL ago
+func (h *Handler) findPaste(c *fiber.Ctx, id uuid.UUID) (models.Paste, error) {
+	now := time.Now()
+	if paste, ok := h.pastes.get(id, now); ok {
+		return paste, nil
+	}
+
+	// The query is shared between requests, so it isn't bound to the
+	// deadline of the one making it
+	value, err, shared := h.pastes.group.Do(id.String(), func() (interface{}, error) {
+		var paste models.Paste
+		err := h.db.First(&paste, "uuid = ?", id).Error
+		return paste, err
+	})
+	if err != nil {
+		return models.Paste{}, err
+	}
+	paste := value.(models.Paste)
+	if paste.Burn {
+		if shared {
+			// Every request reads the paste itself so only one gets to burn it
+			var own models.Paste
+			err := h.dbFor(c).First(&own, "uuid = ?", id).Error
+			return own, err
+		}
+		return paste, nil
+	}
+	// Embargoed pastes are read again so their publication date can be
+	// brought forward
+	if !paste.Published(now) {
+		return paste, nil
+	}
+	h.pastes.add(paste, now.Add(h.config.PasteCacheTTL), int64(h.config.PasteCacheSize))
+	return paste, nil
+}
+
+// get returns the cached paste unless it expired
+func (p *pasteCache) get(id uuid.UUID, now time.Time) (models.Paste, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	element, ok := p.entries[id]
+	if !ok {
+		return models.Paste{}, false
+	}
+	entry := element.Value.(*cachedPaste)
+	if !now.Before(entry.expires) {
+		p.remove(element)
+		return models.Paste{}, false
+	}
+	p.lru.MoveToFront(element)
+	return entry.paste, true
+}
+
+// add caches the paste until expires, evicting the least recently used
+// pastes to stay within capacity bytes. Pastes larger than the capacity
+// aren't cached.
+func (p *pasteCache) add(paste models.Paste, expires time.Time, capacity int64) {
+	size := int64(len(paste.Content) + len(paste.Data) + len(paste.Thumbnail))
+	if capacity <= 0 || size > capacity || !time.Now().Before(expires) {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.entries == nil {
+		p.entries = make(map[uuid.UUID]*list.Element)
+	}
+	if element, ok := p.entries[paste.UUID]; ok {
+		p.remove(element)
+	}
+	p.entries[paste.UUID] = p.lru.PushFront(&cachedPaste{paste: paste, expires: expires, size: size})
+	p.size += size
+	for p.size > capacity {
+		p.remove(p.lru.Back())
+	}
+}
+
+// forget drops the paste from the cache after it changed or was deleted
+func (p *pasteCache) forget(id uuid.UUID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if element, ok := p.entries[id]; ok {
+		p.remove(element)
+	}
+}
+
+func (p *pasteCache) remove(element *list.Element) {
+	entry := p.lru.Remove(element).(*cachedPaste)
+	delete(p.entries, entry.paste.UUID)
+	p.size -= entry.size
+}
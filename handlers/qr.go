@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Sizes in pixels of the QR codes of paste links
+const (
+	defaultQRSize = 256
+	minQRSize     = 64
+	maxQRSize     = 1024
+)
+
+// GetPasteQR renders a PNG QR code of the link to a paste page, so it can be
+// opened on another device. The paste is neither read nor burned.
+func (h *Handler) GetPasteQR(c *fiber.Ctx) error {
+	pasteUUID, err := uuid.Parse(c.Params("uuid"))
+	if err != nil {
+		return fail(c, fiber.StatusNotFound, err.Error())
+	}
+	size := defaultQRSize
+	if value := c.Query("size"); value != "" {
+		size, err = strconv.Atoi(value)
+		if err != nil || size < minQRSize || size > maxQRSize {
+			return fail(c, fiber.StatusBadRequest, fmt.Sprintf("Size must be between %d and %d pixels", minQRSize, maxQRSize))
+		}
+	}
+
+	var paste models.Paste
+	if err := h.dbFor(c).Select("uuid", "expiry_timestamp", "visibility", "owner_token_hash").First(&paste, "uuid = ?", pasteUUID).Error; err != nil {
+		return fail(c, fiber.StatusNotFound, err.Error())
+	}
+	if !h.canRead(c, paste) || time.Now().After(paste.ExpiryTimestamp) {
+		return fail(c, fiber.StatusNotFound, gorm.ErrRecordNotFound.Error())
+	}
+
+	png, err := qrcode.Encode(h.pasteURL(c, paste.UUID), qrcode.Medium, size)
+	if err != nil {
+		h.requestLogger(c).Error("Error rendering paste QR code", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error rendering the QR code")
+	}
+
+	c.Type("png")
+	c.Set(fiber.HeaderExpires, paste.ExpiryTimestamp.UTC().Format(http.TimeFormat))
+	return c.Send(png)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/coolguy1771/wastebin/clientip"
+	"github.com/coolguy1771/wastebin/errorsink"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// SetErrorSink reports the panics and server errors of the requests to sink
+func (h *Handler) SetErrorSink(sink errorsink.Sink) {
+	h.errorSink = sink
+}
+
+// Recover turns the panics of the next handlers into 500 responses and
+// reports them and every other 5xx response to the error sink
+func (h *Handler) Recover(c *fiber.Ctx) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := string(debug.Stack())
+			message := fmt.Sprintf("panic: %v", r)
+			h.requestLogger(c).Error("Recovered from panic", zap.Any("panic", r), zap.String("path", c.Path()), errorsink.Reported)
+			err = fail(c, fiber.StatusInternalServerError, "Internal server error")
+			h.reportError(c, fiber.StatusInternalServerError, message, stack)
+		}
+	}()
+
+	err = c.Next()
+	if status := responseStatus(c, err); status >= fiber.StatusInternalServerError {
+		message := fmt.Sprintf("%s %s responded %d", c.Method(), c.Route().Path, status)
+		if err != nil {
+			message += ": " + err.Error()
+		}
+		h.reportError(c, status, message, "")
+	}
+	return err
+}
+
+// reportError sends an error of the request to the error sink, if any
+func (h *Handler) reportError(c *fiber.Ctx, status int, message, stack string) {
+	if h.errorSink == nil {
+		return
+	}
+	h.errorSink.Capture(errorsink.Event{
+		Timestamp: time.Now(),
+		Level:     errorsink.LevelError,
+		Message:   message,
+		Stack:     stack,
+		Request: &errorsink.Request{
+			Method:    c.Method(),
+			URL:       c.BaseURL() + c.Path(),
+			Status:    status,
+			ClientIP:  clientip.Get(c),
+			RequestID: requestID(c),
+			TraceID:   traceID(c),
+		},
+	})
+}
@@ -0,0 +1,67 @@
+package handlers_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/errorsink"
+	"github.com/coolguy1771/wastebin/handlers"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/gofiber/fiber/v2"
+)
+
+type recordingSink struct {
+	mu     sync.Mutex
+	events []errorsink.Event
+}
+
+func (s *recordingSink) Capture(event errorsink.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+func (s *recordingSink) Close(time.Duration) {}
+
+func TestRecover(t *testing.T) {
+	sink := &recordingSink{}
+	h := handlers.New(&config.Config{AllowedOrigins: "*"}, log.Default(), nil)
+	h.SetErrorSink(sink)
+	app := fiber.New()
+	app.Use(h.Recover)
+	app.Get("/panic", func(c *fiber.Ctx) error { panic("boom") })
+	app.Get("/unavailable", func(c *fiber.Ctx) error { return fiber.ErrServiceUnavailable })
+	app.Get("/missing", func(c *fiber.Ctx) error { return fiber.ErrNotFound })
+
+	req := httptest.NewRequest("GET", "/panic", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusInternalServerError {
+		t.Errorf("expected %d after a panic, got %d", fiber.StatusInternalServerError, resp.StatusCode)
+	}
+
+	for _, path := range []string{"/unavailable", "/missing"} {
+		if _, err := app.Test(httptest.NewRequest("GET", path, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(sink.events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", sink.events)
+	}
+	panicked, unavailable := sink.events[0], sink.events[1]
+	if panicked.Message != "panic: boom" || !strings.Contains(panicked.Stack, "goroutine") ||
+		panicked.Request.Status != fiber.StatusInternalServerError || panicked.Request.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("unexpected panic event %+v", panicked)
+	}
+	if unavailable.Request.Status != fiber.StatusServiceUnavailable || unavailable.Request.URL != "http://example.com/unavailable" {
+		t.Errorf("unexpected 5xx event %+v %+v", unavailable, unavailable.Request)
+	}
+}
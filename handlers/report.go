@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coolguy1771/wastebin/alert"
+	"github.com/coolguy1771/wastebin/audit"
+	"github.com/coolguy1771/wastebin/clientip"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// MaxReportReasonLength is the longest reason a paste can be reported for
+const MaxReportReasonLength = 500
+
+// reportRequest is the report of a paste
+type reportRequest struct {
+	Reason string `json:"reason" form:"reason" validate:"required,max=500"`
+}
+
+// Limits of the reports listed for moderation
+const (
+	defaultReportsLimit = 100
+	maxReportsLimit     = 1000
+)
+
+// ReportPaste reports an abusive paste for moderation with the reason of the
+// form or JSON body. Every client reports a paste once and at most
+// REPORT_HOURLY_LIMIT pastes an hour. Pastes reported REPORT_HIDE_THRESHOLD
+// times are hidden until the admin releases them.
+func (h *Handler) ReportPaste(c *fiber.Ctx) error {
+	paste, ok, err := h.findStoredPaste(c, c.Params("uuid"), "reported")
+	if !ok {
+		return err
+	}
+
+	var req reportRequest
+	if err := Bind(c, &req, maxFormSize); err != nil {
+		return sendBindError(c, err)
+	}
+	var errs fieldErrors
+	reason := strings.TrimSpace(req.Reason)
+	errs.check(reportRequest{Reason: reason})
+	if len(errs) > 0 {
+		return errs.send(c)
+	}
+
+	reporter := clientip.Get(c)
+	if h.config.ReportHourlyLimit > 0 {
+		reports, err := storage.CountReportsBy(h.dbFor(c), reporter, time.Now().Add(-time.Hour))
+		if err != nil {
+			h.requestLogger(c).Error("Error counting paste reports", zap.Error(err))
+			return fail(c, fiber.StatusInternalServerError, "Error reporting paste")
+		}
+		if reports >= int64(h.config.ReportHourlyLimit) {
+			h.recordThrottledAudit(c, audit.ActionRateLimited, c.Method()+" "+c.Path())
+			return fail(c, fiber.StatusTooManyRequests, "Too many reports, try again later")
+		}
+	}
+
+	reports, err := storage.ReportPaste(h.dbFor(c), &models.PasteReport{PasteUUID: paste.UUID, Reporter: reporter, Reason: reason})
+	if errors.Is(err, storage.ErrAlreadyReported) {
+		return fail(c, fiber.StatusConflict, "Paste already reported")
+	}
+	if err != nil {
+		h.requestLogger(c).Error("Error reporting paste", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error reporting paste")
+	}
+	h.recordAudit(c, audit.ActionPasteReport, paste.UUID.String())
+	h.sendAlert(alert.Alert{
+		Kind:  audit.ActionPasteReport,
+		Title: fmt.Sprintf("Paste reported (%d reports)", reports),
+		Text:  "Reason: " + reason,
+		URL:   h.pasteURL(c, paste.UUID),
+	})
+
+	// Hide the paste once enough clients reported it
+	if threshold := h.config.ReportHideThreshold; threshold > 0 && reports >= int64(threshold) && !paste.Quarantined {
+		if err := storage.QuarantinePaste(h.db, paste.UUID); err != nil {
+			h.requestLogger(c).Error("Error hiding reported paste", zap.Error(err))
+		} else {
+			h.pastes.forget(paste.UUID)
+			h.requestLogger(c).Warn("Hid reported paste", zap.String("uuid", paste.UUID.String()), zap.Int64("reports", reports))
+			h.recordAudit(c, audit.ActionPasteQuarantine, paste.UUID.String())
+			h.sendAlert(alert.Alert{
+				Kind:  audit.ActionPasteQuarantine,
+				Title: fmt.Sprintf("Paste hidden after %d reports", reports),
+				Text:  "Release or delete it from the moderation queue",
+				URL:   h.pasteURL(c, paste.UUID),
+			})
+		}
+	}
+	return respond(c, Message{Message: "Paste reported"})
+}
+
+// ListReports returns the reports waiting for moderation, newest first, up
+// to the limit query parameter
+func (h *Handler) ListReports(c *fiber.Ctx) error {
+	limit := defaultReportsLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxReportsLimit {
+			return fail(c, fiber.StatusBadRequest, fmt.Sprintf("Limit must be between 1 and %d", maxReportsLimit))
+		}
+	}
+
+	reports, err := storage.ListReports(h.dbFor(c), limit)
+	if err != nil {
+		h.requestLogger(c).Error("Error listing paste reports", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error listing paste reports")
+	}
+	return respond(c, reports)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// maxRequestIDLength is the longest X-Request-ID header taken from a request
+const maxRequestIDLength = 128
+
+// Keys of the request and trace IDs in the locals of a request
+const (
+	requestIDKey = "requestid"
+	traceIDKey   = "traceid"
+)
+
+// RequestID identifies every request by the X-Request-ID header it came with
+// or a new UUID, echoes it in the response and makes the request's logger,
+// see requestLogger, include it and the trace ID of the traceparent header
+func (h *Handler) RequestID(c *fiber.Ctx) error {
+	id := c.Get(fiber.HeaderXRequestID)
+	if !validRequestID(id) {
+		id = uuid.NewString()
+	}
+	c.Set(fiber.HeaderXRequestID, id)
+	c.Locals(requestIDKey, id)
+
+	fields := []zap.Field{zap.String("request_id", id)}
+	if trace := parseTraceParent(c.Get("traceparent")); trace != "" {
+		c.Locals(traceIDKey, trace)
+		fields = append(fields, zap.String("trace_id", trace))
+	}
+	c.SetUserContext(log.NewContext(c.UserContext(), h.logger.With(fields...)))
+	return c.Next()
+}
+
+// requestLogger returns the logger of the request, which includes its request
+// and trace IDs when the RequestID middleware ran
+func (h *Handler) requestLogger(c *fiber.Ctx) *log.Logger {
+	if _, ok := c.Locals(requestIDKey).(string); ok {
+		return log.FromContext(c.UserContext())
+	}
+	return h.logger
+}
+
+// requestID returns the ID of the request set by the RequestID middleware
+func requestID(c *fiber.Ctx) string {
+	id, _ := c.Locals(requestIDKey).(string)
+	return id
+}
+
+// traceID returns the trace ID of the traceparent header of the request
+func traceID(c *fiber.Ctx) string {
+	if id, ok := c.Locals(traceIDKey).(string); ok {
+		return id
+	}
+	return parseTraceParent(c.Get("traceparent"))
+}
+
+// validRequestID reports whether a request ID is short and printable enough
+// to be logged and echoed
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTraceParent returns the trace ID of a W3C traceparent header, which is
+// version-traceid-parentid-flags
+func parseTraceParent(header string) string {
+	parts := strings.Split(header, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || strings.Trim(parts[1], "0123456789abcdef") != "" {
+		return ""
+	}
+	return parts[1]
+}
@@ -0,0 +1,57 @@
+package handlers_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/handlers"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func TestRequestID(t *testing.T) {
+	var output strings.Builder
+	logger, err := log.New(&output, "INFO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handlers.New(&config.Config{AllowedOrigins: "*"}, logger, nil)
+	app := fiber.New()
+	app.Use(h.RequestID)
+	app.Get("/log", func(c *fiber.Ctx) error {
+		log.FromContext(c.UserContext()).Info("Handled")
+		return fiber.ErrTeapot
+	})
+
+	req := httptest.NewRequest("GET", "/log", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "abc-123")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := resp.Header.Get(fiber.HeaderXRequestID); resp.StatusCode != fiber.StatusTeapot || id != "abc-123" {
+		t.Errorf("expected the request ID echoed on the error response, got %d %q", resp.StatusCode, id)
+	}
+	if !strings.Contains(output.String(), `"request_id":"abc-123"`) || !strings.Contains(output.String(), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`) {
+		t.Errorf("expected the request and trace IDs logged, got %s", output.String())
+	}
+
+	// Missing and unusable IDs are replaced
+	for _, header := range []string{"", "line\nbreak", strings.Repeat("a", 129)} {
+		req := httptest.NewRequest("GET", "/missing", nil)
+		if header != "" {
+			req.Header.Set(fiber.HeaderXRequestID, header)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := uuid.Parse(resp.Header.Get(fiber.HeaderXRequestID)); err != nil {
+			t.Errorf("expected a generated request ID for %q, got %q", header, resp.Header.Get(fiber.HeaderXRequestID))
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// apiVersionKey is the key of the API version in the locals of a request
+const apiVersionKey = "apiversion"
+
+// Response is the envelope of the JSON responses: the data of the requests
+// that succeeded or the error of those that failed, and the meta of the
+// request either way
+type Response struct {
+	Data  interface{} `json:"data,omitempty"`
+	Error *Error      `json:"error,omitempty"`
+	Meta  Meta        `json:"meta"`
+}
+
+// Error describes why a request failed
+type Error struct {
+	Message string `json:"message" example:"record not found"`
+	// Fields lists every invalid field of a request failing validation
+	Fields []FieldError `json:"fields,omitempty"`
+	// Details describe the failure further, such as the quota exceeded
+	Details interface{} `json:"details,omitempty"`
+}
+
+// Meta describes the request answered
+type Meta struct {
+	RequestID string `json:"request_id,omitempty"`
+	// APIVersion is the version of the API routes, unset for the others
+	APIVersion string `json:"api_version,omitempty" example:"v1"`
+}
+
+// Message is the data of the requests that only succeed or fail
+type Message struct {
+	Message string `json:"message" example:"Paste deleted"`
+}
+
+// APIVersion makes the responses of the routes report the version of the
+// API they belong to
+func APIVersion(version string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(apiVersionKey, version)
+		return c.Next()
+	}
+}
+
+// ErrorHandler answers the errors returned by the handlers and middlewares,
+// such as the routes not found, with the error envelope
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	message := "Internal server error"
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		status, message = fiberErr.Code, fiberErr.Message
+	}
+	return fail(c, status, message)
+}
+
+// respond sends data in the envelope, with the status set on c or 200
+func respond(c *fiber.Ctx, data interface{}) error {
+	return c.JSON(Response{Data: data, Meta: responseMeta(c)})
+}
+
+// fail sends the error message in the envelope with status
+func fail(c *fiber.Ctx, status int, message string) error {
+	return failWith(c, status, &Error{Message: message})
+}
+
+// failWith sends the error in the envelope with status
+func failWith(c *fiber.Ctx, status int, err *Error) error {
+	return c.Status(status).JSON(Response{Error: err, Meta: responseMeta(c)})
+}
+
+func responseMeta(c *fiber.Ctx) Meta {
+	version, _ := c.Locals(apiVersionKey).(string)
+	return Meta{RequestID: requestID(c), APIVersion: version}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/coolguy1771/wastebin/alert"
+	"github.com/coolguy1771/wastebin/audit"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/scan"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Limits of the findings listed for review
+const (
+	defaultFindingsLimit = 100
+	maxFindingsLimit     = 1000
+)
+
+// SetScanner sets the scanner checking the content of new pastes
+func (h *Handler) SetScanner(scanner scan.Scanner) {
+	h.scanner = scanner
+}
+
+// scanContent runs the content scanner on a new paste and returns its
+// findings with the strictest action. When the scanner fails the error is
+// logged and the paste is let through with what was found so far.
+func (h *Handler) scanContent(c *fiber.Ctx, content string) ([]scan.Finding, scan.Action) {
+	if h.scanner == nil {
+		return nil, ""
+	}
+	findings, err := h.scanner.Scan(c.UserContext(), content)
+	if err != nil {
+		h.requestLogger(c).Error("Error scanning paste content", zap.Error(err))
+	}
+	return findings, scan.Strictest(findings)
+}
+
+// scanWarning tells the creator of a paste what the scanners found in it
+func scanWarning(findings []scan.Finding, action scan.Action) string {
+	if action == scan.ActionQuarantine {
+		return "Paste quarantined until it is reviewed, it looks like it contains: " + scan.Reasons(findings)
+	}
+	return "Paste flagged for review, it looks like it contains: " + scan.Reasons(findings)
+}
+
+// recordFindings keeps the findings of a quarantined or flagged paste for
+// admin review
+func (h *Handler) recordFindings(c *fiber.Ctx, paste uuid.UUID, findings []scan.Finding, action scan.Action) {
+	records := make([]models.ScanFinding, 0, len(findings))
+	for _, finding := range findings {
+		records = append(records, finding.Record(paste))
+	}
+	if err := storage.AddFindings(h.db, records); err != nil {
+		h.requestLogger(c).Error("Error recording scan findings", zap.Error(err))
+	}
+	kind, title := audit.ActionPasteFlag, "Paste flagged by the content scanner"
+	if action == scan.ActionQuarantine {
+		kind, title = audit.ActionPasteQuarantine, "Paste quarantined by the content scanner"
+	}
+	h.recordAudit(c, kind, paste.String())
+	h.sendAlert(alert.Alert{Kind: kind, Title: title, Text: "Findings: " + scan.Reasons(findings), URL: h.pasteURL(c, paste)})
+}
+
+// ListFindings returns the findings of the quarantined and flagged pastes
+// waiting for review, newest first, up to the limit query parameter
+func (h *Handler) ListFindings(c *fiber.Ctx) error {
+	limit := defaultFindingsLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			limit = 0
+		}
+	}
+	if limit < 1 || limit > maxFindingsLimit {
+		return fail(c, fiber.StatusBadRequest, fmt.Sprintf("Limit must be between 1 and %d", maxFindingsLimit))
+	}
+
+	findings, err := storage.ListFindings(h.dbFor(c), limit)
+	if err != nil {
+		h.requestLogger(c).Error("Error listing scan findings", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error listing scan findings")
+	}
+	return respond(c, findings)
+}
+
+// ReleasePaste marks a quarantined, flagged or reported paste as reviewed,
+// making a quarantined one readable again. Pastes found to be abusive are
+// deleted instead.
+func (h *Handler) ReleasePaste(c *fiber.Ctx) error {
+	pasteUUID, err := uuid.Parse(c.Params("uuid"))
+	if err != nil {
+		return fail(c, fiber.StatusNotFound, err.Error())
+	}
+	released, err := storage.ReleasePaste(h.dbFor(c), pasteUUID)
+	if err != nil {
+		h.requestLogger(c).Error("Error releasing paste", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error releasing paste")
+	}
+	if released == 0 {
+		return fail(c, fiber.StatusNotFound, "Paste not found")
+	}
+	h.pastes.forget(pasteUUID)
+	h.recordAudit(c, audit.ActionPasteRelease, pasteUUID.String())
+	return respond(c, Message{Message: "Paste released"})
+}
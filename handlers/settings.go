@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// maxSettingsBodySize bounds the body of a settings update
+const maxSettingsBodySize = 4096
+
+// settingsTokenHeader selects the settings of a client. It is kept apart from
+// the Authorization header as it only selects defaults and grants nothing.
+const settingsTokenHeader = "X-Settings-Token"
+
+// SavedSettings are the settings of a client
+type SavedSettings struct {
+	models.UserSettings
+	// Token selects the settings in the X-Settings-Token header. It is only
+	// returned when the settings are created.
+	Token string `json:"token,omitempty"`
+}
+
+// settingsRequest is the body of a settings update, named like the fields of
+// a paste creation
+type settingsRequest struct {
+	Expires    int64  `json:"expires" form:"expires"`
+	Language   string `json:"extension" form:"extension"`
+	Visibility string `json:"visibility" form:"visibility"`
+	Burn       bool   `json:"burn" form:"burn"`
+}
+
+// GetUserSettings returns the settings of the settings token of the request
+func (h *Handler) GetUserSettings(c *fiber.Ctx) error {
+	settings, err := h.clientSettings(c)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fail(c, fiber.StatusNotFound, "Settings not found")
+	}
+	if err != nil {
+		h.requestLogger(c).Error("Error reading client settings", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error reading the settings")
+	}
+	return respond(c, SavedSettings{UserSettings: settings})
+}
+
+// PutUserSettings replaces the settings of the settings token of the request,
+// resetting the omitted ones. Without a settings token new settings are
+// created and returned with their token.
+func (h *Handler) PutUserSettings(c *fiber.Ctx) error {
+	var settings models.UserSettings
+	if c.Get(settingsTokenHeader) != "" {
+		var err error
+		settings, err = h.clientSettings(c)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fail(c, fiber.StatusNotFound, "Settings not found")
+		}
+		if err != nil {
+			h.requestLogger(c).Error("Error reading client settings", zap.Error(err))
+			return fail(c, fiber.StatusInternalServerError, "Error saving the settings")
+		}
+	}
+
+	var req settingsRequest
+	if err := Bind(c, &req, maxSettingsBodySize); err != nil {
+		return sendBindError(c, err)
+	}
+	var errs fieldErrors
+	limits := Limits(h.config)
+	if req.Expires != 0 && (req.Expires < limits.MinExpiryMinutes || req.Expires > limits.MaxExpiryMinutes) {
+		errs.add("expires", fmt.Sprintf("Expiry must be 0 or between %d and %d minutes", limits.MinExpiryMinutes, limits.MaxExpiryMinutes))
+	}
+	if len(req.Language) > MaxLanguageLength {
+		errs.add("extension", fmt.Sprintf("Language cannot be longer than %d characters", MaxLanguageLength))
+	} else if !limits.AllowsLanguage(req.Language) {
+		errs.add("extension", fmt.Sprintf("Language %q is not allowed", req.Language))
+	}
+	visibility := models.Visibility(req.Visibility)
+	if visibility != "" && !visibility.Valid() {
+		errs.add("visibility", "Visibility must be public, unlisted or private")
+	}
+	if len(errs) > 0 {
+		return errs.send(c)
+	}
+
+	var token string
+	if settings.ID == 0 {
+		var err error
+		if token, err = settings.SetToken(); err != nil {
+			h.requestLogger(c).Error("Error generating settings token", zap.Error(err))
+			return fail(c, fiber.StatusInternalServerError, "Error saving the settings")
+		}
+	}
+	settings.Expires = req.Expires
+	settings.Language = req.Language
+	settings.Visibility = visibility
+	settings.Burn = req.Burn
+	if err := storage.SaveUserSettings(h.dbFor(c), &settings); err != nil {
+		h.requestLogger(c).Error("Error saving client settings", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error saving the settings")
+	}
+	return respond(c, SavedSettings{UserSettings: settings, Token: token})
+}
+
+// clientSettings returns the settings of the settings token of the request,
+// gorm.ErrRecordNotFound when it has none
+func (h *Handler) clientSettings(c *fiber.Ctx) (models.UserSettings, error) {
+	token := c.Get(settingsTokenHeader)
+	if token == "" {
+		return models.UserSettings{}, gorm.ErrRecordNotFound
+	}
+	return storage.UserSettingsByToken(h.dbFor(c), token)
+}
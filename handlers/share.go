@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coolguy1771/wastebin/audit"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MaxShareTokens is the number of share tokens a paste may have at once
+const MaxShareTokens = 20
+
+// SharedPaste is a share token created for a paste
+type SharedPaste struct {
+	Token     string     `json:"token"`
+	Scope     string     `json:"scope" example:"read"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2021-01-01T00:00:00Z"`
+	// URL reads the paste with the token
+	URL string `json:"url"`
+}
+
+// CreateShareToken generates a read-only token sharing a private or
+// embargoed paste, expiring after the expires form value in minutes when it
+// is set. Only the owner of the paste and the admin can share it.
+func (h *Handler) CreateShareToken(c *fiber.Ctx) error {
+	pasteUUID, err := uuid.Parse(c.Params("uuid"))
+	if err != nil {
+		return fail(c, fiber.StatusNotFound, err.Error())
+	}
+	var paste models.Paste
+	err = h.dbFor(c).Select("uuid", "expiry_timestamp", "quarantined", "owner_token_hash").First(&paste, "uuid = ?", pasteUUID).Error
+	if err != nil || time.Now().After(paste.ExpiryTimestamp) {
+		return fail(c, fiber.StatusNotFound, gorm.ErrRecordNotFound.Error())
+	}
+	admin := h.config.AdminToken != "" && hasBearerToken(c, h.config.AdminToken)
+	auth := c.Get(fiber.HeaderAuthorization)
+	owner := strings.HasPrefix(auth, "Bearer ") && paste.OwnedBy(strings.TrimPrefix(auth, "Bearer "))
+	if !admin && (!owner || paste.Quarantined) {
+		return fail(c, fiber.StatusForbidden, "Only the owner of the paste can share it")
+	}
+
+	var expiresAt *time.Time
+	if value := c.FormValue("expires"); value != "" {
+		limits := Limits(h.config)
+		minutes, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || minutes < limits.MinExpiryMinutes || minutes > limits.MaxExpiryMinutes {
+			return fail(c, fiber.StatusBadRequest, fmt.Sprintf("Expiry must be between %d and %d minutes", limits.MinExpiryMinutes, limits.MaxExpiryMinutes))
+		}
+		at := time.Now().Add(time.Duration(minutes) * time.Minute)
+		expiresAt = &at
+	}
+
+	token, record, err := models.NewShareToken(pasteUUID, expiresAt)
+	if err != nil {
+		h.requestLogger(c).Error("Error generating share token", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error creating share token")
+	}
+	if err := storage.AddShareToken(h.dbFor(c), &record, MaxShareTokens, time.Now()); err != nil {
+		if errors.Is(err, storage.ErrTooManyShareTokens) {
+			return fail(c, fiber.StatusConflict, fmt.Sprintf("A paste cannot have more than %d share tokens", MaxShareTokens))
+		}
+		h.requestLogger(c).Error("Error saving share token", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error creating share token")
+	}
+	h.recordAudit(c, audit.ActionPasteShare, pasteUUID.String())
+
+	return respond(c, SharedPaste{
+		Token:     token,
+		Scope:     record.Scope,
+		ExpiresAt: record.ExpiresAt,
+		URL:       h.pasteURL(c, pasteUUID) + "?token=" + url.QueryEscape(token),
+	})
+}
+
+// sharedWith reports whether the request carries a share token of the
+// paste, as the token query value or a bearer token
+func (h *Handler) sharedWith(c *fiber.Ctx, paste models.Paste) bool {
+	token := c.Query("token")
+	if auth := c.Get(fiber.HeaderAuthorization); token == "" && strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if token == "" {
+		return false
+	}
+	shared, err := storage.SharedWith(h.dbFor(c), paste.UUID, token, time.Now())
+	if err != nil {
+		h.requestLogger(c).Error("Error checking share token", zap.Error(err))
+	}
+	return shared
+}
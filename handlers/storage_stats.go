@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// PasteStats are the statistics of the stored pastes as of ComputedAt
+type PasteStats struct {
+	storage.PasteStats
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// PublicStats are the statistics of the instance anyone may read, as of
+// ComputedAt
+type PublicStats struct {
+	Pastes int64 `json:"pastes"`
+	// PastesToday counts the pastes created since midnight UTC
+	PastesToday   int64     `json:"pastes_today"`
+	StartedAt     time.Time `json:"started_at"`
+	UptimeSeconds int64     `json:"uptime_seconds"`
+	ComputedAt    time.Time `json:"computed_at"`
+}
+
+// statsCache keeps the statistics of the stored pastes between computations
+type statsCache struct {
+	mu     sync.Mutex
+	paste  *PasteStats
+	public *PublicStats
+}
+
+// fresh reports whether statistics computed at are still cached at now
+func (h *Handler) fresh(at, now time.Time) bool {
+	return now.Sub(at) < h.config.StatsCacheInterval
+}
+
+// GetPasteStats returns the number and size of the stored pastes, the pastes
+// per language, the pastes created per day over the last 30 days and how
+// soon the pastes expire. The statistics are computed again once they are
+// older than STATS_CACHE_INTERVAL.
+func (h *Handler) GetPasteStats(c *fiber.Ctx) error {
+	h.statsCache.mu.Lock()
+	defer h.statsCache.mu.Unlock()
+
+	now := time.Now()
+	if cached := h.statsCache.paste; cached != nil && h.fresh(cached.ComputedAt, now) {
+		return respond(c, cached)
+	}
+	computed, err := storage.GetPasteStats(h.dbFor(c), now.AddDate(0, 0, -overviewGrowthDays), now)
+	if err != nil {
+		h.requestLogger(c).Error("Error computing the paste statistics", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error computing the paste statistics")
+	}
+	h.statsCache.paste = &PasteStats{PasteStats: computed, ComputedAt: now}
+	return respond(c, h.statsCache.paste)
+}
+
+// GetPublicStats returns the number of stored pastes, of those created today
+// and the uptime of the instance for status pages. It is only served when
+// PUBLIC_STATS is enabled, and the counts are cached like the admin ones.
+func (h *Handler) GetPublicStats(c *fiber.Ctx) error {
+	if !h.config.PublicStats {
+		return fail(c, fiber.StatusNotFound, "Statistics are not public")
+	}
+	h.statsCache.mu.Lock()
+	defer h.statsCache.mu.Unlock()
+
+	now := time.Now()
+	started := h.stats.Since()
+	if cached := h.statsCache.public; cached == nil || !h.fresh(cached.ComputedAt, now) {
+		today := now.UTC().Truncate(24 * time.Hour)
+		pastes, created, err := storage.CountPastes(h.dbFor(c), today)
+		if err != nil {
+			h.requestLogger(c).Error("Error counting pastes", zap.Error(err))
+			return fail(c, fiber.StatusInternalServerError, "Error computing the statistics")
+		}
+		h.statsCache.public = &PublicStats{Pastes: pastes, PastesToday: created, StartedAt: started, ComputedAt: now}
+	}
+	public := *h.statsCache.public
+	public.UptimeSeconds = int64(now.Sub(started).Seconds())
+	return respond(c, public)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// parseTags reads a comma separated list of tags
+func parseTags(value string) ([]string, error) {
+	return models.NormalizeTags(strings.Split(value, ","))
+}
+
+// ListPastes lists the pastes with the tag given by the tag query parameter,
+// the newest first. Only public pastes are listed, never burn after reading ones.
+func (h *Handler) ListPastes(c *fiber.Ctx) error {
+	tag := strings.ToLower(strings.TrimSpace(c.Query("tag")))
+	if tag == "" {
+		return fail(c, fiber.StatusBadRequest, "A tag to filter on is required")
+	}
+	limit := storage.DefaultListLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > storage.MaxListLimit {
+			return fail(c, fiber.StatusBadRequest, fmt.Sprintf("Limit must be between 1 and %d", storage.MaxListLimit))
+		}
+	}
+
+	pastes, err := storage.ListPastesByTag(h.dbFor(c), tag, time.Now(), limit)
+	if err != nil {
+		h.requestLogger(c).Error("Error listing pastes", zap.String("tag", tag), zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error listing pastes")
+	}
+	for i := range pastes {
+		if pastes[i].HasThumbnail {
+			pastes[i].ThumbnailURL = h.thumbnailURL(c, pastes[i].UUID)
+		}
+	}
+	return respond(c, pastes)
+}
+
+// ListTags lists the tags of the listed pastes with how many pastes use each
+func (h *Handler) ListTags(c *fiber.Ctx) error {
+	tags, err := storage.ListTags(h.dbFor(c), time.Now())
+	if err != nil {
+		h.requestLogger(c).Error("Error listing tags", zap.Error(err))
+		return fail(c, fiber.StatusInternalServerError, "Error listing tags")
+	}
+	return respond(c, tags)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetPasteThumbnail serves the PNG thumbnail of an image paste shown in
+// listings. The paste is neither read nor burned, and burn after reading
+// pastes have no thumbnail.
+func (h *Handler) GetPasteThumbnail(c *fiber.Ctx) error {
+	pasteUUID, err := uuid.Parse(c.Params("uuid"))
+	if err != nil {
+		return fail(c, fiber.StatusNotFound, err.Error())
+	}
+
+	var paste models.Paste
+	err = h.dbFor(c).Select("uuid", "burn", "expiry_timestamp", "visibility", "publish_at", "quarantined", "owner_token_hash", "thumbnail").
+		First(&paste, "uuid = ?", pasteUUID).Error
+	if err != nil {
+		return fail(c, fiber.StatusNotFound, err.Error())
+	}
+	if !h.canRead(c, paste) || time.Now().After(paste.ExpiryTimestamp) {
+		return fail(c, fiber.StatusNotFound, gorm.ErrRecordNotFound.Error())
+	}
+	if paste.Burn || len(paste.Thumbnail) == 0 {
+		return fail(c, fiber.StatusNotFound, "Paste has no thumbnail")
+	}
+
+	c.Type("png")
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderExpires, paste.ExpiryTimestamp.UTC().Format(http.TimeFormat))
+	return c.Send(paste.Thumbnail)
+}
+
+// thumbnailURL returns the link to the thumbnail of a paste
+func (h *Handler) thumbnailURL(c *fiber.Ctx, id uuid.UUID) string {
+	return h.pasteURL(c, id) + "/thumbnail.png"
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// APIDeadline bounds the API requests by API_TIMEOUT. Requests waiting for a
+// paste to be created get their wait on top.
+func (h *Handler) APIDeadline(c *fiber.Ctx) error {
+	timeout := h.config.APITimeout
+	if wait, err := parseWait(c); err == nil && timeout > 0 {
+		timeout += wait
+	}
+	return h.deadline(c, timeout)
+}
+
+// UploadDeadline bounds the requests creating pastes by UPLOAD_TIMEOUT
+func (h *Handler) UploadDeadline(c *fiber.Ctx) error {
+	return h.deadline(c, h.config.UploadTimeout)
+}
+
+// deadline cancels the context of the request after timeout, which stops its
+// database queries and scans. Requests failing once it passed are answered
+// with 503, while those that completed in time keep their response.
+func (h *Handler) deadline(c *fiber.Ctx, timeout time.Duration) error {
+	if timeout <= 0 {
+		return c.Next()
+	}
+	parent := c.UserContext()
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+	c.SetUserContext(ctx)
+	defer c.SetUserContext(parent)
+
+	err := c.Next()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) || err == nil && c.Response().StatusCode() < fiber.StatusBadRequest {
+		return err
+	}
+	h.requestLogger(c).Warn("Request timed out", zap.Duration("timeout", timeout), zap.NamedError("cause", err))
+	c.Response().ResetBody()
+	return fail(c, fiber.StatusServiceUnavailable, "Request timed out")
+}
+
+// dbFor returns the database bound to the context of the request, so that
+// its queries stop at the request deadline
+func (h *Handler) dbFor(c *fiber.Ctx) *gorm.DB {
+	return h.db.WithContext(c.UserContext())
+}
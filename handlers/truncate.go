@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// truncatePaste replaces the content of a paste larger than PREVIEW_SIZE
+// with a preview, describing where the rest is read from. Text is cut at the
+// last line break of the preview when there is one.
+func (h *Handler) truncatePaste(c *fiber.Ctx, paste *models.Paste) {
+	limit := h.config.PreviewSize
+	if limit == 0 || paste.Size <= int64(limit) {
+		return
+	}
+
+	var size int
+	if paste.Attachment() {
+		paste.Data = paste.Data[:limit]
+		size = limit
+	} else {
+		paste.Content = previewCut(paste.Content, limit)
+		size = len(paste.Content)
+	}
+	paste.Truncation = &models.Truncation{
+		PreviewSize: int64(size),
+		RawURL:      h.pasteURL(c, paste.UUID) + "/raw",
+		NextRange:   fmt.Sprintf("bytes=%d-", size),
+		FullURL:     h.baseURL(c) + "/api/v1/paste/" + paste.UUID.String() + "?full=true",
+	}
+}
+
+// previewCut returns the start of the content up to limit bytes, ending with
+// a whole line when possible and a whole character otherwise
+func previewCut(content string, limit int) string {
+	if len(content) <= limit {
+		return content
+	}
+	preview := content[:limit]
+	if i := strings.LastIndexByte(preview, '\n'); i >= 0 {
+		return preview[:i+1]
+	}
+	for len(preview) > 0 && !utf8.RuneStart(content[len(preview)]) {
+		preview = preview[:len(preview)-1]
+	}
+	return preview
+}
+
+// sendContent sends the content of a paste. With ranges, the single byte
+// range asked for by a Range header is sent instead of the whole content.
+func sendContent(c *fiber.Ctx, data []byte, ranges bool) error {
+	if !ranges {
+		return c.Send(data)
+	}
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	header := c.Get(fiber.HeaderRange)
+	if header == "" {
+		return c.Send(data)
+	}
+	start, end, ok := parseRange(header, len(data))
+	if !ok {
+		c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", len(data)))
+		return fail(c, fiber.StatusRequestedRangeNotSatisfiable, "Invalid range")
+	}
+	c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+	return c.Status(fiber.StatusPartialContent).Send(data[start : end+1])
+}
+
+// parseRange returns the first and last byte of a Range header asking for a
+// single range of a content of size bytes, such as bytes=0-99, bytes=100-
+// or bytes=-100
+func parseRange(header string, size int) (int, int, bool) {
+	if !strings.HasPrefix(header, "bytes=") {
+		return 0, 0, false
+	}
+	first, last, found := strings.Cut(strings.TrimPrefix(header, "bytes="), "-")
+	if !found || strings.Contains(last, ",") {
+		return 0, 0, false
+	}
+	if first == "" {
+		// The suffix range asks for the last bytes
+		n, err := strconv.Atoi(last)
+		if err != nil || n <= 0 || size == 0 {
+			return 0, 0, false
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size - 1, true
+	}
+	start, err := strconv.Atoi(first)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false
+	}
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.Atoi(last); err != nil || end < start {
+			return 0, 0, false
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	return start, end, true
+}
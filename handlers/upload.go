@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// isRawUpload reports whether the body of a paste creation is the content
+// itself, sent with PUT or as text/plain, rather than a form
+func isRawUpload(c *fiber.Ctx) bool {
+	if c.Method() == fiber.MethodPut {
+		return true
+	}
+	return strings.HasPrefix(strings.ToLower(c.Get(fiber.HeaderContentType)), fiber.MIMETextPlain)
+}
+
+// pasteValue returns an option of a new paste from the form. Raw uploads
+// take it from the query string or the X-Paste-<Option> header instead, and
+// their content is the body.
+func pasteValue(c *fiber.Ctx, key string, defaultValue ...string) string {
+	if !isRawUpload(c) {
+		return c.FormValue(key, defaultValue...)
+	}
+	if key == "text" {
+		return string(c.Body())
+	}
+	if value := c.Query(key); value != "" {
+		return value
+	}
+	if value := c.Get("X-Paste-" + strings.ReplaceAll(key, "_", "-")); value != "" {
+		return value
+	}
+	if len(defaultValue) > 0 {
+		return defaultValue[0]
+	}
+	return ""
+}
+
+// readUpload reads an uploaded file, stopping one byte past maxSize so that
+// larger files are refused without reading them whole
+func readUpload(file *multipart.FileHeader, maxSize int) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	content, err := io.ReadAll(io.LimitReader(f, int64(maxSize)+1))
+	return string(content), err
+}
+
+// fileLanguage returns the language of a file from its extension
+func fileLanguage(name string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// baseURL is the external URL of the server. It is read from the request
+// unless configured, which proxies that rewrite the Host header require.
+func (h *Handler) baseURL(c *fiber.Ctx) string {
+	if h.config.BaseURL != "" {
+		return strings.TrimRight(h.config.BaseURL, "/")
+	}
+	return c.BaseURL()
+}
+
+// pasteURL is the absolute link to the page of a paste
+func (h *Handler) pasteURL(c *fiber.Ctx, id uuid.UUID) string {
+	return h.baseURL(c) + "/paste/" + id.String()
+}
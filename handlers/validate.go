@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validate checks the validate tags of the request DTOs. Failures are
+// reported under the name of the form value the field is read from.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+		if name == "" || name == "-" {
+			return strings.ToLower(field.Name)
+		}
+		return name
+	})
+	return v
+}
+
+// check adds the failures of the validate tags of request, a pointer to or
+// a struct
+func (e *fieldErrors) check(request interface{}) {
+	var failures validator.ValidationErrors
+	if err := validate.Struct(request); !errors.As(err, &failures) {
+		return
+	}
+	for _, failure := range failures {
+		e.add(failure.Field(), validationMessage(failure))
+	}
+}
+
+// validationMessage describes a failed tag with the name of the struct
+// field, such as "Title cannot be longer than 256 characters"
+func validationMessage(failure validator.FieldError) string {
+	label := failure.StructField()
+	text := failure.Kind() == reflect.String
+	switch failure.Tag() {
+	case "required":
+		return label + " cannot be empty"
+	case "max":
+		if text {
+			return fmt.Sprintf("%s cannot be longer than %s characters", label, failure.Param())
+		}
+		return fmt.Sprintf("%s cannot be more than %s", label, failure.Param())
+	case "min":
+		if text {
+			return fmt.Sprintf("%s must be at least %s characters long", label, failure.Param())
+		}
+		return fmt.Sprintf("%s cannot be less than %s", label, failure.Param())
+	case "oneof":
+		values := strings.Fields(failure.Param())
+		if len(values) > 1 {
+			return fmt.Sprintf("%s must be %s or %s", label, strings.Join(values[:len(values)-1], ", "), values[len(values)-1])
+		}
+		return fmt.Sprintf("%s must be %s", label, failure.Param())
+	default:
+		return label + " is invalid"
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// canRead reports whether the request may read the paste. Private pastes
+// and embargoed ones need their owner token or the admin token as a bearer
+// token, or one of their share tokens. Quarantined ones need the admin token.
+func (h *Handler) canRead(c *fiber.Ctx, paste models.Paste) bool {
+	admin := h.config.AdminToken != "" && hasBearerToken(c, h.config.AdminToken)
+	if paste.Quarantined {
+		return admin
+	}
+	if paste.Visibility != models.VisibilityPrivate && paste.Published(time.Now()) || admin {
+		return true
+	}
+	auth := c.Get(fiber.HeaderAuthorization)
+	if strings.HasPrefix(auth, "Bearer ") && paste.OwnedBy(strings.TrimPrefix(auth, "Bearer ")) {
+		return true
+	}
+	return h.sharedWith(c, paste)
+}
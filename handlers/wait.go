@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxPasteWait bounds how long a request may wait for a paste to be created
+const maxPasteWait = 60 * time.Second
+
+// pasteWaitPoll is how often waiting requests look for pastes created
+// through other instances, pastes created through this one wake them at once
+const pasteWaitPoll = time.Second
+
+// pasteWaiters wakes the requests waiting for a paste once it is created
+type pasteWaiters struct {
+	mu      sync.Mutex
+	waiters map[uuid.UUID][]chan struct{}
+}
+
+// add registers a waiter for the paste and returns the channel closed when it
+// is created and a function to unregister it
+func (w *pasteWaiters) add(id uuid.UUID) (<-chan struct{}, func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.waiters == nil {
+		w.waiters = make(map[uuid.UUID][]chan struct{})
+	}
+	ch := make(chan struct{})
+	w.waiters[id] = append(w.waiters[id], ch)
+
+	return ch, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		waiters := w.waiters[id]
+		for i, waiter := range waiters {
+			if waiter == ch {
+				waiters = append(waiters[:i], waiters[i+1:]...)
+				break
+			}
+		}
+		if len(waiters) == 0 {
+			delete(w.waiters, id)
+		} else {
+			w.waiters[id] = waiters
+		}
+	}
+}
+
+// notify wakes the waiters of a paste
+func (w *pasteWaiters) notify(id uuid.UUID) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, ch := range w.waiters[id] {
+		close(ch)
+	}
+	delete(w.waiters, id)
+}
+
+// parseWait reads the optional wait query parameter, a duration such as 30s
+func parseWait(c *fiber.Ctx) (time.Duration, error) {
+	value := c.Query("wait")
+	if value == "" {
+		return 0, nil
+	}
+	wait, err := time.ParseDuration(value)
+	if err != nil || wait < 0 {
+		return 0, fmt.Errorf("invalid wait duration %q", value)
+	}
+	if wait > maxPasteWait {
+		wait = maxPasteWait
+	}
+	return wait, nil
+}
+
+// waitForPaste blocks until the paste is created or wait elapsed, in which
+// case gorm.ErrRecordNotFound is returned. Waiting stops early when the
+// server starts draining.
+func (h *Handler) waitForPaste(id uuid.UUID, wait time.Duration) (models.Paste, error) {
+	created, cancel := h.waiters.add(id)
+	defer cancel()
+
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+	poll := time.NewTicker(pasteWaitPoll)
+	defer poll.Stop()
+
+	for {
+		// Look again after registering, the paste may have been created in between
+		var paste models.Paste
+		err := h.db.First(&paste, "uuid = ?", id).Error
+		if !errors.Is(err, gorm.ErrRecordNotFound) || h.draining.Load() {
+			return paste, err
+		}
+
+		select {
+		case <-created:
+			created = nil
+		case <-poll.C:
+		case <-timeout.C:
+			return paste, gorm.ErrRecordNotFound
+		}
+	}
+}
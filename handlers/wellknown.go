@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coolguy1771/wastebin/web"
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultRobots keeps crawlers away from the API and the raw content of pastes
+const defaultRobots = `User-agent: *
+Disallow: /api/
+Disallow: /paste/*/raw
+Disallow: /paste/*/qr.png
+`
+
+// Robots serves robots.txt, read from the configured file when one is set
+func (h *Handler) Robots(c *fiber.Ctx) error {
+	robots := []byte(defaultRobots)
+	if h.config.RobotsFile != "" {
+		var err error
+		if robots, err = os.ReadFile(h.config.RobotsFile); err != nil {
+			return err
+		}
+	}
+	c.Type("txt")
+	return c.Send(robots)
+}
+
+// SecurityTxt serves the security.txt of RFC 9116 listing the configured
+// contacts. It is not found unless a contact is configured.
+func (h *Handler) SecurityTxt(c *fiber.Ctx) error {
+	if h.config.SecurityContact == "" {
+		return fiber.ErrNotFound
+	}
+
+	var txt strings.Builder
+	for _, contact := range strings.Split(h.config.SecurityContact, ",") {
+		if contact = strings.TrimSpace(contact); contact != "" {
+			txt.WriteString("Contact: " + contact + "\n")
+		}
+	}
+	// The file is generated, so it never goes stale
+	expires := time.Now().UTC().Truncate(24*time.Hour).AddDate(1, 0, 0)
+	txt.WriteString("Expires: " + expires.Format(time.RFC3339) + "\n")
+	if h.config.SecurityPolicy != "" {
+		txt.WriteString("Policy: " + h.config.SecurityPolicy + "\n")
+	}
+	txt.WriteString("Canonical: " + h.baseURL(c) + "/.well-known/security.txt\n")
+
+	c.Type("txt")
+	return c.SendString(txt.String())
+}
+
+// Favicon serves the icon of the site
+func (h *Handler) Favicon(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age="+strconv.Itoa(h.config.StaticMaxAge))
+	c.Type("ico")
+	return c.Send(web.Favicon)
+}
@@ -0,0 +1,143 @@
+// Package health checks the dependencies of the server. Subsystems register
+// named checks with the latency budget they must answer within, and the
+// readiness probe reports the outcome of every check.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultBudget is the latency budget of the checks registered without one
+const DefaultBudget = time.Second
+
+// Func checks a dependency, returning nil when it is usable
+type Func func(ctx context.Context) error
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	// OK checks answered within their budget
+	OK Status = "ok"
+	// Failing checks returned an error or exceeded their budget
+	Failing Status = "failing"
+)
+
+// Result is the outcome of a check
+type Result struct {
+	Status Status `json:"status" example:"ok"`
+	// Latency and Budget are in milliseconds
+	Latency float64 `json:"latency_ms"`
+	Budget  float64 `json:"budget_ms"`
+	Error   string  `json:"error,omitempty"`
+}
+
+type check struct {
+	budget time.Duration
+	run    Func
+}
+
+// Registry holds the checks of the dependencies of the server
+type Registry struct {
+	mu     sync.RWMutex
+	checks map[string]check
+}
+
+// New creates an empty Registry
+func New() *Registry {
+	return &Registry{checks: make(map[string]check)}
+}
+
+// Register adds the check of a dependency, replacing the check registered
+// with the same name. The check must answer within budget, DefaultBudget
+// when it is 0.
+func (r *Registry) Register(name string, budget time.Duration, run Func) {
+	if budget <= 0 {
+		budget = DefaultBudget
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = check{budget: budget, run: run}
+}
+
+// Unregister removes the check of a dependency
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.checks, name)
+}
+
+// Check runs every check at once and returns their results by name. Checks
+// still running past their budget are reported as failing without waiting
+// for them.
+func (r *Registry) Check(ctx context.Context) map[string]Result {
+	r.mu.RLock()
+	checks := make(map[string]check, len(r.checks))
+	for name, c := range r.checks {
+		checks[name] = c
+	}
+	r.mu.RUnlock()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]Result, len(checks))
+	)
+	for name, c := range checks {
+		wg.Add(1)
+		go func(name string, c check) {
+			defer wg.Done()
+			result := c.check(ctx)
+			mu.Lock()
+			results[name] = result
+			mu.Unlock()
+		}(name, c)
+	}
+	wg.Wait()
+	return results
+}
+
+func (c check) check(ctx context.Context) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.budget)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- c.run(ctx) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	latency := time.Since(start)
+
+	result := Result{Status: OK, Latency: milliseconds(latency), Budget: milliseconds(c.budget)}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || err == nil && latency > c.budget:
+		result.Status = Failing
+		result.Error = fmt.Sprintf("exceeded its latency budget of %s", c.budget)
+	case err != nil:
+		result.Status = Failing
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Healthy reports whether every check of results passed
+func Healthy(results map[string]Result) bool {
+	for _, result := range results {
+		if result.Status != OK {
+			return false
+		}
+	}
+	return true
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
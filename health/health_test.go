@@ -0,0 +1,45 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/health"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := health.New()
+	registry.Register("database", time.Second, func(ctx context.Context) error { return nil })
+	registry.Register("cache", time.Second, func(ctx context.Context) error { return errors.New("connection refused") })
+	registry.Register("queue", 20*time.Millisecond, func(ctx context.Context) error {
+		// Checks ignoring their context are not waited for
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	results := registry.Check(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the checks to stop at their budget, took %s", elapsed)
+	}
+	if len(results) != 3 || health.Healthy(results) {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if result := results["database"]; result.Status != health.OK || result.Budget != 1000 {
+		t.Errorf("unexpected database result %+v", result)
+	}
+	if result := results["cache"]; result.Status != health.Failing || result.Error != "connection refused" {
+		t.Errorf("unexpected cache result %+v", result)
+	}
+	if result := results["queue"]; result.Status != health.Failing || result.Error == "" {
+		t.Errorf("unexpected queue result %+v", result)
+	}
+
+	registry.Unregister("cache")
+	registry.Unregister("queue")
+	if results := registry.Check(context.Background()); len(results) != 1 || !health.Healthy(results) {
+		t.Errorf("unexpected results after unregistering the failing checks %+v", results)
+	}
+}
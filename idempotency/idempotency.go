@@ -0,0 +1,116 @@
+// Package idempotency remembers the responses to requests sent with an
+// Idempotency-Key header so that retries get the same response instead of
+// being handled again. Keys are persisted in the database so they are shared
+// between replicas.
+package idempotency
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TTL is how long the response to a key is kept
+const TTL = 24 * time.Hour
+
+// ClaimTimeout is how long a key stays claimed by a request that neither
+// completed nor aborted it, such as one handled by a replica that stopped
+const ClaimTimeout = 5 * time.Minute
+
+// cleanupInterval is how often the keys older than TTL are deleted
+const cleanupInterval = time.Hour
+
+var (
+	// ErrMismatch is returned by Begin when a key is reused for a different request
+	ErrMismatch = errors.New("idempotency key was used for a different request")
+	// ErrInProgress is returned by Begin while the first request with a key is being handled
+	ErrInProgress = errors.New("a request with this idempotency key is in progress")
+)
+
+// Store records the keys and responses
+type Store struct {
+	db     *gorm.DB
+	logger *log.Logger
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+// New creates a Store
+func New(db *gorm.DB, logger *log.Logger) *Store {
+	return &Store{db: db, logger: logger}
+}
+
+// Begin claims key for the request of client whose body hashes to
+// requestHash. It returns the stored response when the request was already
+// handled, and nil when the caller must handle it and then call Complete or
+// Abort.
+func (s *Store) Begin(client, key, requestHash string, now time.Time) (*models.IdempotencyKey, error) {
+	// A key that expired, or whose claim timed out, can be used again
+	err := s.db.Where("client = ? AND key = ? AND (created_at < ? OR status = ? AND created_at < ?)",
+		client, key, now.Add(-TTL), 0, now.Add(-ClaimTimeout)).Delete(&models.IdempotencyKey{}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.IdempotencyKey{
+		Client:      client,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 1 {
+		// The key is claimed, failing to delete old ones doesn't change that
+		if err := s.cleanup(now); err != nil {
+			s.logger.Error("Error deleting expired idempotency keys", zap.Error(err))
+		}
+		return nil, nil
+	}
+
+	var stored models.IdempotencyKey
+	if err := s.db.Where("client = ? AND key = ?", client, key).First(&stored).Error; err != nil {
+		return nil, err
+	}
+	if stored.RequestHash != requestHash {
+		return nil, ErrMismatch
+	}
+	if stored.Status == 0 {
+		return nil, ErrInProgress
+	}
+	return &stored, nil
+}
+
+// Complete stores the response to the request claimed with Begin
+func (s *Store) Complete(client, key string, status int, body []byte) error {
+	return s.db.Model(&models.IdempotencyKey{}).Where("client = ? AND key = ?", client, key).Updates(map[string]interface{}{
+		"status": status,
+		"body":   string(body),
+	}).Error
+}
+
+// Abort releases a key claimed with Begin so that the request can be retried
+func (s *Store) Abort(client, key string) error {
+	return s.db.Where("client = ? AND key = ? AND status = ?", client, key, 0).Delete(&models.IdempotencyKey{}).Error
+}
+
+// cleanup deletes the keys older than TTL, at most once per cleanupInterval
+func (s *Store) cleanup(now time.Time) error {
+	s.mu.Lock()
+	if now.Sub(s.lastCleanup) < cleanupInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.lastCleanup = now
+	s.mu.Unlock()
+
+	return s.db.Where("created_at < ?", now.Add(-TTL)).Delete(&models.IdempotencyKey{}).Error
+}
@@ -0,0 +1,69 @@
+package idempotency_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/idempotency"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestStore(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.IdempotencyKey{}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := idempotency.New(db, log.Default())
+	now := time.Date(2023, 1, 1, 10, 30, 0, 0, time.UTC)
+
+	stored, err := s.Begin("ip:192.0.2.1", "key", "hash", now)
+	if err != nil || stored != nil {
+		t.Fatalf("expected to claim a new key, got %+v, %v", stored, err)
+	}
+	if _, err := s.Begin("ip:192.0.2.1", "key", "hash", now); !errors.Is(err, idempotency.ErrInProgress) {
+		t.Fatalf("expected the key to be in progress, got %v", err)
+	}
+
+	if err := s.Complete("ip:192.0.2.1", "key", 200, []byte(`{"uuid":"a"}`)); err != nil {
+		t.Fatal(err)
+	}
+	stored, err = s.Begin("ip:192.0.2.1", "key", "hash", now.Add(time.Hour))
+	if err != nil || stored == nil || stored.Status != 200 || stored.Body != `{"uuid":"a"}` {
+		t.Fatalf("expected the stored response, got %+v, %v", stored, err)
+	}
+	if _, err := s.Begin("ip:192.0.2.1", "key", "other", now); !errors.Is(err, idempotency.ErrMismatch) {
+		t.Fatalf("expected a mismatch reusing the key for another request, got %v", err)
+	}
+
+	// Keys are per client and expire
+	if stored, err := s.Begin("ip:192.0.2.2", "key", "other", now); err != nil || stored != nil {
+		t.Fatalf("expected another client to claim the key, got %+v, %v", stored, err)
+	}
+	if stored, err := s.Begin("ip:192.0.2.1", "key", "other", now.Add(idempotency.TTL+time.Hour)); err != nil || stored != nil {
+		t.Fatalf("expected the expired key to be claimed again, got %+v, %v", stored, err)
+	}
+
+	// A claim that was never completed times out
+	if stored, err := s.Begin("ip:192.0.2.3", "key", "hash", now); err != nil || stored != nil {
+		t.Fatalf("expected to claim a new key, got %+v, %v", stored, err)
+	}
+	if stored, err := s.Begin("ip:192.0.2.3", "key", "hash", now.Add(idempotency.ClaimTimeout+time.Second)); err != nil || stored != nil {
+		t.Fatalf("expected the stale claim to be claimed again, got %+v, %v", stored, err)
+	}
+
+	// Aborting releases the key
+	if err := s.Abort("ip:192.0.2.1", "key"); err != nil {
+		t.Fatal(err)
+	}
+	if stored, err := s.Begin("ip:192.0.2.1", "key", "hash", now.Add(idempotency.TTL+time.Hour)); err != nil || stored != nil {
+		t.Fatalf("expected the aborted key to be claimed again, got %+v, %v", stored, err)
+	}
+}
@@ -0,0 +1,72 @@
+package ipfilter
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/coolguy1771/wastebin/audit"
+	"github.com/coolguy1771/wastebin/clientip"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Filter is a fiber middleware rejecting requests based on the client IP
+type Filter struct {
+	allow  []*net.IPNet
+	deny   []*net.IPNet
+	logger *log.Logger
+	db     *gorm.DB
+}
+
+// New creates a Filter from comma separated lists of CIDRs. Requests from the
+// denied networks are always rejected, when allowed networks are given only
+// requests from those are accepted.
+func New(allow, deny string, logger *log.Logger, db *gorm.DB) (*Filter, error) {
+	allowed, err := clientip.ParseCIDRs(allow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allow list: %w", err)
+	}
+	denied, err := clientip.ParseCIDRs(deny)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deny list: %w", err)
+	}
+
+	return &Filter{
+		allow:  allowed,
+		deny:   denied,
+		logger: logger,
+		db:     db,
+	}, nil
+}
+
+// Allowed reports whether requests from ip are accepted
+func (f *Filter) Allowed(ip net.IP) bool {
+	if ip == nil {
+		return len(f.allow) == 0 && len(f.deny) == 0
+	}
+	if clientip.Contains(f.deny, ip) {
+		return false
+	}
+	return len(f.allow) == 0 || clientip.Contains(f.allow, ip)
+}
+
+// Handler rejects requests from clients that are not allowed with 403 and
+// records them in the audit log
+func (f *Filter) Handler(c *fiber.Ctx) error {
+	if len(f.allow) == 0 && len(f.deny) == 0 {
+		return c.Next()
+	}
+
+	ip := clientip.Get(c)
+	if f.Allowed(net.ParseIP(ip)) {
+		return c.Next()
+	}
+
+	f.logger.Warn("Blocked request from IP", zap.String("ip", ip), zap.String("path", c.Path()))
+	if err := audit.Record(f.db, audit.ActionRequestBlocked, ip, c.Method()+" "+c.Path()); err != nil {
+		f.logger.Error("Error recording audit event", zap.String("action", audit.ActionRequestBlocked), zap.Error(err))
+	}
+	return fiber.ErrForbidden
+}
@@ -0,0 +1,32 @@
+package ipfilter_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/coolguy1771/wastebin/ipfilter"
+	"github.com/coolguy1771/wastebin/log"
+)
+
+func TestAllowed(t *testing.T) {
+	filter, err := ipfilter.New("10.0.0.0/8, 2001:db8::/32", "10.0.0.1", log.Default(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]bool{
+		"10.1.2.3":    true,
+		"2001:db8::1": true,
+		"10.0.0.1":    false,
+		"192.0.2.1":   false,
+	}
+	for ip, allowed := range cases {
+		if filter.Allowed(net.ParseIP(ip)) != allowed {
+			t.Errorf("expected Allowed(%s) to be %t", ip, allowed)
+		}
+	}
+
+	if _, err := ipfilter.New("10.0.0.0/33", "", log.Default(), nil); err == nil {
+		t.Error("expected an error for an invalid CIDR")
+	}
+}
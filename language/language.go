@@ -0,0 +1,94 @@
+// Package language guesses the language of a paste from its content, naming
+// languages like highlight.js, which the frontend highlights pastes with
+package language
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// sniffLength is the number of bytes at the start of a paste looked at
+const sniffLength = 4096
+
+// interpreters maps the interpreters of shebang lines to their language
+var interpreters = map[string]string{
+	"sh":      "bash",
+	"bash":    "bash",
+	"zsh":     "bash",
+	"dash":    "bash",
+	"ksh":     "bash",
+	"python":  "python",
+	"python2": "python",
+	"python3": "python",
+	"node":    "javascript",
+	"deno":    "typescript",
+	"ruby":    "ruby",
+	"perl":    "perl",
+	"php":     "php",
+	"lua":     "lua",
+	"Rscript": "r",
+	"awk":     "awk",
+	"fish":    "shell",
+	"pwsh":    "powershell",
+}
+
+// signature is a pattern that identifies a language when it matches
+type signature struct {
+	language string
+	pattern  *regexp.Regexp
+}
+
+// signatures are tried in order, so the more specific ones come first
+var signatures = []signature{
+	{"php", regexp.MustCompile(`^\s*<\?php`)},
+	{"xml", regexp.MustCompile(`^\s*<\?xml`)},
+	{"html", regexp.MustCompile(`(?i)^\s*(<!doctype html|<html)`)},
+	{"diff", regexp.MustCompile(`(?m)^(diff --git |--- a/.*\n\+\+\+ b/|@@ -\d+(,\d+)? \+\d+(,\d+)? @@)`)},
+	{"go", regexp.MustCompile(`(?m)^package \w+\s*$[\s\S]*^(import|func|type|var|const)\b`)},
+	{"rust", regexp.MustCompile(`(?m)^\s*(pub )?fn \w+.*\{|^use (std|crate)::`)},
+	{"c", regexp.MustCompile(`(?m)^#include\s*[<"]`)},
+	{"java", regexp.MustCompile(`(?m)^(public |final )?(class|interface) \w+[\s\S]*public static void main`)},
+	{"python", regexp.MustCompile(`(?m)^(def \w+\(.*\):|class \w+(\(.*\))?:|from [\w.]+ import |import \w+$)`)},
+	{"dockerfile", regexp.MustCompile(`(?m)^FROM \S+[\s\S]*^(RUN|CMD|COPY|ENTRYPOINT) `)},
+	{"sql", regexp.MustCompile(`(?im)^\s*(select .+ from|insert into|create table|update \w+ set|delete from) `)},
+	{"javascript", regexp.MustCompile(`(?m)^(const|let|var) \w+ = (require\(|\(|function|async)|^(export |import .* from ['"])`)},
+	{"yaml", regexp.MustCompile(`(?m)\A(---\s*\n)?([\w.-]+:( .*)?\n)+[\w.-]+:( .*)?$`)},
+	{"ini", regexp.MustCompile(`(?m)\A\s*\[[\w. -]+\]\s*\n(\s*[\w.-]+\s*=.*\n?)+`)},
+	{"markdown", regexp.MustCompile(`(?m)^(#{1,6} \S.*\n[\s\S]*(^[-*] |\[.+\]\(.+\)|^` + "```" + `))`)},
+}
+
+// Detect guesses the language of a paste, returning "" when unsure
+func Detect(content string) string {
+	if len(content) > sniffLength {
+		content = content[:sniffLength]
+	}
+
+	if strings.HasPrefix(content, "#!") {
+		line, _, _ := strings.Cut(content[2:], "\n")
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			interpreter := path.Base(fields[0])
+			// #!/usr/bin/env python3
+			if interpreter == "env" && len(fields) > 1 {
+				interpreter = fields[1]
+			}
+			if language, ok := interpreters[interpreter]; ok {
+				return language
+			}
+		}
+	}
+
+	trimmed := strings.TrimSpace(content)
+	if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+		return "json"
+	}
+
+	for _, s := range signatures {
+		if s.pattern.MatchString(content) {
+			return s.language
+		}
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package language_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/coolguy1771/wastebin/language"
+)
+
+func TestDetect(t *testing.T) {
+	tests := map[string]string{
+		"#!/bin/sh\necho hello\n":                              "bash",
+		"#!/usr/bin/env python3\nprint('hello')\n":             "python",
+		`{"name": "wastebin", "tags": ["go"]}`:                 "json",
+		"<?php echo 'hello';\n":                                "php",
+		"<!DOCTYPE html>\n<html></html>\n":                     "html",
+		"diff --git a/main.go b/main.go\n":                     "diff",
+		"package main\n\nimport \"fmt\"\n":                     "go",
+		"fn main() {\n    println!(\"hello\");\n}\n":           "rust",
+		"#include <stdio.h>\n\nint main(void) {}\n":            "c",
+		"def hello(name):\n    return name\n":                  "python",
+		"FROM golang:1.21\nRUN go build ./...\n":               "dockerfile",
+		"SELECT uuid FROM pastes WHERE burn = true;\n":         "sql",
+		"const express = require('express');\n":                "javascript",
+		"name: wastebin\nimage: wastebin:latest\nport: 3000\n": "yaml",
+		"[server]\nport = 3000\nhost = localhost\n":            "ini",
+		"# Wastebin\n\nA pastebin, see [the docs](docs).\n":    "markdown",
+		"just some notes\nabout nothing in particular\n":       "",
+		"": "",
+	}
+	for content, expected := range tests {
+		if detected := language.Detect(content); detected != expected {
+			t.Errorf("expected %q detected for %q, got %q", expected, content, detected)
+		}
+	}
+
+	// Only the start of long pastes is looked at
+	long := "package main\n\nfunc main() {}\n" + strings.Repeat("x", 10000)
+	if detected := language.Detect(long); detected != "go" {
+		t.Errorf("expected a long paste detected as go, got %q", detected)
+	}
+}
@@ -0,0 +1,84 @@
+// Package loadshed bounds the number of requests handled at once. Requests
+// over the limit wait in a bounded queue for a slot and are refused when the
+// queue is full or the wait is too long, so that bursts of writes don't pile
+// up on the database.
+package loadshed
+
+import (
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config sizes the limit and its queue
+type Config struct {
+	// MaxInFlight is the number of requests handled at once, 0 disables the limit
+	MaxInFlight int
+	// QueueDepth is the number of requests that may wait for a slot
+	QueueDepth int
+	// QueueTimeout is how long queued requests wait for a slot
+	QueueTimeout time.Duration
+}
+
+// Shedder limits the requests handled at once
+type Shedder struct {
+	config Config
+	slots  chan struct{}
+	queued atomic.Int64
+}
+
+// New creates a Shedder
+func New(config Config) *Shedder {
+	s := &Shedder{config: config}
+	if config.MaxInFlight > 0 {
+		s.slots = make(chan struct{}, config.MaxInFlight)
+	}
+	return s
+}
+
+// Acquire takes a slot, waiting in the queue while all are taken. It returns
+// the function giving the slot back, or false when the request is shed.
+func (s *Shedder) Acquire() (func(), bool) {
+	release := func() { <-s.slots }
+	select {
+	case s.slots <- struct{}{}:
+		return release, true
+	default:
+	}
+
+	if s.queued.Add(1) > int64(s.config.QueueDepth) {
+		s.queued.Add(-1)
+		return nil, false
+	}
+	defer s.queued.Add(-1)
+
+	timeout := time.NewTimer(s.config.QueueTimeout)
+	defer timeout.Stop()
+	select {
+	case s.slots <- struct{}{}:
+		return release, true
+	case <-timeout.C:
+		return nil, false
+	}
+}
+
+// Handler refuses the requests shed with 503 and a Retry-After header
+func (s *Shedder) Handler(c *fiber.Ctx) error {
+	if s.slots == nil {
+		return c.Next()
+	}
+	release, ok := s.Acquire()
+	if !ok {
+		retryAfter := int(math.Ceil(s.config.QueueTimeout.Seconds()))
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Server is overloaded, try again later")
+	}
+	defer release()
+	return c.Next()
+}
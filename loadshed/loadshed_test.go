@@ -0,0 +1,72 @@
+package loadshed_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/loadshed"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAcquire(t *testing.T) {
+	shedder := loadshed.New(loadshed.Config{MaxInFlight: 1, QueueDepth: 1, QueueTimeout: time.Second})
+
+	release, ok := shedder.Acquire()
+	if !ok {
+		t.Fatal("expected a free slot")
+	}
+
+	// The queued request gets the slot once it is released
+	acquired := make(chan bool)
+	go func() {
+		release, ok := shedder.Acquire()
+		if ok {
+			release()
+		}
+		acquired <- ok
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if _, ok := shedder.Acquire(); ok {
+		t.Error("expected the request to be shed with the queue full")
+	}
+	release()
+	if !<-acquired {
+		t.Error("expected the queued request to get the slot")
+	}
+
+	shedder = loadshed.New(loadshed.Config{MaxInFlight: 1, QueueDepth: 1, QueueTimeout: 10 * time.Millisecond})
+	release, _ = shedder.Acquire()
+	defer release()
+	if _, ok := shedder.Acquire(); ok {
+		t.Error("expected the request to be shed after waiting in the queue")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	shedder := loadshed.New(loadshed.Config{MaxInFlight: 1, QueueTimeout: 1500 * time.Millisecond})
+	app := fiber.New()
+	app.Use(shedder.Handler)
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected %d with a free slot, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	release, _ := shedder.Acquire()
+	defer release()
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") != "2" {
+		t.Errorf("expected %d with Retry-After when saturated, got %d %q", http.StatusServiceUnavailable, resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+}
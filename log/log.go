@@ -1,6 +1,7 @@
 package log
 
 import (
+	"context"
 	"io"
 	"os"
 
@@ -76,7 +77,7 @@ func ResetDefault(l *Logger) {
 
 type Logger struct {
 	l     *zap.Logger // zap ensure that zap.Logger is safe for concurrent use
-	level zapcore.Level
+	level zap.AtomicLevel
 }
 
 var std, _ = New(os.Stdout, "INFO")
@@ -94,18 +95,33 @@ func New(writer io.Writer, level string) (logger *Logger, err error) {
 		return logger, err
 	}
 	cfg := zap.NewProductionConfig()
+	atomicLevel := zap.NewAtomicLevelAt(parsedAtomicLevel)
 	core := zapcore.NewCore(
 		zapcore.NewJSONEncoder(cfg.EncoderConfig),
 		zapcore.AddSync(writer),
-		zapcore.Level(parsedAtomicLevel),
+		atomicLevel,
 	)
 	logger = &Logger{
 		l:     zap.New(core),
-		level: parsedAtomicLevel,
+		level: atomicLevel,
 	}
 	return logger, err
 }
 
+// SetLevel changes the level of the logger at runtime
+func (l *Logger) SetLevel(level string) error {
+	parsedLevel, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.level.SetLevel(parsedLevel)
+	return nil
+}
+
+func SetLevel(level string) error {
+	return std.SetLevel(level)
+}
+
 func (l *Logger) Sync() error {
 	return l.l.Sync()
 }
@@ -116,3 +132,64 @@ func Sync() error {
 	}
 	return nil
 }
+
+// WithErrorHook returns a logger writing to the same output that also passes
+// the entries at the error level and above, with their fields, to hook
+func (l *Logger) WithErrorHook(hook func(entry zapcore.Entry, fields []zapcore.Field)) *Logger {
+	return &Logger{
+		l: l.l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &hookCore{Core: core, hook: hook}
+		})),
+		level: l.level,
+	}
+}
+
+// hookCore passes the written error entries to a hook
+type hookCore struct {
+	zapcore.Core
+	hook   func(entry zapcore.Entry, fields []zapcore.Field)
+	fields []zapcore.Field
+}
+
+func (c *hookCore) With(fields []zapcore.Field) zapcore.Core {
+	return &hookCore{
+		Core:   c.Core.With(fields),
+		hook:   c.hook,
+		fields: append(c.fields[:len(c.fields):len(c.fields)], fields...),
+	}
+}
+
+func (c *hookCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *hookCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	err := c.Core.Write(entry, fields)
+	if entry.Level >= zapcore.ErrorLevel {
+		c.hook(entry, append(c.fields[:len(c.fields):len(c.fields)], fields...))
+	}
+	return err
+}
+
+// With returns a logger adding fields to every entry
+func (l *Logger) With(fields ...zap.Field) *Logger {
+	return &Logger{l: l.l.With(fields...), level: l.level}
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying logger
+func NewContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger
+func FromContext(ctx context.Context) *Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return logger
+	}
+	return Default()
+}
@@ -0,0 +1,96 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Formats of the log entries
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Destinations of the log entries
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+	OutputSyslog = "syslog"
+)
+
+// Options configure where and how Open writes the log entries
+type Options struct {
+	Level string
+	// Format is FormatJSON, the default, or FormatConsole
+	Format string
+	// Output is one of the Output constants, the writer passed to Open when
+	// empty
+	Output string
+	// File is the path written to with OutputFile. It is rotated once it
+	// reaches MaxSize megabytes, and the rotated files older than MaxAge days
+	// or beyond the MaxBackups newest are deleted, zero keeping them all.
+	File       string
+	MaxSize    int
+	MaxAge     int
+	MaxBackups int
+	// SyslogTag is the tag of the entries written with OutputSyslog
+	SyslogTag string
+}
+
+// Open creates a logger writing to the output and in the format of opts,
+// writing to out when no output is set
+func Open(out io.Writer, opts Options) (*Logger, error) {
+	level, err := zapcore.ParseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	atomicLevel := zap.NewAtomicLevelAt(level)
+
+	var encoder zapcore.Encoder
+	switch opts.Format {
+	case "", FormatJSON:
+		encoder = zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	case FormatConsole:
+		encoder = zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+	default:
+		return nil, fmt.Errorf("unknown log format %q", opts.Format)
+	}
+
+	var core zapcore.Core
+	switch opts.Output {
+	case "":
+		core = zapcore.NewCore(encoder, zapcore.AddSync(out), atomicLevel)
+	case OutputStdout:
+		core = zapcore.NewCore(encoder, zapcore.Lock(os.Stdout), atomicLevel)
+	case OutputStderr:
+		core = zapcore.NewCore(encoder, zapcore.Lock(os.Stderr), atomicLevel)
+	case OutputFile:
+		if opts.File == "" {
+			return nil, fmt.Errorf("no log file set")
+		}
+		core = zapcore.NewCore(encoder, zapcore.AddSync(&lumberjack.Logger{
+			Filename:   opts.File,
+			MaxSize:    opts.MaxSize,
+			MaxAge:     opts.MaxAge,
+			MaxBackups: opts.MaxBackups,
+		}), atomicLevel)
+	case OutputSyslog:
+		core, err = newSyslogCore(encoder, atomicLevel, opts.SyslogTag)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown log output %q", opts.Output)
+	}
+
+	return &Logger{
+		l:     zap.New(core),
+		level: atomicLevel,
+	}, nil
+}
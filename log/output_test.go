@@ -0,0 +1,49 @@
+package log_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coolguy1771/wastebin/log"
+)
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wastebin.log")
+	logger, err := log.Open(nil, log.Options{Level: "INFO", Output: log.OutputFile, File: path, MaxSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("Hidden")
+	logger.Info("Written")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 1 || !strings.Contains(lines[0], `"msg":"Written"`) {
+		t.Errorf("unexpected log file %s", data)
+	}
+
+	var output strings.Builder
+	logger, err = log.Open(&output, log.Options{Level: "INFO", Format: log.FormatConsole})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("Readable")
+	if !strings.Contains(output.String(), "INFO\tReadable") {
+		t.Errorf("unexpected console output %q", output.String())
+	}
+
+	for _, opts := range []log.Options{
+		{Level: "LOUD"},
+		{Level: "INFO", Format: "xml"},
+		{Level: "INFO", Output: "printer"},
+		{Level: "INFO", Output: log.OutputFile},
+	} {
+		if _, err := log.Open(&output, opts); err == nil {
+			t.Errorf("expected an error for %+v", opts)
+		}
+	}
+}
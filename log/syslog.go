@@ -0,0 +1,66 @@
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"log/syslog"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// syslogCore writes the entries to the local syslog daemon, or journald, with
+// the severity of their level
+type syslogCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	writer  *syslog.Writer
+}
+
+func newSyslogCore(encoder zapcore.Encoder, enabler zapcore.LevelEnabler, tag string) (zapcore.Core, error) {
+	writer, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogCore{LevelEnabler: enabler, encoder: encoder, writer: writer}, nil
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	encoder := c.encoder.Clone()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	return &syslogCore{LevelEnabler: c.LevelEnabler, encoder: encoder, writer: c.writer}
+}
+
+func (c *syslogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *syslogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buffer, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	defer buffer.Free()
+
+	message := buffer.String()
+	switch entry.Level {
+	case zapcore.DebugLevel:
+		return c.writer.Debug(message)
+	case zapcore.InfoLevel:
+		return c.writer.Info(message)
+	case zapcore.WarnLevel:
+		return c.writer.Warning(message)
+	case zapcore.ErrorLevel:
+		return c.writer.Err(message)
+	default:
+		return c.writer.Crit(message)
+	}
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
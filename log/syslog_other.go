@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package log
+
+import (
+	"errors"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func newSyslogCore(encoder zapcore.Encoder, enabler zapcore.LevelEnabler, tag string) (zapcore.Core, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
@@ -1,26 +1,208 @@
 package models
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/coolguy1771/wastebin/thumbnail"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// CreatePasteRequest is a paste to create. Its validate tags hold the checks
+// that don't depend on the configuration.
 type CreatePasteRequest struct {
-	Content    string
-	Burn       bool
-	Language   string
-	ExpiryTime string
+	Content     string
+	Burn        bool
+	Language    string `form:"extension" validate:"max=64"`
+	ExpiryTime  string
+	Title       string `form:"title" validate:"max=256"`
+	Description string `form:"description" validate:"max=1024"`
+}
+
+// Visibility controls who can find and read a paste
+type Visibility string
+
+const (
+	// VisibilityPublic pastes are listed
+	VisibilityPublic Visibility = "public"
+	// VisibilityUnlisted pastes can be read by anyone with their link
+	VisibilityUnlisted Visibility = "unlisted"
+	// VisibilityPrivate pastes can only be read with their owner token
+	VisibilityPrivate Visibility = "private"
+)
+
+// Valid reports whether v is a known visibility
+func (v Visibility) Valid() bool {
+	switch v {
+	case VisibilityPublic, VisibilityUnlisted, VisibilityPrivate:
+		return true
+	}
+	return false
 }
 
 type Paste struct {
-	Content         string    `json:"content" example:"Paste A"`
-	Burn            bool      `json:"burn" example:"false"`
-	Language        string    `json:"language" example:"go"`
-	UUID            uuid.UUID `json:"paste_id" gorm:"type:uuid"`
-	ExpiryTimestamp time.Time `json:"expiry_timestamp" example:"2021-01-01T00:00:00Z"`
+	Content         string     `json:"content" example:"Paste A"`
+	Burn            bool       `json:"burn" example:"false"`
+	Language        string     `json:"language" example:"go"`
+	UUID            uuid.UUID  `json:"paste_id" gorm:"type:uuid;uniqueIndex"`
+	ExpiryTimestamp time.Time  `json:"expiry_timestamp" example:"2021-01-01T00:00:00Z" gorm:"index"`
+	CreatedAt       time.Time  `json:"created_at" example:"2021-01-01T00:00:00Z" gorm:"index"`
+	Title           string     `json:"title" example:"Deploy script"`
+	Description     string     `json:"description" example:"Rolls out the staging cluster"`
+	Tags            []string   `json:"tags" gorm:"-"`
+	Visibility      Visibility `json:"visibility" gorm:"default:unlisted" example:"unlisted"`
+	Views           int64      `json:"views" example:"3"`
+	// ParentID is the paste this one was forked from
+	ParentID *uuid.UUID `json:"forked_from,omitempty" gorm:"type:uuid;index"`
+	// PublishAt is when an embargoed paste becomes readable by anyone but its owner
+	PublishAt *time.Time `json:"publish_at,omitempty" gorm:"index" example:"2021-01-01T00:00:00Z"`
+	// Quarantined pastes are only readable by the admin until they are released
+	Quarantined bool `json:"quarantined,omitempty"`
+	// ContentType is the MIME type of attachments, whose content is Data
+	// instead of Content. It is empty for text pastes.
+	ContentType string `json:"content_type,omitempty" example:"image/png"`
+	Data        []byte `json:"data,omitempty"`
+	// Thumbnail is the PNG preview of image attachments, see Derive
+	Thumbnail []byte `json:"-"`
+	// Annotations are the notes left on lines of the content
+	Annotations []Annotation `json:"annotations,omitempty" gorm:"-"`
+	// Truncation is set when only a preview of a large content was read
+	Truncation *Truncation `json:"truncated,omitempty" gorm:"-"`
+	// OwnerTokenHash is the SHA-256 of the token that reads a private paste
+	OwnerTokenHash string `json:"-"`
+	// Fields derived from the content, see Derive
+	Size     int64  `json:"size" example:"7"`
+	Checksum string `json:"checksum" example:"sha256:24f2c6379c180f71c181af8e43edcbe5fb5419b9d9289b2edaba2078ac61042b"`
+}
+
+// Derive computes the fields derived from the content. New derived fields are
+// added here and backfilled for existing pastes with the reindex command.
+func (p *Paste) Derive() {
+	data := []byte(p.Content)
+	if p.Attachment() {
+		data = p.Data
+	}
+	sum := sha256.Sum256(data)
+	p.Size = int64(len(data))
+	p.Checksum = "sha256:" + hex.EncodeToString(sum[:])
+
+	// Images that can't be decoded are kept without a thumbnail
+	p.Thumbnail = nil
+	if thumbnail.Supported(p.mediaType()) {
+		p.Thumbnail, _ = thumbnail.Generate(p.Data)
+	}
+}
+
+// Attachment reports whether the paste is binary content stored in Data
+func (p *Paste) Attachment() bool {
+	return p.ContentType != ""
+}
+
+// Image reports whether the paste is an image attachment, which browsers
+// display inline
+func (p *Paste) Image() bool {
+	return inlineTypes[p.mediaType()]
+}
+
+// mediaType returns the content type without its parameters
+func (p *Paste) mediaType() string {
+	mediaType, _, _ := strings.Cut(p.ContentType, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// inlineTypes are the MIME types of the images served inline. SVG images are
+// left out as they can carry scripts.
+var inlineTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+	"image/avif": true,
+}
+
+// SetOwnerToken generates the token of the owner of the paste, which deletes
+// it and reads it when it is private, and stores its hash. The token itself is
+// only returned to the creator.
+func (p *Paste) SetOwnerToken() (string, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	p.OwnerTokenHash = HashToken(token)
+	return token, nil
+}
+
+// Published reports whether the paste is no longer embargoed at now
+func (p *Paste) Published(now time.Time) bool {
+	return p.PublishAt == nil || !now.Before(*p.PublishAt)
+}
+
+// OwnedBy reports whether token is the owner token of the paste
+func (p *Paste) OwnedBy(token string) bool {
+	if p.OwnerTokenHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(p.OwnerTokenHash)) == 1
+}
+
+// newToken generates a random token
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the SHA-256 of an owner or share token, which is stored
+// instead of the token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Limits of the tags of a paste
+const (
+	MaxTags      = 10
+	MaxTagLength = 32
+)
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// NormalizeTags validates the tags of a paste and returns them sorted.
+// Tags are lowercased and deduplicated so that Go and go are the same tag.
+func NormalizeTags(names []string) ([]string, error) {
+	seen := make(map[string]bool)
+	tags := []string{}
+	for _, tag := range names {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > MaxTagLength {
+			return nil, fmt.Errorf("Tags cannot be longer than %d characters", MaxTagLength)
+		}
+		if !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("Invalid tag %q, tags may only contain letters, digits, - and _", tag)
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	if len(tags) > MaxTags {
+		return nil, fmt.Errorf("A paste cannot have more than %d tags", MaxTags)
+	}
+	sort.Strings(tags)
+	return tags, nil
 }
 
 type DB struct {
@@ -29,3 +211,222 @@ type DB struct {
 	DBName  string
 	Retries int
 }
+
+// AuditEvent records a security relevant action such as a deletion
+type AuditEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Timestamp time.Time `json:"timestamp" gorm:"index"`
+	Action    string    `json:"action" example:"paste.delete"`
+	Actor     string    `json:"actor" example:"203.0.113.7"`
+	Target    string    `json:"target" example:"5b7b1c1e-3c2a-4e0e-9a59-7a8d2d3c4b5a"`
+}
+
+// ScanFinding records why a content scanner quarantined or flagged a paste
+// for admin review
+type ScanFinding struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PasteUUID uuid.UUID `json:"paste_id" gorm:"type:uuid;index"`
+	Scanner   string    `json:"scanner" example:"secrets"`
+	Reason    string    `json:"reason" example:"AWS access key"`
+	Action    string    `json:"action" example:"quarantine"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// PasteReport is a report of an abusive paste waiting for moderation. Every
+// client reports a paste at most once.
+type PasteReport struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PasteUUID uuid.UUID `json:"paste_id" gorm:"type:uuid;uniqueIndex:idx_paste_reports_paste_reporter"`
+	Reporter  string    `json:"reporter" gorm:"uniqueIndex:idx_paste_reports_paste_reporter" example:"203.0.113.7"`
+	Reason    string    `json:"reason" example:"Phishing page"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Annotation is a note left on a line of a paste
+type Annotation struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PasteUUID uuid.UUID `json:"-" gorm:"type:uuid;index"`
+	Line      int       `json:"line" example:"12"`
+	Note      string    `json:"note" example:"This should check the error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ScopeRead is the scope of share tokens reading a paste
+const ScopeRead = "read"
+
+// ShareToken lets whoever has it read a private or embargoed paste without
+// its owner token, until it expires
+type ShareToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	PasteUUID uuid.UUID  `json:"-" gorm:"type:uuid;index"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex"`
+	Scope     string     `json:"scope" example:"read"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2021-01-01T00:00:00Z"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NewShareToken generates a read-only share token of the paste and returns it
+// with its record, which keeps only its hash
+func NewShareToken(paste uuid.UUID, expiresAt *time.Time) (string, ShareToken, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", ShareToken{}, err
+	}
+	return token, ShareToken{PasteUUID: paste, TokenHash: HashToken(token), Scope: ScopeRead, ExpiresAt: expiresAt}, nil
+}
+
+// UserSettings are the defaults a client keeps on the server for the pastes
+// it creates, applied when the creation omits them. They are not an account:
+// their token only selects them and grants nothing else.
+type UserSettings struct {
+	ID        uint   `json:"-" gorm:"primaryKey"`
+	TokenHash string `json:"-" gorm:"uniqueIndex"`
+	// Expires is the default expiry in minutes, 0 for the server default
+	Expires    int64      `json:"expires" example:"1440"`
+	Language   string     `json:"extension" example:"go"`
+	Visibility Visibility `json:"visibility,omitempty" example:"private"`
+	Burn       bool       `json:"burn"`
+	CreatedAt  time.Time  `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedAt  time.Time  `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+}
+
+// SetToken generates the token of the settings and stores its hash. The
+// token itself is only returned to the client.
+func (s *UserSettings) SetToken() (string, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	s.TokenHash = HashToken(token)
+	return token, nil
+}
+
+// Types of the events of the outbox
+const (
+	EventPasteCreated = "paste.created"
+	EventPasteDeleted = "paste.deleted"
+	EventPasteBurned  = "paste.burned"
+	EventPasteExpired = "paste.expired"
+)
+
+// OutboxEvent is a change of a paste stored in the transaction making it,
+// kept until it is delivered to the webhook or its retention is over
+type OutboxEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Type      string    `json:"type" example:"paste.created"`
+	PasteUUID uuid.UUID `json:"paste_id" gorm:"type:uuid"`
+	// Payload is the JSON metadata of the paste
+	Payload   string    `json:"-"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	// Attempts counts the failed deliveries, retried from NextAttemptAt
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// QuotaUsage counts the pastes a client created during a quota period
+type QuotaUsage struct {
+	Client      string    `json:"client" gorm:"primaryKey"`
+	Period      string    `json:"period" gorm:"primaryKey"`
+	PeriodStart time.Time `json:"period_start" gorm:"primaryKey;index"`
+	Pastes      int64     `json:"pastes"`
+	Bytes       int64     `json:"bytes"`
+}
+
+// Tag labels pastes so they can be found by topic
+type Tag struct {
+	ID   uint   `json:"-" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"uniqueIndex" example:"kubernetes"`
+}
+
+// PasteTag links a paste to one of its tags
+type PasteTag struct {
+	PasteUUID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	TagID     uint      `gorm:"primaryKey;index"`
+}
+
+// TagCount is a tag with the number of listed pastes using it
+type TagCount struct {
+	Name  string `json:"name" example:"kubernetes"`
+	Count int64  `json:"count" example:"3"`
+}
+
+// Truncation describes the preview read instead of the content of a large
+// paste, Size being the size of the whole content
+type Truncation struct {
+	// PreviewSize is the number of bytes of the content in the preview
+	PreviewSize int64 `json:"preview_size" example:"1048576"`
+	// RawURL serves the whole content, or parts of it with Range requests
+	RawURL string `json:"raw_url" example:"https://paste.example.com/paste/2b2c0c14-9d0a-4a51-8a5d-6a5a4e1c4f43/raw"`
+	// NextRange is the Range header reading the content after the preview
+	NextRange string `json:"next_range" example:"bytes=1048576-"`
+	// FullURL reads the paste with its whole content
+	FullURL string `json:"full_url" example:"https://paste.example.com/api/v1/paste/2b2c0c14-9d0a-4a51-8a5d-6a5a4e1c4f43?full=true"`
+}
+
+// PasteSummary describes a listed paste without its content
+type PasteSummary struct {
+	UUID            uuid.UUID  `json:"paste_id"`
+	Language        string     `json:"language" example:"go"`
+	Title           string     `json:"title" example:"Deploy script"`
+	Description     string     `json:"description" example:"Rolls out the staging cluster"`
+	Tags            []string   `json:"tags" gorm:"-"`
+	Size            int64      `json:"size" example:"7"`
+	ParentID        *uuid.UUID `json:"forked_from,omitempty"`
+	ExpiryTimestamp time.Time  `json:"expiry_timestamp" example:"2021-01-01T00:00:00Z" gorm:"index"`
+	CreatedAt       time.Time  `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	// ContentType is set for attachments, image ones having a thumbnail
+	ContentType  string `json:"content_type,omitempty" example:"image/png"`
+	HasThumbnail bool   `json:"-"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty" gorm:"-" example:"https://paste.example.com/paste/2b2c0c14-9d0a-4a51-8a5d-6a5a4e1c4f43/thumbnail.png"`
+}
+
+// IdempotencyKey remembers the response to a request a client sent with an
+// Idempotency-Key header. Status is 0 while the request is being handled.
+type IdempotencyKey struct {
+	Client      string    `json:"client" gorm:"primaryKey"`
+	Key         string    `json:"key" gorm:"primaryKey"`
+	RequestHash string    `json:"request_hash"`
+	Status      int       `json:"status"`
+	Body        string    `json:"body"`
+	CreatedAt   time.Time `json:"created_at" gorm:"index"`
+}
+
+// PasteMeta describes a paste without its content
+type PasteMeta struct {
+	UUID            uuid.UUID  `json:"paste_id"`
+	Language        string     `json:"language" example:"go"`
+	Title           string     `json:"title" example:"Deploy script"`
+	Description     string     `json:"description" example:"Rolls out the staging cluster"`
+	Burn            bool       `json:"burn" example:"false"`
+	Visibility      Visibility `json:"visibility" example:"unlisted"`
+	ContentType     string     `json:"content_type,omitempty" example:"image/png"`
+	Size            int64      `json:"size" example:"7"`
+	Checksum        string     `json:"checksum" example:"sha256:24f2c6379c180f71c181af8e43edcbe5fb5419b9d9289b2edaba2078ac61042b"`
+	Views           int64      `json:"views" example:"3"`
+	ParentID        *uuid.UUID `json:"forked_from,omitempty"`
+	PublishAt       *time.Time `json:"publish_at,omitempty" example:"2021-01-01T00:00:00Z"`
+	ExpiryTimestamp time.Time  `json:"expiry_timestamp" example:"2021-01-01T00:00:00Z" gorm:"index"`
+	CreatedAt       time.Time  `json:"created_at" example:"2021-01-01T00:00:00Z"`
+}
+
+// Meta returns the metadata of the paste
+func (p *Paste) Meta() PasteMeta {
+	return PasteMeta{
+		UUID:            p.UUID,
+		Language:        p.Language,
+		Title:           p.Title,
+		Description:     p.Description,
+		Burn:            p.Burn,
+		Visibility:      p.Visibility,
+		ContentType:     p.ContentType,
+		Size:            p.Size,
+		Checksum:        p.Checksum,
+		Views:           p.Views,
+		ParentID:        p.ParentID,
+		PublishAt:       p.PublishAt,
+		ExpiryTimestamp: p.ExpiryTimestamp,
+		CreatedAt:       p.CreatedAt,
+	}
+}
@@ -0,0 +1,85 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/coolguy1771/wastebin/models"
+)
+
+// Kafka produces the events to a topic through a Kafka REST Proxy, keyed by
+// the UUID of their paste so that the events of a paste keep their order.
+type Kafka struct {
+	url    string
+	client *http.Client
+}
+
+// kafkaRecords is the body of a produce request of the REST Proxy v2 API
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaRecord struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// kafkaOffsets is the answer of a produce request, with an error per record
+// the brokers refused
+type kafkaOffsets struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// NewKafka creates a Kafka producing to topic through the REST Proxy at
+// proxyURL
+func NewKafka(proxyURL, topic string) *Kafka {
+	return &Kafka{
+		url:    strings.TrimSuffix(proxyURL, "/") + "/topics/" + url.PathEscape(topic),
+		client: &http.Client{},
+	}
+}
+
+// Deliver produces an event and waits for the brokers to acknowledge it
+func (k *Kafka) Deliver(ctx context.Context, event models.OutboxEvent) error {
+	value, err := Encode(event)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(kafkaRecords{Records: []kafkaRecord{{Key: event.PasteUUID.String(), Value: value}}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("kafka rest proxy answered %s", resp.Status)
+	}
+	var offsets kafkaOffsets
+	if err := json.NewDecoder(resp.Body).Decode(&offsets); err != nil {
+		return err
+	}
+	for _, offset := range offsets.Offsets {
+		if offset.ErrorCode != nil {
+			return fmt.Errorf("kafka refused the event: %s", offset.Error)
+		}
+	}
+	return nil
+}
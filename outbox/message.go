@@ -0,0 +1,33 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+)
+
+// MessageVersion is the version of the message schema, raised when a change
+// breaks its consumers. The schema is described in schema/paste-event.json.
+const MessageVersion = 1
+
+// Message is the body of an event as delivered to the webhook and the
+// brokers
+type Message struct {
+	Version   int             `json:"version"`
+	ID        uint            `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Paste     json.RawMessage `json:"paste"`
+}
+
+// Encode returns the message of an event
+func Encode(event models.OutboxEvent) ([]byte, error) {
+	return json.Marshal(Message{
+		Version:   MessageVersion,
+		ID:        event.ID,
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt,
+		Paste:     json.RawMessage(event.Payload),
+	})
+}
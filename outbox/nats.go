@@ -0,0 +1,53 @@
+package outbox
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/nats-io/nats.go"
+)
+
+// NATS publishes the events on the subject prefix followed by the type of
+// the event, such as wastebin.events.paste.created. The Nats-Msg-Id header
+// carries the event ID, so that JetStream streams skip the repeated events.
+type NATS struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+// NewNATS connects to the comma separated NATS servers of url. The server
+// connection is retried in the background when they can't be reached, and the
+// events wait in the outbox meanwhile.
+func NewNATS(url, prefix string) (*NATS, error) {
+	conn, err := nats.Connect(url,
+		nats.Name("wastebin"),
+		nats.MaxReconnects(-1),
+		nats.RetryOnFailedConnect(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &NATS{conn: conn, prefix: prefix}, nil
+}
+
+// Deliver publishes an event and waits for the server to receive it
+func (n *NATS) Deliver(ctx context.Context, event models.OutboxEvent) error {
+	body, err := Encode(event)
+	if err != nil {
+		return err
+	}
+	msg := nats.NewMsg(n.prefix + "." + event.Type)
+	msg.Data = body
+	msg.Header.Set("Nats-Msg-Id", strconv.FormatUint(uint64(event.ID), 10))
+	msg.Header.Set("Content-Type", "application/json")
+	if err := n.conn.PublishMsg(msg); err != nil {
+		return err
+	}
+	return n.conn.FlushWithContext(ctx)
+}
+
+// Close closes the connection once the published events are sent
+func (n *NATS) Close() error {
+	return n.conn.Drain()
+}
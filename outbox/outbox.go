@@ -0,0 +1,246 @@
+// Package outbox delivers the events of the pastes stored in the outbox
+// table. Events are stored in the transaction changing the paste and
+// delivered afterwards, retried until they succeed, so every change is
+// delivered at least once even when the server crashes meanwhile.
+package outbox
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/storage"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// batchSize is the number of events delivered per poll
+	batchSize = 100
+	// maxBackoff caps the wait between the attempts of an event
+	maxBackoff = time.Hour
+	// pruneInterval is how often the events past their retention are deleted
+	pruneInterval = time.Hour
+)
+
+// Sink delivers events, such as a webhook
+type Sink interface {
+	Deliver(ctx context.Context, event models.OutboxEvent) error
+}
+
+// Sinks delivers the events to each of its sinks in turn. An event failing
+// to reach one of them is delivered again to all of them.
+type Sinks []Sink
+
+// Deliver delivers an event to every sink, stopping at the first failure
+func (s Sinks) Deliver(ctx context.Context, event models.OutboxEvent) error {
+	for _, sink := range s {
+		if err := sink.Deliver(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Config sets how the events are delivered and kept
+type Config struct {
+	// Interval is the time between the polls of the outbox
+	Interval time.Duration
+	// Timeout bounds a delivery
+	Timeout time.Duration
+	// Retention is how long the events are kept, delivered or not
+	Retention time.Duration
+}
+
+// Stats are the delivery counters of the dispatcher since startup
+type Stats struct {
+	Delivered int64 `json:"delivered"`
+	Failed    int64 `json:"failed"`
+	// Pending is the number of events not delivered yet, across dispatchers
+	Pending         int64      `json:"pending"`
+	LastDeliveredAt *time.Time `json:"last_delivered_at,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+}
+
+// Dispatcher polls the outbox and delivers the due events to its sink, the
+// oldest first. Failed deliveries are retried with an exponential backoff.
+type Dispatcher struct {
+	db     *gorm.DB
+	sink   Sink
+	config Config
+	logger *log.Logger
+
+	done      chan struct{}
+	stopped   chan struct{}
+	stop      sync.Once
+	lastPrune time.Time
+
+	mu            sync.Mutex
+	delivered     int64
+	failed        int64
+	lastDelivered time.Time
+	lastError     string
+}
+
+// New creates a Dispatcher. Without a sink the events are only pruned.
+func New(db *gorm.DB, sink Sink, config Config, logger *log.Logger) *Dispatcher {
+	return &Dispatcher{
+		db:      db,
+		sink:    sink,
+		config:  config,
+		logger:  logger,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+// Start polls the outbox in the background until Stop is called
+func (d *Dispatcher) Start() {
+	go func() {
+		defer close(d.stopped)
+		ticker := time.NewTicker(d.config.Interval)
+		defer ticker.Stop()
+		for {
+			d.Dispatch(time.Now())
+			select {
+			case <-d.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops polling the outbox and waits for the delivery in progress
+func (d *Dispatcher) Stop() {
+	d.stop.Do(func() {
+		close(d.done)
+		select {
+		case <-d.stopped:
+		case <-time.After(d.config.Timeout):
+		}
+	})
+}
+
+// Dispatch delivers the events due by now and returns how many were
+// delivered. Events past their retention are pruned first.
+func (d *Dispatcher) Dispatch(now time.Time) int {
+	d.prune(now)
+	if d.sink == nil {
+		return 0
+	}
+
+	events, err := storage.PendingEvents(d.db, now, batchSize)
+	if err != nil {
+		d.logger.Error("Error reading the outbox", zap.Error(err))
+		return 0
+	}
+	delivered := 0
+	for i := range events {
+		select {
+		case <-d.done:
+			return delivered
+		default:
+		}
+		if d.deliver(&events[i]) {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// deliver claims and delivers an event and reports whether it was delivered
+func (d *Dispatcher) deliver(event *models.OutboxEvent) bool {
+	// The claim outlasts the delivery so that it isn't attempted twice
+	claimed, err := storage.ClaimEvent(d.db, event, time.Now().Add(2*d.config.Timeout))
+	if err != nil {
+		d.logger.Error("Error claiming outbox event", zap.Uint("id", event.ID), zap.Error(err))
+		return false
+	}
+	if !claimed {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+	err = d.sink.Deliver(ctx, *event)
+	cancel()
+	if err != nil {
+		next := time.Now().Add(backoff(event.Attempts))
+		d.logger.Warn("Error delivering outbox event", zap.Uint("id", event.ID), zap.String("type", event.Type),
+			zap.Int("attempts", event.Attempts+1), zap.Time("next_attempt", next), zap.Error(err))
+		d.record(false, err.Error())
+		if err := storage.MarkEventFailed(d.db, event.ID, next, err.Error()); err != nil {
+			d.logger.Error("Error recording failed outbox event", zap.Uint("id", event.ID), zap.Error(err))
+		}
+		return false
+	}
+	d.record(true, "")
+	if err := storage.MarkEventDelivered(d.db, event.ID, time.Now()); err != nil {
+		// The event is delivered again once the claim is over
+		d.logger.Error("Error recording delivered outbox event", zap.Uint("id", event.ID), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// record counts a delivery attempt
+func (d *Dispatcher) record(delivered bool, reason string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if delivered {
+		d.delivered++
+		d.lastDelivered = time.Now()
+		return
+	}
+	d.failed++
+	d.lastError = reason
+}
+
+// Stats returns the delivery counters of the dispatcher and the number of
+// events pending in the outbox
+func (d *Dispatcher) Stats() (Stats, error) {
+	pending, err := storage.CountPendingEvents(d.db)
+	if err != nil {
+		return Stats{}, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stats := Stats{Delivered: d.delivered, Failed: d.failed, Pending: pending, LastError: d.lastError}
+	if !d.lastDelivered.IsZero() {
+		lastDelivered := d.lastDelivered
+		stats.LastDeliveredAt = &lastDelivered
+	}
+	return stats, nil
+}
+
+// prune deletes the events past their retention, at most once per
+// pruneInterval
+func (d *Dispatcher) prune(now time.Time) {
+	if d.config.Retention <= 0 || now.Sub(d.lastPrune) < pruneInterval {
+		return
+	}
+	d.lastPrune = now
+	pruned, err := storage.PruneEvents(d.db, now.Add(-d.config.Retention))
+	if err != nil {
+		d.logger.Error("Error pruning the outbox", zap.Error(err))
+		return
+	}
+	if pruned > 0 {
+		d.logger.Info("Pruned old outbox events", zap.Int64("pruned", pruned))
+	}
+}
+
+// backoff returns the wait before attempting an event again after it failed
+// attempts times already, doubling from 10 seconds up to maxBackoff
+func backoff(attempts int) time.Duration {
+	wait := 10 * time.Second
+	for i := 0; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff {
+		return maxBackoff
+	}
+	return wait
+}
@@ -0,0 +1,294 @@
+package outbox_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/outbox"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type sink struct {
+	fail   bool
+	events []models.OutboxEvent
+}
+
+func (s *sink) Deliver(ctx context.Context, event models.OutboxEvent) error {
+	if s.fail {
+		return errors.New("connection refused")
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestDispatcher(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:outbox?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Migrate(db, log.Default()); err != nil {
+		t.Fatal(err)
+	}
+
+	paste := models.Paste{UUID: uuid.New(), Content: "Paste A", Burn: true, ExpiryTimestamp: time.Now().Add(time.Hour)}
+	if err := storage.CreatePaste(db, &paste); err != nil {
+		t.Fatal(err)
+	}
+	// The events of failed changes are rolled back with them
+	if err := storage.CreatePaste(db, &models.Paste{UUID: paste.UUID, Content: "Taken"}); err == nil {
+		t.Fatal("expected the paste with a taken UUID refused")
+	}
+	if _, err := storage.DeletePaste(db, paste.UUID, models.EventPasteBurned); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &sink{fail: true}
+	dispatcher := outbox.New(db, s, outbox.Config{Interval: time.Second, Timeout: time.Second}, log.Default())
+	now := time.Now()
+	if delivered := dispatcher.Dispatch(now); delivered != 0 {
+		t.Fatalf("expected no event delivered while the sink fails, got %d", delivered)
+	}
+	var failed models.OutboxEvent
+	if err := db.First(&failed).Error; err != nil {
+		t.Fatal(err)
+	}
+	if failed.Attempts != 1 || failed.LastError != "connection refused" || !failed.NextAttemptAt.After(now) {
+		t.Errorf("unexpected failed event %+v", failed)
+	}
+
+	// The failed events wait for their backoff
+	s.fail = false
+	if delivered := dispatcher.Dispatch(now); delivered != 0 {
+		t.Fatalf("expected the failed events to wait, got %d delivered", delivered)
+	}
+	if delivered := dispatcher.Dispatch(now.Add(time.Minute)); delivered != 2 {
+		t.Fatalf("expected both events delivered, got %d", delivered)
+	}
+	if len(s.events) != 2 || s.events[0].Type != models.EventPasteCreated || s.events[1].Type != models.EventPasteBurned {
+		t.Fatalf("unexpected events %+v", s.events)
+	}
+	var meta models.PasteMeta
+	if err := json.Unmarshal([]byte(s.events[1].Payload), &meta); err != nil || meta.UUID != paste.UUID || !meta.Burn {
+		t.Errorf("unexpected payload %s", s.events[1].Payload)
+	}
+	if delivered := dispatcher.Dispatch(now.Add(time.Hour)); delivered != 0 {
+		t.Errorf("expected the delivered events not delivered again, got %d", delivered)
+	}
+
+	stats, err := dispatcher.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Delivered != 2 || stats.Failed != 2 || stats.Pending != 0 || stats.LastDeliveredAt == nil || stats.LastError != "connection refused" {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var received struct {
+		ID    uint             `json:"id"`
+		Type  string           `json:"type"`
+		Paste models.PasteMeta `json:"paste"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Wastebin-Signature") != "sha256="+outbox.Sign([]byte("secret"), body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("X-Wastebin-Event") != models.EventPasteDeleted || r.Header.Get("X-Wastebin-Delivery") != "7" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.Unmarshal(body, &received)
+	}))
+	defer server.Close()
+
+	id := uuid.New()
+	event := models.OutboxEvent{ID: 7, Type: models.EventPasteDeleted, PasteUUID: id, Payload: `{"paste_id":"` + id.String() + `"}`}
+	if err := outbox.NewWebhook(server.URL, "secret").Deliver(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if received.ID != 7 || received.Type != models.EventPasteDeleted || received.Paste.UUID != id {
+		t.Errorf("unexpected webhook body %+v", received)
+	}
+	if err := outbox.NewWebhook(server.URL, "other").Deliver(context.Background(), event); err == nil {
+		t.Error("expected the webhook refusing the event to fail the delivery")
+	}
+}
+
+func TestSchema(t *testing.T) {
+	raw, err := os.ReadFile("schema/paste-event.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	type object struct {
+		Required   []string                   `json:"required"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	var schema, pasteSchema object
+	var typeSchema struct {
+		Enum []string `json:"enum"`
+	}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(schema.Properties["paste"], &pasteSchema); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(schema.Properties["type"], &typeSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every field of the message and of the paste metadata is described
+	paste := models.Paste{UUID: uuid.New(), ContentType: "text/plain", ParentID: &uuid.UUID{}, PublishAt: &time.Time{}}
+	payload, _ := json.Marshal(paste.Meta())
+	body, err := outbox.Encode(models.OutboxEvent{ID: 1, Type: models.EventPasteCreated, Payload: string(payload)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var message, meta map[string]json.RawMessage
+	json.Unmarshal(body, &message)
+	json.Unmarshal(payload, &meta)
+	for key := range message {
+		if _, ok := schema.Properties[key]; !ok {
+			t.Errorf("message field %s missing from the schema", key)
+		}
+	}
+	for key := range meta {
+		if _, ok := pasteSchema.Properties[key]; !ok {
+			t.Errorf("paste field %s missing from the schema", key)
+		}
+	}
+	for _, key := range append(schema.Required, pasteSchema.Required...) {
+		if _, ok := message[key]; !ok {
+			if _, ok := meta[key]; !ok {
+				t.Errorf("required field %s missing from the message", key)
+			}
+		}
+	}
+	for _, eventType := range []string{models.EventPasteCreated, models.EventPasteDeleted, models.EventPasteBurned, models.EventPasteExpired} {
+		if !strings.Contains(strings.Join(typeSchema.Enum, " "), eventType) {
+			t.Errorf("event type %s missing from the schema", eventType)
+		}
+	}
+}
+
+func TestKafka(t *testing.T) {
+	var records struct {
+		Records []struct {
+			Key   string         `json:"key"`
+			Value outbox.Message `json:"value"`
+		} `json:"records"`
+	}
+	refuse := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/topics/wastebin.events" || r.Header.Get("Content-Type") != "application/vnd.kafka.json.v2+json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&records)
+		if refuse {
+			io.WriteString(w, `{"offsets":[{"partition":null,"offset":null,"error_code":50003,"error":"Kafka error"}]}`)
+			return
+		}
+		io.WriteString(w, `{"offsets":[{"partition":0,"offset":12,"error_code":null,"error":null}]}`)
+	}))
+	defer server.Close()
+
+	id := uuid.New()
+	event := models.OutboxEvent{ID: 3, Type: models.EventPasteExpired, PasteUUID: id, Payload: `{"paste_id":"` + id.String() + `"}`}
+	kafka := outbox.NewKafka(server.URL+"/", "wastebin.events")
+	if err := kafka.Deliver(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if len(records.Records) != 1 || records.Records[0].Key != id.String() || records.Records[0].Value.ID != 3 ||
+		records.Records[0].Value.Version != outbox.MessageVersion || records.Records[0].Value.Type != models.EventPasteExpired {
+		t.Errorf("unexpected records %+v", records)
+	}
+	refuse = true
+	if err := kafka.Deliver(context.Background(), event); err == nil || !strings.Contains(err.Error(), "Kafka error") {
+		t.Errorf("expected the refused record to fail the delivery, got %v", err)
+	}
+}
+
+// serveNATS answers a NATS client on listener and sends the subject, headers
+// and payload of each message it publishes to published
+func serveNATS(listener net.Listener, published chan<- [3]string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "INFO {\"server_id\":\"test\",\"version\":\"2.2.0\",\"proto\":1,\"headers\":true,\"max_payload\":1048576}\r\n")
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case fields[0] == "PING":
+			fmt.Fprint(conn, "PONG\r\n")
+		case fields[0] == "HPUB" && len(fields) == 4:
+			headerSize, _ := strconv.Atoi(fields[2])
+			size, _ := strconv.Atoi(fields[3])
+			msg := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, msg); err != nil {
+				return
+			}
+			published <- [3]string{fields[1], string(msg[:headerSize]), string(msg[headerSize:size])}
+		}
+	}
+}
+
+func TestNATS(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	published := make(chan [3]string, 1)
+	go serveNATS(listener, published)
+
+	nats, err := outbox.NewNATS("nats://"+listener.Addr().String(), "wastebin.events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nats.Close()
+
+	id := uuid.New()
+	event := models.OutboxEvent{ID: 9, Type: models.EventPasteCreated, PasteUUID: id, Payload: `{"paste_id":"` + id.String() + `"}`}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := nats.Deliver(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+	msg := <-published
+	var message outbox.Message
+	if err := json.Unmarshal([]byte(msg[2]), &message); err != nil {
+		t.Fatal(err)
+	}
+	if msg[0] != "wastebin.events.paste.created" || !strings.Contains(msg[1], "Nats-Msg-Id: 9") || message.ID != 9 {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
@@ -0,0 +1,64 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/coolguy1771/wastebin/models"
+)
+
+// Webhook posts the events as JSON to a URL. With a secret the body is
+// signed with HMAC-SHA256 in the X-Wastebin-Signature header, so that the
+// receiver can check it comes from the server.
+type Webhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhook creates a Webhook posting to url
+func NewWebhook(url, secret string) *Webhook {
+	return &Webhook{url: url, secret: []byte(secret), client: &http.Client{}}
+}
+
+// Deliver posts an event. Receivers get every event at least once and can
+// skip the repeated ones by their X-Wastebin-Delivery header.
+func (w *Webhook) Deliver(ctx context.Context, event models.OutboxEvent) error {
+	body, err := Encode(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Wastebin-Event", event.Type)
+	req.Header.Set("X-Wastebin-Delivery", strconv.FormatUint(uint64(event.ID), 10))
+	if len(w.secret) > 0 {
+		req.Header.Set("X-Wastebin-Signature", "sha256="+Sign(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of body with secret
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,4 @@
+// Package wastebinv1 holds the gRPC API generated from paste.proto
+package wastebinv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative wastebin/v1/paste.proto
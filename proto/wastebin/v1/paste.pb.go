@@ -0,0 +1,982 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        (unknown)
+// source: wastebin/v1/paste.proto
+
+package wastebinv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Visibility controls who can find and read a paste
+type Visibility int32
+
+const (
+	Visibility_VISIBILITY_UNSPECIFIED Visibility = 0
+	// Listed pastes
+	Visibility_VISIBILITY_PUBLIC Visibility = 1
+	// Pastes readable by anyone with their ID, the default
+	Visibility_VISIBILITY_UNLISTED Visibility = 2
+	// Pastes only readable with their owner token
+	Visibility_VISIBILITY_PRIVATE Visibility = 3
+)
+
+// Enum value maps for Visibility.
+var (
+	Visibility_name = map[int32]string{
+		0: "VISIBILITY_UNSPECIFIED",
+		1: "VISIBILITY_PUBLIC",
+		2: "VISIBILITY_UNLISTED",
+		3: "VISIBILITY_PRIVATE",
+	}
+	Visibility_value = map[string]int32{
+		"VISIBILITY_UNSPECIFIED": 0,
+		"VISIBILITY_PUBLIC":      1,
+		"VISIBILITY_UNLISTED":    2,
+		"VISIBILITY_PRIVATE":     3,
+	}
+)
+
+func (x Visibility) Enum() *Visibility {
+	p := new(Visibility)
+	*p = x
+	return p
+}
+
+func (x Visibility) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Visibility) Descriptor() protoreflect.EnumDescriptor {
+	return file_wastebin_v1_paste_proto_enumTypes[0].Descriptor()
+}
+
+func (Visibility) Type() protoreflect.EnumType {
+	return &file_wastebin_v1_paste_proto_enumTypes[0]
+}
+
+func (x Visibility) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Visibility.Descriptor instead.
+func (Visibility) EnumDescriptor() ([]byte, []int) {
+	return file_wastebin_v1_paste_proto_rawDescGZIP(), []int{0}
+}
+
+type Paste struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Empty in listings
+	Content     string     `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Language    string     `protobuf:"bytes,3,opt,name=language,proto3" json:"language,omitempty"`
+	Title       string     `protobuf:"bytes,4,opt,name=title,proto3" json:"title,omitempty"`
+	Description string     `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	Burn        bool       `protobuf:"varint,6,opt,name=burn,proto3" json:"burn,omitempty"`
+	Visibility  Visibility `protobuf:"varint,7,opt,name=visibility,proto3,enum=wastebin.v1.Visibility" json:"visibility,omitempty"`
+	Tags        []string   `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty"`
+	Size        int64      `protobuf:"varint,9,opt,name=size,proto3" json:"size,omitempty"`
+	Checksum    string     `protobuf:"bytes,10,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	Views       int64      `protobuf:"varint,11,opt,name=views,proto3" json:"views,omitempty"`
+	// ID of the paste this one was forked from
+	ForkedFrom string                 `protobuf:"bytes,12,opt,name=forked_from,json=forkedFrom,proto3" json:"forked_from,omitempty"`
+	CreatedAt  *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ExpiresAt  *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+}
+
+func (x *Paste) Reset() {
+	*x = Paste{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wastebin_v1_paste_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Paste) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Paste) ProtoMessage() {}
+
+func (x *Paste) ProtoReflect() protoreflect.Message {
+	mi := &file_wastebin_v1_paste_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Paste.ProtoReflect.Descriptor instead.
+func (*Paste) Descriptor() ([]byte, []int) {
+	return file_wastebin_v1_paste_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Paste) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Paste) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Paste) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *Paste) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Paste) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Paste) GetBurn() bool {
+	if x != nil {
+		return x.Burn
+	}
+	return false
+}
+
+func (x *Paste) GetVisibility() Visibility {
+	if x != nil {
+		return x.Visibility
+	}
+	return Visibility_VISIBILITY_UNSPECIFIED
+}
+
+func (x *Paste) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Paste) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Paste) GetChecksum() string {
+	if x != nil {
+		return x.Checksum
+	}
+	return ""
+}
+
+func (x *Paste) GetViews() int64 {
+	if x != nil {
+		return x.Views
+	}
+	return 0
+}
+
+func (x *Paste) GetForkedFrom() string {
+	if x != nil {
+		return x.ForkedFrom
+	}
+	return ""
+}
+
+func (x *Paste) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Paste) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type CreatePasteRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Content     string `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	Language    string `protobuf:"bytes,2,opt,name=language,proto3" json:"language,omitempty"`
+	Title       string `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Description string `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	Burn        bool   `protobuf:"varint,5,opt,name=burn,proto3" json:"burn,omitempty"`
+	// Minutes until the paste expires
+	ExpiresMinutes int64      `protobuf:"varint,6,opt,name=expires_minutes,json=expiresMinutes,proto3" json:"expires_minutes,omitempty"`
+	Visibility     Visibility `protobuf:"varint,7,opt,name=visibility,proto3,enum=wastebin.v1.Visibility" json:"visibility,omitempty"`
+	Tags           []string   `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Optional ID chosen by the client, generated when empty
+	Id string `protobuf:"bytes,9,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *CreatePasteRequest) Reset() {
+	*x = CreatePasteRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wastebin_v1_paste_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreatePasteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePasteRequest) ProtoMessage() {}
+
+func (x *CreatePasteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wastebin_v1_paste_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePasteRequest.ProtoReflect.Descriptor instead.
+func (*CreatePasteRequest) Descriptor() ([]byte, []int) {
+	return file_wastebin_v1_paste_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreatePasteRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *CreatePasteRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *CreatePasteRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *CreatePasteRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CreatePasteRequest) GetBurn() bool {
+	if x != nil {
+		return x.Burn
+	}
+	return false
+}
+
+func (x *CreatePasteRequest) GetExpiresMinutes() int64 {
+	if x != nil {
+		return x.ExpiresMinutes
+	}
+	return 0
+}
+
+func (x *CreatePasteRequest) GetVisibility() Visibility {
+	if x != nil {
+		return x.Visibility
+	}
+	return Visibility_VISIBILITY_UNSPECIFIED
+}
+
+func (x *CreatePasteRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CreatePasteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type CreatePasteResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Paste *Paste `protobuf:"bytes,1,opt,name=paste,proto3" json:"paste,omitempty"`
+	// Token deleting the paste and reading it when private, only returned on
+	// creation
+	OwnerToken string `protobuf:"bytes,2,opt,name=owner_token,json=ownerToken,proto3" json:"owner_token,omitempty"`
+}
+
+func (x *CreatePasteResponse) Reset() {
+	*x = CreatePasteResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wastebin_v1_paste_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreatePasteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePasteResponse) ProtoMessage() {}
+
+func (x *CreatePasteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wastebin_v1_paste_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePasteResponse.ProtoReflect.Descriptor instead.
+func (*CreatePasteResponse) Descriptor() ([]byte, []int) {
+	return file_wastebin_v1_paste_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreatePasteResponse) GetPaste() *Paste {
+	if x != nil {
+		return x.Paste
+	}
+	return nil
+}
+
+func (x *CreatePasteResponse) GetOwnerToken() string {
+	if x != nil {
+		return x.OwnerToken
+	}
+	return ""
+}
+
+type GetPasteRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *GetPasteRequest) Reset() {
+	*x = GetPasteRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wastebin_v1_paste_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetPasteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPasteRequest) ProtoMessage() {}
+
+func (x *GetPasteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wastebin_v1_paste_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPasteRequest.ProtoReflect.Descriptor instead.
+func (*GetPasteRequest) Descriptor() ([]byte, []int) {
+	return file_wastebin_v1_paste_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetPasteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetPasteResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Paste *Paste `protobuf:"bytes,1,opt,name=paste,proto3" json:"paste,omitempty"`
+}
+
+func (x *GetPasteResponse) Reset() {
+	*x = GetPasteResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wastebin_v1_paste_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetPasteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPasteResponse) ProtoMessage() {}
+
+func (x *GetPasteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wastebin_v1_paste_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPasteResponse.ProtoReflect.Descriptor instead.
+func (*GetPasteResponse) Descriptor() ([]byte, []int) {
+	return file_wastebin_v1_paste_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetPasteResponse) GetPaste() *Paste {
+	if x != nil {
+		return x.Paste
+	}
+	return nil
+}
+
+type DeletePasteRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *DeletePasteRequest) Reset() {
+	*x = DeletePasteRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wastebin_v1_paste_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeletePasteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePasteRequest) ProtoMessage() {}
+
+func (x *DeletePasteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wastebin_v1_paste_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePasteRequest.ProtoReflect.Descriptor instead.
+func (*DeletePasteRequest) Descriptor() ([]byte, []int) {
+	return file_wastebin_v1_paste_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DeletePasteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeletePasteResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *DeletePasteResponse) Reset() {
+	*x = DeletePasteResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wastebin_v1_paste_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeletePasteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePasteResponse) ProtoMessage() {}
+
+func (x *DeletePasteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wastebin_v1_paste_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePasteResponse.ProtoReflect.Descriptor instead.
+func (*DeletePasteResponse) Descriptor() ([]byte, []int) {
+	return file_wastebin_v1_paste_proto_rawDescGZIP(), []int{6}
+}
+
+type ListPastesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Tag string `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	// Number of pastes to list, 50 when unset and at most 100
+	Limit int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+}
+
+func (x *ListPastesRequest) Reset() {
+	*x = ListPastesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wastebin_v1_paste_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListPastesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPastesRequest) ProtoMessage() {}
+
+func (x *ListPastesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wastebin_v1_paste_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPastesRequest.ProtoReflect.Descriptor instead.
+func (*ListPastesRequest) Descriptor() ([]byte, []int) {
+	return file_wastebin_v1_paste_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListPastesRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *ListPastesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListPastesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pastes []*Paste `protobuf:"bytes,1,rep,name=pastes,proto3" json:"pastes,omitempty"`
+}
+
+func (x *ListPastesResponse) Reset() {
+	*x = ListPastesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wastebin_v1_paste_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListPastesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPastesResponse) ProtoMessage() {}
+
+func (x *ListPastesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wastebin_v1_paste_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPastesResponse.ProtoReflect.Descriptor instead.
+func (*ListPastesResponse) Descriptor() ([]byte, []int) {
+	return file_wastebin_v1_paste_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListPastesResponse) GetPastes() []*Paste {
+	if x != nil {
+		return x.Pastes
+	}
+	return nil
+}
+
+var File_wastebin_v1_paste_proto protoreflect.FileDescriptor
+
+var file_wastebin_v1_paste_proto_rawDesc = []byte{
+	0x0a, 0x17, 0x77, 0x61, 0x73, 0x74, 0x65, 0x62, 0x69, 0x6e, 0x2f, 0x76, 0x31, 0x2f, 0x70, 0x61,
+	0x73, 0x74, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x77, 0x61, 0x73, 0x74, 0x65,
+	0x62, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xc3, 0x03, 0x0a, 0x05, 0x50, 0x61, 0x73, 0x74,
+	0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69,
+	0x64, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x6c,
+	0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6c,
+	0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x12, 0x20, 0x0a,
+	0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x12, 0x0a, 0x04, 0x62, 0x75, 0x72, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x62,
+	0x75, 0x72, 0x6e, 0x12, 0x37, 0x0a, 0x0a, 0x76, 0x69, 0x73, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74,
+	0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e, 0x77, 0x61, 0x73, 0x74, 0x65, 0x62,
+	0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x69, 0x73, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79,
+	0x52, 0x0a, 0x76, 0x69, 0x73, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x12, 0x12, 0x0a, 0x04,
+	0x74, 0x61, 0x67, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x74, 0x61, 0x67, 0x73,
+	0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04,
+	0x73, 0x69, 0x7a, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d,
+	0x12, 0x14, 0x0a, 0x05, 0x76, 0x69, 0x65, 0x77, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x05, 0x76, 0x69, 0x65, 0x77, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x66, 0x6f, 0x72, 0x6b, 0x65, 0x64,
+	0x5f, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x66, 0x6f, 0x72,
+	0x6b, 0x65, 0x64, 0x46, 0x72, 0x6f, 0x6d, 0x12, 0x39, 0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64,
+	0x41, 0x74, 0x12, 0x39, 0x0a, 0x0a, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x5f, 0x61, 0x74,
+	0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x09, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x41, 0x74, 0x22, 0x9c, 0x02,
+	0x0a, 0x12, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x50, 0x61, 0x73, 0x74, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x1a,
+	0x0a, 0x08, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x69,
+	0x74, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65,
+	0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x62, 0x75, 0x72, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x04, 0x62, 0x75, 0x72, 0x6e, 0x12, 0x27, 0x0a, 0x0f, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65,
+	0x73, 0x5f, 0x6d, 0x69, 0x6e, 0x75, 0x74, 0x65, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0e, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x4d, 0x69, 0x6e, 0x75, 0x74, 0x65, 0x73, 0x12,
+	0x37, 0x0a, 0x0a, 0x76, 0x69, 0x73, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e, 0x77, 0x61, 0x73, 0x74, 0x65, 0x62, 0x69, 0x6e, 0x2e, 0x76,
+	0x31, 0x2e, 0x56, 0x69, 0x73, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x52, 0x0a, 0x76, 0x69,
+	0x73, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x61, 0x67, 0x73,
+	0x18, 0x08, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x74, 0x61, 0x67, 0x73, 0x12, 0x0e, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x22, 0x60, 0x0a, 0x13,
+	0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x50, 0x61, 0x73, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x28, 0x0a, 0x05, 0x70, 0x61, 0x73, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x77, 0x61, 0x73, 0x74, 0x65, 0x62, 0x69, 0x6e, 0x2e, 0x76, 0x31,
+	0x2e, 0x50, 0x61, 0x73, 0x74, 0x65, 0x52, 0x05, 0x70, 0x61, 0x73, 0x74, 0x65, 0x12, 0x1f, 0x0a,
+	0x0b, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0a, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x21,
+	0x0a, 0x0f, 0x47, 0x65, 0x74, 0x50, 0x61, 0x73, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69,
+	0x64, 0x22, 0x3c, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x50, 0x61, 0x73, 0x74, 0x65, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x28, 0x0a, 0x05, 0x70, 0x61, 0x73, 0x74, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x77, 0x61, 0x73, 0x74, 0x65, 0x62, 0x69, 0x6e, 0x2e,
+	0x76, 0x31, 0x2e, 0x50, 0x61, 0x73, 0x74, 0x65, 0x52, 0x05, 0x70, 0x61, 0x73, 0x74, 0x65, 0x22,
+	0x24, 0x0a, 0x12, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x50, 0x61, 0x73, 0x74, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x02, 0x69, 0x64, 0x22, 0x15, 0x0a, 0x13, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x50,
+	0x61, 0x73, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x3b, 0x0a, 0x11,
+	0x4c, 0x69, 0x73, 0x74, 0x50, 0x61, 0x73, 0x74, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x61, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x74, 0x61, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x22, 0x40, 0x0a, 0x12, 0x4c, 0x69, 0x73,
+	0x74, 0x50, 0x61, 0x73, 0x74, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x2a, 0x0a, 0x06, 0x70, 0x61, 0x73, 0x74, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x12, 0x2e, 0x77, 0x61, 0x73, 0x74, 0x65, 0x62, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61,
+	0x73, 0x74, 0x65, 0x52, 0x06, 0x70, 0x61, 0x73, 0x74, 0x65, 0x73, 0x2a, 0x70, 0x0a, 0x0a, 0x56,
+	0x69, 0x73, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x12, 0x1a, 0x0a, 0x16, 0x56, 0x49, 0x53,
+	0x49, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46,
+	0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x15, 0x0a, 0x11, 0x56, 0x49, 0x53, 0x49, 0x42, 0x49, 0x4c,
+	0x49, 0x54, 0x59, 0x5f, 0x50, 0x55, 0x42, 0x4c, 0x49, 0x43, 0x10, 0x01, 0x12, 0x17, 0x0a, 0x13,
+	0x56, 0x49, 0x53, 0x49, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x5f, 0x55, 0x4e, 0x4c, 0x49, 0x53,
+	0x54, 0x45, 0x44, 0x10, 0x02, 0x12, 0x16, 0x0a, 0x12, 0x56, 0x49, 0x53, 0x49, 0x42, 0x49, 0x4c,
+	0x49, 0x54, 0x59, 0x5f, 0x50, 0x52, 0x49, 0x56, 0x41, 0x54, 0x45, 0x10, 0x03, 0x32, 0xca, 0x02,
+	0x0a, 0x0c, 0x50, 0x61, 0x73, 0x74, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x50,
+	0x0a, 0x0b, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x50, 0x61, 0x73, 0x74, 0x65, 0x12, 0x1f, 0x2e,
+	0x77, 0x61, 0x73, 0x74, 0x65, 0x62, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72, 0x65, 0x61,
+	0x74, 0x65, 0x50, 0x61, 0x73, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20,
+	0x2e, 0x77, 0x61, 0x73, 0x74, 0x65, 0x62, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x50, 0x61, 0x73, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x47, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x50, 0x61, 0x73, 0x74, 0x65, 0x12, 0x1c, 0x2e, 0x77,
+	0x61, 0x73, 0x74, 0x65, 0x62, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x50, 0x61,
+	0x73, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x77, 0x61, 0x73,
+	0x74, 0x65, 0x62, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x50, 0x61, 0x73, 0x74,
+	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x50, 0x0a, 0x0b, 0x44, 0x65, 0x6c,
+	0x65, 0x74, 0x65, 0x50, 0x61, 0x73, 0x74, 0x65, 0x12, 0x1f, 0x2e, 0x77, 0x61, 0x73, 0x74, 0x65,
+	0x62, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x50, 0x61, 0x73,
+	0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x77, 0x61, 0x73, 0x74,
+	0x65, 0x62, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x50, 0x61,
+	0x73, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4d, 0x0a, 0x0a, 0x4c,
+	0x69, 0x73, 0x74, 0x50, 0x61, 0x73, 0x74, 0x65, 0x73, 0x12, 0x1e, 0x2e, 0x77, 0x61, 0x73, 0x74,
+	0x65, 0x62, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x61, 0x73, 0x74,
+	0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x77, 0x61, 0x73, 0x74,
+	0x65, 0x62, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x61, 0x73, 0x74,
+	0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x3e, 0x5a, 0x3c, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x63, 0x6f, 0x6f, 0x6c, 0x67, 0x75, 0x79,
+	0x31, 0x37, 0x37, 0x31, 0x2f, 0x77, 0x61, 0x73, 0x74, 0x65, 0x62, 0x69, 0x6e, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2f, 0x77, 0x61, 0x73, 0x74, 0x65, 0x62, 0x69, 0x6e, 0x2f, 0x76, 0x31, 0x3b,
+	0x77, 0x61, 0x73, 0x74, 0x65, 0x62, 0x69, 0x6e, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+}
+
+var (
+	file_wastebin_v1_paste_proto_rawDescOnce sync.Once
+	file_wastebin_v1_paste_proto_rawDescData = file_wastebin_v1_paste_proto_rawDesc
+)
+
+func file_wastebin_v1_paste_proto_rawDescGZIP() []byte {
+	file_wastebin_v1_paste_proto_rawDescOnce.Do(func() {
+		file_wastebin_v1_paste_proto_rawDescData = protoimpl.X.CompressGZIP(file_wastebin_v1_paste_proto_rawDescData)
+	})
+	return file_wastebin_v1_paste_proto_rawDescData
+}
+
+var file_wastebin_v1_paste_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_wastebin_v1_paste_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_wastebin_v1_paste_proto_goTypes = []interface{}{
+	(Visibility)(0),               // 0: wastebin.v1.Visibility
+	(*Paste)(nil),                 // 1: wastebin.v1.Paste
+	(*CreatePasteRequest)(nil),    // 2: wastebin.v1.CreatePasteRequest
+	(*CreatePasteResponse)(nil),   // 3: wastebin.v1.CreatePasteResponse
+	(*GetPasteRequest)(nil),       // 4: wastebin.v1.GetPasteRequest
+	(*GetPasteResponse)(nil),      // 5: wastebin.v1.GetPasteResponse
+	(*DeletePasteRequest)(nil),    // 6: wastebin.v1.DeletePasteRequest
+	(*DeletePasteResponse)(nil),   // 7: wastebin.v1.DeletePasteResponse
+	(*ListPastesRequest)(nil),     // 8: wastebin.v1.ListPastesRequest
+	(*ListPastesResponse)(nil),    // 9: wastebin.v1.ListPastesResponse
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+}
+var file_wastebin_v1_paste_proto_depIdxs = []int32{
+	0,  // 0: wastebin.v1.Paste.visibility:type_name -> wastebin.v1.Visibility
+	10, // 1: wastebin.v1.Paste.created_at:type_name -> google.protobuf.Timestamp
+	10, // 2: wastebin.v1.Paste.expires_at:type_name -> google.protobuf.Timestamp
+	0,  // 3: wastebin.v1.CreatePasteRequest.visibility:type_name -> wastebin.v1.Visibility
+	1,  // 4: wastebin.v1.CreatePasteResponse.paste:type_name -> wastebin.v1.Paste
+	1,  // 5: wastebin.v1.GetPasteResponse.paste:type_name -> wastebin.v1.Paste
+	1,  // 6: wastebin.v1.ListPastesResponse.pastes:type_name -> wastebin.v1.Paste
+	2,  // 7: wastebin.v1.PasteService.CreatePaste:input_type -> wastebin.v1.CreatePasteRequest
+	4,  // 8: wastebin.v1.PasteService.GetPaste:input_type -> wastebin.v1.GetPasteRequest
+	6,  // 9: wastebin.v1.PasteService.DeletePaste:input_type -> wastebin.v1.DeletePasteRequest
+	8,  // 10: wastebin.v1.PasteService.ListPastes:input_type -> wastebin.v1.ListPastesRequest
+	3,  // 11: wastebin.v1.PasteService.CreatePaste:output_type -> wastebin.v1.CreatePasteResponse
+	5,  // 12: wastebin.v1.PasteService.GetPaste:output_type -> wastebin.v1.GetPasteResponse
+	7,  // 13: wastebin.v1.PasteService.DeletePaste:output_type -> wastebin.v1.DeletePasteResponse
+	9,  // 14: wastebin.v1.PasteService.ListPastes:output_type -> wastebin.v1.ListPastesResponse
+	11, // [11:15] is the sub-list for method output_type
+	7,  // [7:11] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_wastebin_v1_paste_proto_init() }
+func file_wastebin_v1_paste_proto_init() {
+	if File_wastebin_v1_paste_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_wastebin_v1_paste_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Paste); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wastebin_v1_paste_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CreatePasteRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wastebin_v1_paste_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CreatePasteResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wastebin_v1_paste_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetPasteRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wastebin_v1_paste_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetPasteResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wastebin_v1_paste_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeletePasteRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wastebin_v1_paste_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeletePasteResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wastebin_v1_paste_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListPastesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wastebin_v1_paste_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListPastesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_wastebin_v1_paste_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_wastebin_v1_paste_proto_goTypes,
+		DependencyIndexes: file_wastebin_v1_paste_proto_depIdxs,
+		EnumInfos:         file_wastebin_v1_paste_proto_enumTypes,
+		MessageInfos:      file_wastebin_v1_paste_proto_msgTypes,
+	}.Build()
+	File_wastebin_v1_paste_proto = out.File
+	file_wastebin_v1_paste_proto_rawDesc = nil
+	file_wastebin_v1_paste_proto_goTypes = nil
+	file_wastebin_v1_paste_proto_depIdxs = nil
+}
@@ -0,0 +1,228 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: wastebin/v1/paste.proto
+
+package wastebinv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	PasteService_CreatePaste_FullMethodName = "/wastebin.v1.PasteService/CreatePaste"
+	PasteService_GetPaste_FullMethodName    = "/wastebin.v1.PasteService/GetPaste"
+	PasteService_DeletePaste_FullMethodName = "/wastebin.v1.PasteService/DeletePaste"
+	PasteService_ListPastes_FullMethodName  = "/wastebin.v1.PasteService/ListPastes"
+)
+
+// PasteServiceClient is the client API for PasteService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PasteServiceClient interface {
+	// CreatePaste stores a new paste
+	CreatePaste(ctx context.Context, in *CreatePasteRequest, opts ...grpc.CallOption) (*CreatePasteResponse, error)
+	// GetPaste returns a paste. Reading a burn after reading paste deletes it.
+	GetPaste(ctx context.Context, in *GetPasteRequest, opts ...grpc.CallOption) (*GetPasteResponse, error)
+	// DeletePaste deletes a paste
+	DeletePaste(ctx context.Context, in *DeletePasteRequest, opts ...grpc.CallOption) (*DeletePasteResponse, error)
+	// ListPastes lists the public pastes with a tag, the newest first
+	ListPastes(ctx context.Context, in *ListPastesRequest, opts ...grpc.CallOption) (*ListPastesResponse, error)
+}
+
+type pasteServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPasteServiceClient(cc grpc.ClientConnInterface) PasteServiceClient {
+	return &pasteServiceClient{cc}
+}
+
+func (c *pasteServiceClient) CreatePaste(ctx context.Context, in *CreatePasteRequest, opts ...grpc.CallOption) (*CreatePasteResponse, error) {
+	out := new(CreatePasteResponse)
+	err := c.cc.Invoke(ctx, PasteService_CreatePaste_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pasteServiceClient) GetPaste(ctx context.Context, in *GetPasteRequest, opts ...grpc.CallOption) (*GetPasteResponse, error) {
+	out := new(GetPasteResponse)
+	err := c.cc.Invoke(ctx, PasteService_GetPaste_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pasteServiceClient) DeletePaste(ctx context.Context, in *DeletePasteRequest, opts ...grpc.CallOption) (*DeletePasteResponse, error) {
+	out := new(DeletePasteResponse)
+	err := c.cc.Invoke(ctx, PasteService_DeletePaste_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pasteServiceClient) ListPastes(ctx context.Context, in *ListPastesRequest, opts ...grpc.CallOption) (*ListPastesResponse, error) {
+	out := new(ListPastesResponse)
+	err := c.cc.Invoke(ctx, PasteService_ListPastes_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PasteServiceServer is the server API for PasteService service.
+// All implementations must embed UnimplementedPasteServiceServer
+// for forward compatibility
+type PasteServiceServer interface {
+	// CreatePaste stores a new paste
+	CreatePaste(context.Context, *CreatePasteRequest) (*CreatePasteResponse, error)
+	// GetPaste returns a paste. Reading a burn after reading paste deletes it.
+	GetPaste(context.Context, *GetPasteRequest) (*GetPasteResponse, error)
+	// DeletePaste deletes a paste
+	DeletePaste(context.Context, *DeletePasteRequest) (*DeletePasteResponse, error)
+	// ListPastes lists the public pastes with a tag, the newest first
+	ListPastes(context.Context, *ListPastesRequest) (*ListPastesResponse, error)
+	mustEmbedUnimplementedPasteServiceServer()
+}
+
+// UnimplementedPasteServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedPasteServiceServer struct {
+}
+
+func (UnimplementedPasteServiceServer) CreatePaste(context.Context, *CreatePasteRequest) (*CreatePasteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePaste not implemented")
+}
+func (UnimplementedPasteServiceServer) GetPaste(context.Context, *GetPasteRequest) (*GetPasteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPaste not implemented")
+}
+func (UnimplementedPasteServiceServer) DeletePaste(context.Context, *DeletePasteRequest) (*DeletePasteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePaste not implemented")
+}
+func (UnimplementedPasteServiceServer) ListPastes(context.Context, *ListPastesRequest) (*ListPastesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPastes not implemented")
+}
+func (UnimplementedPasteServiceServer) mustEmbedUnimplementedPasteServiceServer() {}
+
+// UnsafePasteServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PasteServiceServer will
+// result in compilation errors.
+type UnsafePasteServiceServer interface {
+	mustEmbedUnimplementedPasteServiceServer()
+}
+
+func RegisterPasteServiceServer(s grpc.ServiceRegistrar, srv PasteServiceServer) {
+	s.RegisterService(&PasteService_ServiceDesc, srv)
+}
+
+func _PasteService_CreatePaste_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePasteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PasteServiceServer).CreatePaste(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PasteService_CreatePaste_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PasteServiceServer).CreatePaste(ctx, req.(*CreatePasteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PasteService_GetPaste_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPasteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PasteServiceServer).GetPaste(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PasteService_GetPaste_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PasteServiceServer).GetPaste(ctx, req.(*GetPasteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PasteService_DeletePaste_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePasteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PasteServiceServer).DeletePaste(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PasteService_DeletePaste_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PasteServiceServer).DeletePaste(ctx, req.(*DeletePasteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PasteService_ListPastes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPastesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PasteServiceServer).ListPastes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PasteService_ListPastes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PasteServiceServer).ListPastes(ctx, req.(*ListPastesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PasteService_ServiceDesc is the grpc.ServiceDesc for PasteService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PasteService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "wastebin.v1.PasteService",
+	HandlerType: (*PasteServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreatePaste",
+			Handler:    _PasteService_CreatePaste_Handler,
+		},
+		{
+			MethodName: "GetPaste",
+			Handler:    _PasteService_GetPaste_Handler,
+		},
+		{
+			MethodName: "DeletePaste",
+			Handler:    _PasteService_DeletePaste_Handler,
+		},
+		{
+			MethodName: "ListPastes",
+			Handler:    _PasteService_ListPastes_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "wastebin/v1/paste.proto",
+}
@@ -0,0 +1,185 @@
+// Package quota limits the number of pastes and bytes a client may create
+// per hour and per day. Usage is persisted in the database so quotas survive
+// restarts and are shared between replicas.
+package quota
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Windows quotas are counted over
+const (
+	WindowHour = "hour"
+	WindowDay  = "day"
+)
+
+// cleanupInterval is how often the usage of windows that ended is deleted
+const cleanupInterval = time.Hour
+
+// Limits are the pastes and bytes allowed per window, zero means unlimited
+type Limits struct {
+	Pastes int64 `json:"pastes"`
+	Bytes  int64 `json:"bytes"`
+}
+
+func (l Limits) enabled() bool {
+	return l.Pastes > 0 || l.Bytes > 0
+}
+
+// ExceededError is returned by Reserve when creating a paste would exceed a quota
+type ExceededError struct {
+	Window string    `json:"window"`
+	Limit  Limits    `json:"limit"`
+	Used   Limits    `json:"used"`
+	Reset  time.Time `json:"reset"`
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s quota exceeded", e.Window)
+}
+
+// Quota reserves the usage of clients
+type Quota struct {
+	db      *gorm.DB
+	logger  *log.Logger
+	windows atomic.Pointer[[]window]
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+type window struct {
+	name   string
+	length time.Duration
+	limits Limits
+}
+
+// New creates a Quota enforcing the hourly and daily limits
+func New(db *gorm.DB, logger *log.Logger, hourly, daily Limits) *Quota {
+	q := &Quota{db: db, logger: logger}
+	q.SetLimits(hourly, daily)
+	return q
+}
+
+// SetLimits replaces the hourly and daily limits, the usage already recorded
+// counts towards the new ones
+func (q *Quota) SetLimits(hourly, daily Limits) {
+	var windows []window
+	if hourly.enabled() {
+		windows = append(windows, window{name: WindowHour, length: time.Hour, limits: hourly})
+	}
+	if daily.enabled() {
+		windows = append(windows, window{name: WindowDay, length: 24 * time.Hour, limits: daily})
+	}
+	q.windows.Store(&windows)
+}
+
+// Reservation is the usage of a paste reserved by Reserve
+type Reservation struct {
+	key     string
+	size    int64
+	windows []window
+	at      time.Time
+}
+
+// Reserve adds a paste of size bytes to the usage of key, or returns an
+// *ExceededError when it would exceed one of the quotas. The usage is checked
+// and added by a single conditional update per window in one transaction, so
+// that concurrent pastes cannot exceed the quotas together. The reservation
+// must be released when the paste is not created.
+func (q *Quota) Reserve(key string, size int64, now time.Time) (*Reservation, error) {
+	windows := *q.windows.Load()
+	if len(windows) == 0 {
+		return &Reservation{}, nil
+	}
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+		for _, w := range windows {
+			start := now.UTC().Truncate(w.length)
+			// The usage must exist for the update to add to it
+			err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.QuotaUsage{
+				Client:      key,
+				Period:      w.name,
+				PeriodStart: start,
+			}).Error
+			if err != nil {
+				return err
+			}
+
+			update := tx.Model(&models.QuotaUsage{}).Where("client = ? AND period = ? AND period_start = ?", key, w.name, start)
+			if w.limits.Pastes > 0 {
+				update = update.Where("pastes + 1 <= ?", w.limits.Pastes)
+			}
+			if w.limits.Bytes > 0 {
+				update = update.Where("bytes + ? <= ?", size, w.limits.Bytes)
+			}
+			result := update.Updates(map[string]interface{}{
+				"pastes": gorm.Expr("pastes + ?", 1),
+				"bytes":  gorm.Expr("bytes + ?", size),
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 1 {
+				continue
+			}
+
+			var usage models.QuotaUsage
+			if err := tx.Where("client = ? AND period = ? AND period_start = ?", key, w.name, start).First(&usage).Error; err != nil {
+				return err
+			}
+			return &ExceededError{
+				Window: w.name,
+				Limit:  w.limits,
+				Used:   Limits{Pastes: usage.Pastes, Bytes: usage.Bytes},
+				Reset:  start.Add(w.length),
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The usage is reserved, failing to delete old usage doesn't change that
+	if err := q.cleanup(now); err != nil {
+		q.logger.Error("Error deleting expired quota usage", zap.Error(err))
+	}
+	return &Reservation{key: key, size: size, windows: windows, at: now}, nil
+}
+
+// Release gives back the usage of a paste that was not created
+func (q *Quota) Release(r *Reservation) error {
+	for _, w := range r.windows {
+		err := q.db.Model(&models.QuotaUsage{}).
+			Where("client = ? AND period = ? AND period_start = ?", r.key, w.name, r.at.UTC().Truncate(w.length)).
+			Updates(map[string]interface{}{
+				"pastes": gorm.Expr("pastes - ?", 1),
+				"bytes":  gorm.Expr("bytes - ?", r.size),
+			}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanup deletes the usage of windows that ended, at most once per cleanupInterval
+func (q *Quota) cleanup(now time.Time) error {
+	q.mu.Lock()
+	if now.Sub(q.lastCleanup) < cleanupInterval {
+		q.mu.Unlock()
+		return nil
+	}
+	q.lastCleanup = now
+	q.mu.Unlock()
+
+	return q.db.Where("period_start < ?", now.UTC().Add(-24*time.Hour).Truncate(24*time.Hour)).Delete(&models.QuotaUsage{}).Error
+}
@@ -0,0 +1,98 @@
+package quota_test
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/quota"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestQuota(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "quota.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.QuotaUsage{}); err != nil {
+		t.Fatal(err)
+	}
+
+	q := quota.New(db, log.Default(), quota.Limits{Pastes: 2}, quota.Limits{Bytes: 100})
+	now := time.Date(2023, 1, 1, 10, 30, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if _, err := q.Reserve("ip:192.0.2.1", 10, now); err != nil {
+			t.Fatalf("paste %d: %v", i, err)
+		}
+	}
+
+	var exceeded *quota.ExceededError
+	if _, err := q.Reserve("ip:192.0.2.1", 10, now); !errors.As(err, &exceeded) {
+		t.Fatalf("expected the hourly quota to be exceeded, got %v", err)
+	}
+	if exceeded.Window != quota.WindowHour || exceeded.Used.Pastes != 2 || !exceeded.Reset.Equal(now.Truncate(time.Hour).Add(time.Hour)) {
+		t.Errorf("unexpected quota details %+v", exceeded)
+	}
+
+	if _, err := q.Reserve("ip:192.0.2.2", 10, now); err != nil {
+		t.Errorf("expected other clients to have their own quota, got %v", err)
+	}
+
+	// The next hour only the daily byte quota is left, a paste exceeding it
+	// reserves nothing
+	now = now.Add(time.Hour)
+	if _, err := q.Reserve("ip:192.0.2.1", 81, now); !errors.As(err, &exceeded) || exceeded.Window != quota.WindowDay || exceeded.Used.Bytes != 20 {
+		t.Fatalf("expected the daily quota to be exceeded, got %v", err)
+	}
+	reservation, err := q.Reserve("ip:192.0.2.1", 80, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Reserve("ip:192.0.2.1", 1, now); !errors.As(err, &exceeded) {
+		t.Fatalf("expected the daily quota to be used up, got %v", err)
+	}
+
+	// Released usage can be reserved again
+	if err := q.Release(reservation); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Reserve("ip:192.0.2.1", 80, now); err != nil {
+		t.Fatalf("expected the released usage to be available, got %v", err)
+	}
+}
+
+func TestReserveConcurrently(t *testing.T) {
+	// Concurrent transactions wait for each other on a database file
+	db, err := gorm.Open(sqlite.Open("file:"+filepath.Join(t.TempDir(), "quota.db")+"?_busy_timeout=5000&_txlock=immediate"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.QuotaUsage{}); err != nil {
+		t.Fatal(err)
+	}
+
+	q := quota.New(db, log.Default(), quota.Limits{Pastes: 5}, quota.Limits{})
+	now := time.Date(2023, 1, 1, 10, 30, 0, 0, time.UTC)
+	var wg sync.WaitGroup
+	var reserved atomic.Int64
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := q.Reserve("ip:192.0.2.1", 10, now); err == nil {
+				reserved.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if reserved.Load() != 5 {
+		t.Errorf("expected 5 pastes reserved, got %d", reserved.Load())
+	}
+}
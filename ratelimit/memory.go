@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the rate limit state in process memory
+type MemoryStore struct {
+	mu        sync.Mutex
+	tats      map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tats: make(map[string]time.Time),
+	}
+}
+
+// Take records a request for key
+func (s *MemoryStore) Take(key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	tat, result := gcra(s.tats[key], limit, now)
+	s.tats[key] = tat
+	return result, nil
+}
+
+// sweep forgets the clients whose full burst is available again
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, tat := range s.tats {
+		if tat.Before(now) {
+			delete(s.tats, key)
+		}
+	}
+}
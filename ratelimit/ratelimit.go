@@ -0,0 +1,198 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/coolguy1771/wastebin/clientip"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Limit is the rate at which a client may make requests
+type Limit struct {
+	// PerMinute is the sustained number of requests allowed per minute, 0 disables the limit
+	PerMinute int
+	// Burst is the number of requests that may be made at once
+	Burst int
+}
+
+// Result describes the outcome of taking a request from a client's allowance
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	Reset      time.Duration // until the full burst is available again
+	RetryAfter time.Duration // until the next request is allowed, only set when denied
+}
+
+// Store keeps the rate limit state of every client
+type Store interface {
+	Take(key string, limit Limit, now time.Time) (Result, error)
+}
+
+// Rule overrides the default limit for the requests matching a method and path prefix
+type Rule struct {
+	Method string
+	Prefix string
+	Limit  Limit
+}
+
+// ParseRules parses per route overrides in the form
+// "POST /api/v1/paste=20:5,/paste=300:50" where the method is optional and
+// the numbers are the requests per minute and the burst
+func ParseRules(s string) ([]Rule, error) {
+	var rules []Rule
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, limit, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit rule %q: missing limit", entry)
+		}
+		rule := Rule{Prefix: strings.TrimSpace(route)}
+		if method, prefix, ok := strings.Cut(rule.Prefix, " "); ok {
+			rule.Method = strings.ToUpper(method)
+			rule.Prefix = strings.TrimSpace(prefix)
+		}
+		if !strings.HasPrefix(rule.Prefix, "/") {
+			return nil, fmt.Errorf("invalid rate limit rule %q: path must start with /", entry)
+		}
+
+		perMinute, burst, _ := strings.Cut(limit, ":")
+		var err error
+		if rule.Limit.PerMinute, err = strconv.Atoi(strings.TrimSpace(perMinute)); err != nil {
+			return nil, fmt.Errorf("invalid rate limit rule %q: %w", entry, err)
+		}
+		rule.Limit.Burst = rule.Limit.PerMinute
+		if burst != "" {
+			if rule.Limit.Burst, err = strconv.Atoi(strings.TrimSpace(burst)); err != nil {
+				return nil, fmt.Errorf("invalid rate limit rule %q: %w", entry, err)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Limiter is a fiber middleware limiting the request rate of every client IP
+type Limiter struct {
+	store  Store
+	limits atomic.Pointer[limits]
+	logger *log.Logger
+
+	onLimited func(c *fiber.Ctx)
+}
+
+// limits are the default limit and the rules overriding it
+type limits struct {
+	limit Limit
+	rules []Rule
+}
+
+// New creates a Limiter applying limit to every request not matched by one of the rules
+func New(store Store, limit Limit, rules []Rule, logger *log.Logger) *Limiter {
+	l := &Limiter{
+		store:  store,
+		logger: logger,
+	}
+	l.SetLimits(limit, rules)
+	return l
+}
+
+// SetLimits replaces the default limit and the rules, the allowance clients
+// already used counts towards the new limits
+func (l *Limiter) SetLimits(limit Limit, rules []Rule) {
+	l.limits.Store(&limits{limit: limit, rules: rules})
+}
+
+// OnLimited sets a function called with every rejected request
+func (l *Limiter) OnLimited(fn func(c *fiber.Ctx)) {
+	l.onLimited = fn
+}
+
+// match returns the limit for the request and the key its allowance is tracked under
+func (l *Limiter) match(method, path string) (Limit, string) {
+	limits := l.limits.Load()
+	limit, key, longest := limits.limit, "", -1
+	for _, rule := range limits.rules {
+		if rule.Method != "" && rule.Method != method {
+			continue
+		}
+		if strings.HasPrefix(path, rule.Prefix) && len(rule.Prefix) > longest {
+			limit, key, longest = rule.Limit, rule.Method+" "+rule.Prefix, len(rule.Prefix)
+		}
+	}
+	return limit, key
+}
+
+// Handler rejects the request with 429 when the client exceeded its limit
+func (l *Limiter) Handler(c *fiber.Ctx) error {
+	limit, rule := l.match(c.Method(), c.Path())
+	if limit.PerMinute <= 0 {
+		return c.Next()
+	}
+
+	result, err := l.store.Take(clientip.Get(c)+"|"+rule, limit, time.Now())
+	if err != nil {
+		// Let the request through rather than failing every request while the store is unavailable
+		l.logger.Error("Error checking the rate limit", zap.Error(err))
+		return c.Next()
+	}
+
+	c.Set("X-RateLimit-Limit", strconv.Itoa(limit.PerMinute))
+	c.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Set("X-RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+
+	if !result.Allowed {
+		if l.onLimited != nil {
+			l.onLimited(c)
+		}
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds(result.RetryAfter)))
+		return fiber.NewError(fiber.StatusTooManyRequests, "Rate limit exceeded")
+	}
+	return c.Next()
+}
+
+// seconds rounds d up to whole seconds
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// gcra applies the generic cell rate algorithm to the theoretical arrival
+// time tat of the next request and returns the updated tat
+func gcra(tat time.Time, limit Limit, now time.Time) (time.Time, Result) {
+	interval := time.Minute / time.Duration(limit.PerMinute)
+	burst := limit.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	tolerance := interval * time.Duration(burst)
+
+	if tat.Before(now) {
+		tat = now
+	}
+	next := tat.Add(interval)
+
+	if allowAt := next.Add(-tolerance); now.Before(allowAt) {
+		return tat, Result{
+			Allowed:    false,
+			Remaining:  0,
+			Reset:      tat.Sub(now),
+			RetryAfter: allowAt.Sub(now),
+		}
+	}
+
+	return next, Result{
+		Allowed:   true,
+		Remaining: int((tolerance - next.Sub(now)) / interval),
+		Reset:     next.Sub(now),
+	}
+}
@@ -0,0 +1,105 @@
+package ratelimit_test
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/ratelimit"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ratelimit.ParseRules("post /api/v1/paste=20:5, /paste=300")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ratelimit.Rule{
+		{Method: "POST", Prefix: "/api/v1/paste", Limit: ratelimit.Limit{PerMinute: 20, Burst: 5}},
+		{Prefix: "/paste", Limit: ratelimit.Limit{PerMinute: 300, Burst: 300}},
+	}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Errorf("unexpected rules %+v", rules)
+	}
+
+	for _, invalid := range []string{"/paste", "paste=10", "/paste=ten", "/paste=10:five"} {
+		if _, err := ratelimit.ParseRules(invalid); err == nil {
+			t.Errorf("expected an error parsing %q", invalid)
+		}
+	}
+}
+
+func TestMemoryStoreBurst(t *testing.T) {
+	testStoreBurst(t, ratelimit.NewMemoryStore())
+}
+
+func TestRedisStoreBurst(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	testStoreBurst(t, ratelimit.NewRedisStore(client))
+}
+
+func testStoreBurst(t *testing.T, store ratelimit.Store) {
+	limit := ratelimit.Limit{PerMinute: 60, Burst: 3}
+	now := time.Now()
+
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take("client", limit, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed || result.Remaining != i {
+			t.Fatalf("expected request to be allowed with %d remaining, got %+v", i, result)
+		}
+	}
+
+	result, _ := store.Take("client", limit, now)
+	if result.Allowed || result.RetryAfter != time.Second {
+		t.Fatalf("expected request to be denied for a second, got %+v", result)
+	}
+
+	result, _ = store.Take("client", limit, now.Add(time.Second))
+	if !result.Allowed {
+		t.Fatalf("expected request to be allowed after a second, got %+v", result)
+	}
+}
+
+func TestHandlerHeaders(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.Limit{PerMinute: 100, Burst: 100}, []ratelimit.Rule{
+		{Method: "POST", Prefix: "/paste", Limit: ratelimit.Limit{PerMinute: 1, Burst: 1}},
+	}, log.Default())
+	app := fiber.New()
+	app.Use(limiter.Handler)
+	app.All("/paste", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/paste", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get("X-RateLimit-Limit") != "1" || resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("unexpected first response %d %v", resp.StatusCode, resp.Header)
+	}
+
+	resp, err = app.Test(httptest.NewRequest("POST", "/paste", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusTooManyRequests || resp.Header.Get("Retry-After") != "60" {
+		t.Fatalf("unexpected second response %d %v", resp.StatusCode, resp.Header)
+	}
+
+	// Other methods use the default limit
+	resp, err = app.Test(httptest.NewRequest("GET", "/paste", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get("X-RateLimit-Limit") != "100" {
+		t.Fatalf("unexpected response for the default limit %d %v", resp.StatusCode, resp.Header)
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix namespaces the rate limit keys in a shared Redis database
+const redisKeyPrefix = "wastebin:ratelimit:"
+
+// gcraScript applies the same algorithm as gcra atomically in Redis. The
+// theoretical arrival time is stored in milliseconds and expires once the
+// client's full burst is available again.
+var gcraScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local tolerance = tonumber(ARGV[3])
+
+local tat = tonumber(redis.call("GET", KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+local next = tat + interval
+
+local allow_at = next - tolerance
+if now < allow_at then
+	return {0, tat - now, allow_at - now}
+end
+
+redis.call("SET", KEYS[1], next, "PX", next - now)
+return {1, next - now, 0}
+`)
+
+// RedisStore keeps the rate limit state in Redis so that it is shared by
+// every instance using the same Redis server
+type RedisStore struct {
+	client redis.UniversalClient
+}
+
+// NewRedisStore creates a RedisStore using client
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{
+		client: client,
+	}
+}
+
+// Take records a request for key
+func (s *RedisStore) Take(key string, limit Limit, now time.Time) (Result, error) {
+	interval := time.Minute / time.Duration(limit.PerMinute)
+	burst := limit.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	tolerance := interval * time.Duration(burst)
+
+	values, err := gcraScript.Run(context.Background(), s.client, []string{redisKeyPrefix + key},
+		now.UnixMilli(), interval.Milliseconds(), tolerance.Milliseconds()).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		Allowed:    values[0] == 1,
+		Reset:      time.Duration(values[1]) * time.Millisecond,
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+	}
+	if result.Allowed {
+		result.Remaining = int((tolerance - result.Reset) / interval)
+	}
+	return result, nil
+}
@@ -1,46 +1,112 @@
 package routes
 
 import (
+	"net/http"
+
+	"github.com/coolguy1771/wastebin/breaker"
+	"github.com/coolguy1771/wastebin/clientip"
 	"github.com/coolguy1771/wastebin/config"
 	"github.com/coolguy1771/wastebin/handlers"
+	"github.com/coolguy1771/wastebin/ipfilter"
+	"github.com/coolguy1771/wastebin/loadshed"
+	"github.com/coolguy1771/wastebin/ratelimit"
+	"github.com/coolguy1771/wastebin/web"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
 )
 
-// Add routes to the app
-func AddRoutes(app *fiber.App) *fiber.App {
-	app.Use(cors.New())
-
-	api := app.Group("/api")
-	v1 := api.Group("/v1", func(c *fiber.Ctx) error {
-		c.JSON(fiber.Map{
-			"message": "🐣 v1",
-		})
-		return c.Next()
-	})
-
-	v1.Get("/paste/:uuid", handlers.GetPaste)
-	v1.Post("/paste", handlers.CreatePaste)
-	v1.Delete("/paste/:uuid", handlers.DeletePaste)
-
-	// Serve Single Page application
-	if config.Conf.Dev {
-		app.Static("/", "./web/build/")
-	} else {
-		app.Static("/", "/web/")
-	}
-
-	app.Get("/", serveSPA)
-	app.Get("/paste/:uuid", serveSPA)
-	app.Get("/paste/:uuid/raw", handlers.GetRawPaste)
+// Middleware are the configured middlewares shared by the routes
+type Middleware struct {
+	ClientIP *clientip.Resolver
+	Filter   *ipfilter.Filter
+	Limiter  *ratelimit.Limiter
+	Shedder  *loadshed.Shedder
+	Breaker  *breaker.Breaker
+}
+
+// Add the API routes to the app
+func AddRoutes(app *fiber.App, h *handlers.Handler, mw Middleware) *fiber.App {
+	app.Use(h.RequestID)
+	app.Use(h.AccessLog)
+	app.Use(h.Recover)
+	app.Use(mw.ClientIP.Handler)
+	app.Use(h.CORS)
+	app.Use(h.Drain)
+	app.Use(h.CollectStats)
+
+	// The probes are registered before the IP filter so they keep working
+	// when the kubelet isn't in the allow list
+	health := app.Group("/health")
+	health.Get("/startup", h.StartupProbe)
+	health.Get("/live", h.LivenessProbe)
+	health.Get("/ready", h.ReadinessProbe)
+	health.Get("/prestop", h.PreStop)
+	health.Post("/prestop", h.PreStop)
+
+	app.Use(mw.Filter.Handler)
+	app.Use(mw.Shedder.Handler)
+	app.Use(mw.Breaker.Handler)
+
+	handlers.AddDebugRoutes(app.Group("/debug", h.RequireDebug))
+
+	app.Get("/robots.txt", h.Robots)
+	app.Get("/.well-known/security.txt", h.SecurityTxt)
+	app.Get("/favicon.ico", h.Favicon)
+
+	api := app.Group("/api", mw.Limiter.Handler)
+	v1 := api.Group("/v1", handlers.APIVersion("v1"))
+
+	// Requests are bounded by API_TIMEOUT and paste creations by
+	// UPLOAD_TIMEOUT. The event streams stay open as long as the paste.
+	v1.Get("/limits", h.APIDeadline, h.GetPasteLimits)
+	v1.Get("/limits/paste", h.APIDeadline, h.GetPasteLimits)
+	v1.Get("/paste/:uuid", h.APIDeadline, h.GetPaste)
+	v1.Get("/paste/:uuid/meta", h.APIDeadline, h.GetPasteMeta)
+	v1.Get("/paste/:uuid/events", h.PasteEvents)
+	v1.Post("/paste", h.UploadDeadline, h.DetectAbuse, h.Idempotent, h.RequireCaptcha, h.CreatePaste)
+	v1.Put("/paste", h.UploadDeadline, h.DetectAbuse, h.Idempotent, h.RequireCaptcha, h.CreatePaste)
+	v1.Post("/paste/:uuid/fork", h.UploadDeadline, h.DetectAbuse, h.Idempotent, h.ForkPaste)
+	v1.Post("/paste/:uuid/report", h.APIDeadline, h.ReportPaste)
+	v1.Post("/paste/:uuid/annotations", h.APIDeadline, h.AnnotatePaste)
+	v1.Post("/paste/:uuid/tokens", h.APIDeadline, h.CreateShareToken)
+	v1.Post("/paste/:uuid/extend", h.APIDeadline, h.ExtendPaste)
+	v1.Get("/paste/:a/diff/:b", h.APIDeadline, h.DiffPastes)
+	v1.Delete("/paste/:uuid", h.APIDeadline, h.DeletePaste)
+	v1.Get("/pastes", h.APIDeadline, h.ListPastes)
+	v1.Get("/tags", h.APIDeadline, h.ListTags)
+	v1.Get("/stats", h.APIDeadline, h.GetPublicStats)
+	v1.Get("/user/settings", h.APIDeadline, h.GetUserSettings)
+	v1.Put("/user/settings", h.APIDeadline, h.PutUserSettings)
+
+	admin := v1.Group("/admin", h.RequireAdmin, h.APIDeadline)
+	admin.Get("/overview", h.GetOverview)
+	admin.Get("/stats", h.GetPasteStats)
+	admin.Get("/audit", h.QueryAudit)
+	admin.Get("/audit/export", h.ExportAudit)
+	admin.Get("/findings", h.ListFindings)
+	admin.Get("/reports", h.ListReports)
+	admin.Post("/paste/:uuid/release", h.ReleasePaste)
+
+	app.Get("/paste/:uuid/raw", mw.Limiter.Handler, h.APIDeadline, h.GetRawPaste)
+	app.Get("/paste/:uuid/qr.png", mw.Limiter.Handler, h.APIDeadline, h.GetPasteQR)
+	app.Get("/paste/:uuid/thumbnail.png", mw.Limiter.Handler, h.APIDeadline, h.GetPasteThumbnail)
+	app.Get("/paste/:uuid/embed", mw.Limiter.Handler, h.APIDeadline, h.GetPasteEmbed)
+	app.Get("/services/oembed", mw.Limiter.Handler, h.APIDeadline, h.OEmbed)
 
 	return app
 }
 
-func serveSPA(c *fiber.Ctx) error {
-	if config.Conf.Dev {
-		return c.SendFile("./web/build/index.html")
-	} else {
-		return c.SendFile("/web/index.html")
-	}
+// Add the web frontend routes to the app
+func AddUIRoutes(app *fiber.App, h *handlers.Handler, conf *config.Config) *fiber.App {
+	files := web.Files(conf.Dev)
+
+	// Serve Single Page application, other paths fall through to the routes below
+	app.Use(staticCache(files, conf))
+	app.Use(filesystem.New(filesystem.Config{
+		Root:  http.FS(files),
+		Index: "index.html",
+	}))
+	app.Get("/paste/:uuid", h.APIDeadline, h.PastePage(files))
+
+	return app
 }
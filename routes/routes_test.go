@@ -1 +1,66 @@
 package routes_test
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/handlers"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/routes"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestUICacheHeaders(t *testing.T) {
+	// Development builds are read from ./web/build
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"index.html":                   "<html><head></head></html>",
+		"favicon.ico":                  "icon",
+		"_app/immutable/start-1a2b.js": "start()",
+	} {
+		path := filepath.Join(dir, "web", "build", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	db, err := gorm.Open(sqlite.Open("file:routes?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := config.Default()
+	conf.Dev = true
+	app := fiber.New()
+	routes.AddUIRoutes(app, handlers.New(&conf, log.Default(), db), &conf)
+
+	for path, expected := range map[string]string{
+		"/":                             "no-cache",
+		"/index.html":                   "no-cache",
+		"/favicon.ico":                  "public, max-age=3600",
+		"/_app/immutable/start-1a2b.js": "public, max-age=31536000, immutable",
+	} {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusOK || resp.Header.Get("Cache-Control") != expected {
+			t.Errorf("expected %s to be served with Cache-Control %q, got %d %q", path, expected, resp.StatusCode, resp.Header.Get("Cache-Control"))
+		}
+	}
+}
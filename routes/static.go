@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// staticCache sets the Cache-Control header of the frontend files. Files
+// under the immutable paths have content hashes in their names and are cached
+// for a year, pages are revalidated so deploys are picked up at once, and the
+// other files are cached for the configured max age.
+func staticCache(files fs.FS, conf *config.Config) fiber.Handler {
+	var immutable []string
+	for _, prefix := range strings.Split(conf.StaticImmutablePaths, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			immutable = append(immutable, prefix)
+		}
+	}
+	maxAge := "public, max-age=" + strconv.Itoa(conf.StaticMaxAge)
+
+	return func(c *fiber.Ctx) error {
+		name := strings.TrimPrefix(path.Clean(c.Path()), "/")
+		if name == "" || strings.HasSuffix(name, ".html") {
+			c.Set(fiber.HeaderCacheControl, "no-cache")
+			return c.Next()
+		}
+		// Leave the paths of the other routes alone
+		if info, err := fs.Stat(files, name); err != nil || info.IsDir() {
+			return c.Next()
+		}
+
+		c.Set(fiber.HeaderCacheControl, maxAge)
+		for _, prefix := range immutable {
+			if strings.HasPrefix(c.Path(), prefix) {
+				c.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+				break
+			}
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,78 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HTTP sends the content to an external scanning service. The service is
+// sent {"content": "..."} and answers with {"findings": [{"reason": "..."}]},
+// each finding optionally naming its own action.
+type HTTP struct {
+	url    string
+	action Action
+	client *http.Client
+}
+
+// httpRequest is the body sent to the scanning service
+type httpRequest struct {
+	Content string `json:"content"`
+}
+
+// httpResponse is the body the scanning service answers with
+type httpResponse struct {
+	Findings []struct {
+		Reason string `json:"reason"`
+		Action Action `json:"action"`
+	} `json:"findings"`
+}
+
+// NewHTTP creates an HTTP scanner waiting at most timeout for the service.
+// action is used for the findings without a valid one.
+func NewHTTP(url string, timeout time.Duration, action Action) *HTTP {
+	return &HTTP{
+		url:    url,
+		action: action,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Scan returns the findings of the scanning service
+func (h *HTTP) Scan(ctx context.Context, content string) ([]Finding, error) {
+	body, err := json.Marshal(httpRequest{Content: content})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scanning service answered %s", resp.Status)
+	}
+
+	var result httpResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid scanning service response: %w", err)
+	}
+	findings := make([]Finding, 0, len(result.Findings))
+	for _, found := range result.Findings {
+		finding := Finding{Scanner: "http", Reason: found.Reason, Action: found.Action}
+		if !finding.Action.Valid() {
+			finding.Action = h.action
+		}
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
@@ -0,0 +1,132 @@
+// Package scan checks the content of new pastes for abuse such as blocked
+// content or leaked credentials
+package scan
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/google/uuid"
+)
+
+// Action is what is done with a paste a Scanner found something in
+type Action string
+
+const (
+	// ActionReject refuses to create the paste
+	ActionReject Action = "reject"
+	// ActionQuarantine creates the paste readable only by the admin until
+	// it is released
+	ActionQuarantine Action = "quarantine"
+	// ActionFlag creates the paste as usual and lists it for admin review
+	ActionFlag Action = "flag"
+)
+
+// severity orders the actions from the most lenient to the strictest
+var severity = map[Action]int{
+	ActionFlag:       1,
+	ActionQuarantine: 2,
+	ActionReject:     3,
+}
+
+// Valid reports whether a is a known action
+func (a Action) Valid() bool {
+	return severity[a] > 0
+}
+
+// Finding is something a Scanner found in the content of a paste
+type Finding struct {
+	Scanner string `json:"scanner" example:"secrets"`
+	Reason  string `json:"reason" example:"AWS access key"`
+	Action  Action `json:"action" example:"quarantine"`
+}
+
+// Scanner checks the content of a new paste. It returns nothing when the
+// content is fine.
+type Scanner interface {
+	Scan(ctx context.Context, content string) ([]Finding, error)
+}
+
+// Chain runs every scanner and returns all their findings
+type Chain []Scanner
+
+// Scan runs the scanners in order, stopping at the first error
+func (c Chain) Scan(ctx context.Context, content string) ([]Finding, error) {
+	var findings []Finding
+	for _, scanner := range c {
+		found, err := scanner.Scan(ctx, content)
+		if err != nil {
+			return findings, err
+		}
+		findings = append(findings, found...)
+	}
+	return findings, nil
+}
+
+// Strictest returns the strictest action of the findings, "" when there are none
+func Strictest(findings []Finding) Action {
+	var strictest Action
+	for _, finding := range findings {
+		if severity[finding.Action] > severity[strictest] {
+			strictest = finding.Action
+		}
+	}
+	return strictest
+}
+
+// Blocklist finds content matching any of its regular expressions
+type Blocklist struct {
+	patterns []*regexp.Regexp
+	action   Action
+}
+
+// NewBlocklist compiles the patterns, one per line, ignoring empty lines and
+// the ones starting with #
+func NewBlocklist(patterns string, action Action) (*Blocklist, error) {
+	b := &Blocklist{action: action}
+	for _, line := range strings.Split(patterns, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pattern, err := regexp.Compile(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blocklist pattern %q: %w", line, err)
+		}
+		b.patterns = append(b.patterns, pattern)
+	}
+	return b, nil
+}
+
+// Scan returns a finding for the first pattern the content matches. The
+// reason names the pattern by its position so it isn't revealed to the
+// creators of rejected pastes.
+func (b *Blocklist) Scan(ctx context.Context, content string) ([]Finding, error) {
+	for i, pattern := range b.patterns {
+		if pattern.MatchString(content) {
+			return []Finding{{Scanner: "blocklist", Reason: fmt.Sprintf("Blocklist pattern %d", i+1), Action: b.action}}, nil
+		}
+	}
+	return nil, nil
+}
+
+// Record returns the finding as stored for the admin review of a paste
+func (f Finding) Record(paste uuid.UUID) models.ScanFinding {
+	return models.ScanFinding{PasteUUID: paste, Scanner: f.Scanner, Reason: f.Reason, Action: string(f.Action)}
+}
+
+// Reasons returns the distinct reasons of the findings separated by commas
+func Reasons(findings []Finding) string {
+	seen := make(map[string]bool)
+	var reasons []string
+	for _, finding := range findings {
+		if !seen[finding.Reason] {
+			seen[finding.Reason] = true
+			reasons = append(reasons, finding.Reason)
+		}
+	}
+	return strings.Join(reasons, ", ")
+}
@@ -0,0 +1,46 @@
+package scan
+
+import (
+	"context"
+	"regexp"
+)
+
+// secretPatterns identify well known credentials
+var secretPatterns = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"AWS secret key", regexp.MustCompile(`(?i)aws_?secret_?access_?key\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{82})\b`)},
+	{"GitLab token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{"Stripe secret key", regexp.MustCompile(`\b(sk|rk)_live_[A-Za-z0-9]{24,}\b`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"Google OAuth token", regexp.MustCompile(`\bya29\.[0-9A-Za-z_-]{20,}`)},
+	{"GCP service account key", regexp.MustCompile(`"type"\s*:\s*"service_account"[\s\S]*"private_key"\s*:`)},
+	{"JSON Web Token", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{"private key", regexp.MustCompile(`-----BEGIN ((RSA|DSA|EC|OPENSSH|PGP|ENCRYPTED) )?PRIVATE KEY( BLOCK)?-----`)},
+}
+
+// Secrets finds credentials such as cloud access keys, API tokens and
+// private keys
+type Secrets struct {
+	action Action
+}
+
+// NewSecrets creates a Secrets scanner taking action on the pastes with credentials
+func NewSecrets(action Action) *Secrets {
+	return &Secrets{action: action}
+}
+
+// Scan returns a finding for every kind of credential in the content
+func (s *Secrets) Scan(ctx context.Context, content string) ([]Finding, error) {
+	var findings []Finding
+	for _, secret := range secretPatterns {
+		if secret.pattern.MatchString(content) {
+			findings = append(findings, Finding{Scanner: "secrets", Reason: secret.name, Action: s.action})
+		}
+	}
+	return findings, nil
+}
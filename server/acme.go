@@ -0,0 +1,28 @@
+package server
+
+import (
+	"crypto/tls"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// acmeConfig serves certificates obtained and renewed from Let's Encrypt.
+// The HTTP-01 challenges are answered on the ACME HTTP port, which redirects
+// every other request to HTTPS.
+func (s *Server) acmeConfig() (*tls.Config, error) {
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(s.config.ACMEDomainList()...),
+		Cache:      autocert.DirCache(s.config.ACMECacheDir),
+		Email:      s.config.ACMEEmail,
+	}
+
+	challenges, err := s.serveHTTP(s.config.ACMEHTTPPort, "ACME challenge", manager.HTTPHandler(s.redirectHandler()))
+	if err != nil {
+		return nil, err
+	}
+	s.challenges = challenges
+	s.logger.Info("Answering ACME challenges", zap.Strings("domains", s.config.ACMEDomainList()))
+	return manager.TLSConfig(), nil
+}
@@ -0,0 +1,268 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/net/http2"
+)
+
+// detectTimeout bounds the TLS handshake or the read of the HTTP/2 preface
+// deciding which protocol a connection speaks
+const detectTimeout = 10 * time.Second
+
+// http2Preface starts every HTTP/2 connection made without TLS
+const http2Preface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+// http2Listener serves the HTTP/2 connections of a listener itself and
+// passes the others on to fasthttp, which only speaks HTTP/1. On HTTPS
+// listeners the clients ask for HTTP/2 through ALPN, on plain ones by
+// starting with the HTTP/2 preface, as proxies speaking h2c do.
+type http2Listener struct {
+	net.Listener
+	tls       bool
+	server    *http2.Server
+	handler   fasthttp.RequestHandler
+	bodyLimit int
+	logger    *log.Logger
+
+	conns  chan net.Conn
+	failed chan struct{}
+	err    error
+
+	mu     sync.Mutex
+	closed bool
+	active map[net.Conn]struct{}
+}
+
+// withHTTP2 makes the listener serve HTTP/2 when it is enabled for its kind,
+// HTTP2_ENABLED for HTTPS listeners and H2C_ENABLED for plain ones
+func (s *Server) withHTTP2(listener net.Listener, secure bool) net.Listener {
+	if secure && !s.config.HTTP2Enabled || !secure && !s.config.H2CEnabled {
+		return listener
+	}
+	l := &http2Listener{
+		Listener:  listener,
+		tls:       secure,
+		server:    &http2.Server{},
+		handler:   s.wastebin.App().Handler(),
+		bodyLimit: s.wastebin.App().Config().BodyLimit,
+		logger:    s.logger,
+		conns:     make(chan net.Conn),
+		failed:    make(chan struct{}),
+		active:    make(map[net.Conn]struct{}),
+	}
+	go l.accept()
+	return l
+}
+
+// Accept returns the next HTTP/1 connection
+func (l *http2Listener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.failed:
+		return nil, l.err
+	}
+}
+
+// Close stops listening and closes the HTTP/2 connections, whose requests
+// were drained before
+func (l *http2Listener) Close() error {
+	err := l.Listener.Close()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	for conn := range l.active {
+		conn.Close()
+	}
+	return err
+}
+
+// accept routes the accepted connections until the listener fails or is
+// closed
+func (l *http2Listener) accept() {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			l.err = err
+			close(l.failed)
+			return
+		}
+		go l.route(conn)
+	}
+}
+
+// route serves the connection when it speaks HTTP/2 and passes it on to
+// Accept otherwise
+func (l *http2Listener) route(conn net.Conn) {
+	conn, h2, err := l.detect(conn)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	if !h2 {
+		select {
+		case l.conns <- conn:
+		case <-l.failed:
+			conn.Close()
+		}
+		return
+	}
+
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		conn.Close()
+		return
+	}
+	l.active[conn] = struct{}{}
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		delete(l.active, conn)
+		l.mu.Unlock()
+	}()
+
+	l.server.ServeConn(conn, &http2.ServeConnOpts{Handler: l.bridge(conn)})
+}
+
+// detect reports whether the connection speaks HTTP/2, returning the
+// connection to read from instead, as the preface is read ahead
+func (l *http2Listener) detect(conn net.Conn) (net.Conn, bool, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(detectTimeout)); err != nil {
+		return conn, false, err
+	}
+	defer conn.SetReadDeadline(time.Time{})
+
+	if l.tls {
+		tlsConn, ok := conn.(*tls.Conn)
+		if !ok {
+			return conn, false, nil
+		}
+		if err := tlsConn.Handshake(); err != nil {
+			return conn, false, err
+		}
+		return conn, tlsConn.ConnectionState().NegotiatedProtocol == http2.NextProtoTLS, nil
+	}
+
+	// Read until the data stops matching the preface, so that short
+	// HTTP/1 requests aren't waited on
+	reader := bufio.NewReaderSize(conn, len(http2Preface))
+	peeked := &peekedConn{Conn: conn, reader: reader}
+	for n := 1; n <= len(http2Preface); n++ {
+		data, err := reader.Peek(n)
+		if err != nil {
+			return peeked, false, err
+		}
+		if data[n-1] != http2Preface[n-1] {
+			return peeked, false, nil
+		}
+	}
+	return peeked, true, nil
+}
+
+// bridge passes the HTTP/2 requests of the connection to the fasthttp
+// handler of the app and sends back its responses
+func (l *http2Listener) bridge(conn net.Conn) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ctx fasthttp.RequestCtx
+		ctx.Init2(conn, fasthttpLogger{l.logger}, false)
+
+		req := &ctx.Request
+		req.Header.SetMethod(r.Method)
+		req.SetRequestURI(r.URL.RequestURI())
+		req.Header.SetHost(r.Host)
+		for key, values := range r.Header {
+			// HTTP/2 clients may split the cookies in several headers
+			if key == "Cookie" {
+				req.Header.Set(key, strings.Join(values, "; "))
+				continue
+			}
+			for _, value := range values {
+				req.Header.Add(key, value)
+			}
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(l.bodyLimit)))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			}
+			return
+		}
+		req.SetBody(body)
+		req.Header.SetContentLength(len(body))
+
+		l.handler(&ctx)
+
+		resp := &ctx.Response
+		stream := resp.IsBodyStream()
+		// The connection headers of HTTP/1 are not allowed in HTTP/2
+		resp.Header.VisitAll(func(key, value []byte) {
+			switch {
+			case bytes.EqualFold(key, []byte(fasthttp.HeaderConnection)), bytes.EqualFold(key, []byte(fasthttp.HeaderTransferEncoding)):
+			case stream && bytes.EqualFold(key, []byte(fasthttp.HeaderContentLength)):
+			default:
+				w.Header().Add(string(key), string(value))
+			}
+		})
+		w.WriteHeader(resp.StatusCode())
+		if !stream {
+			w.Write(resp.Body())
+			return
+		}
+		resp.BodyWriteTo(&streamWriter{w: w, ctx: r.Context()})
+	})
+}
+
+// streamWriter sends the body written by a stream writer, such as the events
+// of a paste, flushing it as it is written. Writes fail once the client went
+// away, which stops the stream writer.
+type streamWriter struct {
+	w   http.ResponseWriter
+	ctx context.Context
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if err := s.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := s.w.Write(p)
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
+
+// peekedConn reads a connection through the reader that read ahead of it
+type peekedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *peekedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// fasthttpLogger writes the messages of fasthttp to the server log
+type fasthttpLogger struct {
+	logger *log.Logger
+}
+
+func (l fasthttpLogger) Printf(format string, args ...interface{}) {
+	l.logger.Warn(fmt.Sprintf(format, args...))
+}
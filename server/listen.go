@@ -0,0 +1,183 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/coolguy1771/wastebin/certs"
+	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+)
+
+// listenFDsStart is the first file descriptor of the sockets passed with
+// LISTEN_FDS, after stdin, stdout and stderr
+const listenFDsStart = 3
+
+// listen opens the configured listeners, serving TLS with tlsConfig on the
+// HTTPS ones. Every listener is closed again when one fails to open. The
+// sockets passed by systemd socket activation are used instead when there
+// are any.
+func (s *Server) listen(tlsConfig *tls.Config) (_ []net.Listener, err error) {
+	if listeners, err := s.activatedListeners(tlsConfig); listeners != nil || err != nil {
+		return listeners, err
+	}
+
+	addrs, err := s.config.Listeners()
+	if err != nil {
+		return nil, err
+	}
+
+	var listeners []net.Listener
+	defer func() {
+		if err != nil {
+			for _, listener := range listeners {
+				listener.Close()
+			}
+		}
+	}()
+
+	for _, addr := range addrs {
+		// A socket left behind by a previous run would make listening fail
+		if addr.Network == "unix" {
+			if info, err := os.Stat(addr.Address); err == nil && info.Mode()&fs.ModeSocket != 0 {
+				if err := os.Remove(addr.Address); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		listener, err := net.Listen(addr.Network, addr.Address)
+		if err != nil {
+			return nil, err
+		}
+		if addr.TLS {
+			listener = tls.NewListener(listener, tlsConfig)
+		}
+		listener = s.withHTTP2(listener, addr.TLS)
+		listeners = append(listeners, listener)
+		s.logger.Info("Starting the server", zap.Stringer("address", addr))
+	}
+	return listeners, nil
+}
+
+// activatedListeners returns the listening sockets passed in LISTEN_FDS by
+// systemd socket activation, nil when there are none. The sockets stay open
+// while the service restarts, so upgrades don't refuse connections. Sockets
+// named https in LISTEN_FDNAMES serve TLS. The variables are unset so that
+// child processes don't take the sockets for theirs.
+func (s *Server) activatedListeners(tlsConfig *tls.Config) (_ []net.Listener, err error) {
+	fds := os.Getenv("LISTEN_FDS")
+	if fds == "" {
+		return nil, nil
+	}
+	pid := os.Getenv("LISTEN_PID")
+	names := os.Getenv("LISTEN_FDNAMES")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDNAMES")
+	if pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(fds)
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS %q", fds)
+	}
+
+	var listeners []net.Listener
+	defer func() {
+		if err != nil {
+			for _, listener := range listeners {
+				listener.Close()
+			}
+		}
+	}()
+
+	fdNames := strings.Split(names, ":")
+	for i := 0; i < count; i++ {
+		name := ""
+		if i < len(fdNames) {
+			name = fdNames[i]
+		}
+		file := os.NewFile(uintptr(listenFDsStart+i), name)
+		listener, err := net.FileListener(file)
+		// The listener holds its own copy of the descriptor
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("socket %d passed in LISTEN_FDS: %w", listenFDsStart+i, err)
+		}
+		if name == "https" {
+			if tlsConfig == nil {
+				listener.Close()
+				return nil, errors.New("socket named https passed in LISTEN_FDS needs TLS or ACME to be enabled")
+			}
+			listener = tls.NewListener(listener, tlsConfig)
+		}
+		listener = s.withHTTP2(listener, name == "https")
+		listeners = append(listeners, listener)
+		s.logger.Info("Starting the server on an activated socket", zap.Stringer("address", listener.Addr()), zap.String("name", name))
+	}
+	return listeners, nil
+}
+
+// tlsConfig returns the TLS configuration of the HTTPS listeners, nil when
+// neither TLS nor ACME is enabled
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	var tlsConfig *tls.Config
+	var err error
+	switch {
+	case s.config.TLSEnabled:
+		tlsConfig, err = s.certFilesConfig()
+	case s.config.ACMEEnabled:
+		tlsConfig, err = s.acmeConfig()
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.NextProtos = s.nextProtos(tlsConfig.NextProtos)
+	return tlsConfig, nil
+}
+
+// nextProtos returns the protocols offered through ALPN, keeping the ones
+// of ACME challenges. HTTP/2 is only offered when HTTP2_ENABLED serves it,
+// as fasthttp speaks HTTP/1.1 only.
+func (s *Server) nextProtos(protos []string) []string {
+	next := []string{"http/1.1"}
+	if s.config.HTTP2Enabled {
+		next = []string{http2.NextProtoTLS, "http/1.1"}
+	}
+	for _, proto := range protos {
+		if proto != http2.NextProtoTLS && proto != "http/1.1" {
+			next = append(next, proto)
+		}
+	}
+	return next
+}
+
+// certFilesConfig serves the configured certificate, which is reloaded
+// whenever its files change
+func (s *Server) certFilesConfig() (*tls.Config, error) {
+	reloader, err := certs.NewReloader(s.config.TLSCertFile, s.config.TLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		err := reloader.Watch(s.done, func() {
+			s.logger.Info("Reloaded the TLS certificate")
+		}, func(err error) {
+			s.logger.Error("Error reloading the TLS certificate", zap.Error(err))
+		})
+		if err != nil {
+			s.logger.Error("Error watching the TLS certificate", zap.Error(err))
+		}
+	}()
+	return reloader.TLSConfig(), nil
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"time"
+
+	"github.com/coolguy1771/wastebin/stats"
+	"go.uber.org/zap"
+)
+
+// watchPool logs the use of the database connection pool every interval and
+// warns when requests waited too long for a free connection, which calls for
+// more connections
+func (s *Server) watchPool() {
+	ticker := time.NewTicker(s.config.DBPoolStatsInterval)
+	defer ticker.Stop()
+
+	var monitor stats.PoolMonitor
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			dbStats, err := s.wastebin.DBStats()
+			if err != nil {
+				s.logger.Error("Error reading the database pool stats", zap.Error(err))
+				continue
+			}
+			interval := monitor.Observe(dbStats)
+			fields := []zap.Field{
+				zap.Int("open", interval.Open),
+				zap.Int("in_use", interval.InUse),
+				zap.Int("idle", interval.Idle),
+				zap.Int("max_open", interval.MaxOpen),
+				zap.Int64("waits", interval.Waits),
+				zap.Duration("average_wait", interval.AverageWait),
+			}
+			if interval.AverageWait > s.config.DBPoolWaitThreshold {
+				s.logger.Warn("Requests are waiting for database connections", fields...)
+				continue
+			}
+			s.logger.Debug("Database connection pool", fields...)
+		}
+	}
+}
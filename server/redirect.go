@@ -0,0 +1,74 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// serveHTTP serves handler on port with net/http until the server is shut
+// down
+func (s *Server) serveHTTP(port, name string, handler http.Handler) (*http.Server, error) {
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	s.logger.Info("Starting the "+name+" server", zap.String("port", port))
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("Error serving the "+name+" server", zap.Error(err))
+		}
+	}()
+	return server, nil
+}
+
+// redirectHandler redirects reads to the same URL over HTTPS, on the port
+// of the first HTTPS listener. Other requests are refused rather than
+// redirected, so clients notice they sent their body in the clear.
+func (s *Server) redirectHandler() http.Handler {
+	port := s.httpsPort()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Use HTTPS", http.StatusBadRequest)
+			return
+		}
+		host := r.Host
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		}
+		host = strings.Trim(host, "[]")
+		if port != "" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// httpsPort returns the port of the first HTTPS listener, empty for the
+// default port or when it is not known
+func (s *Server) httpsPort() string {
+	addrs, err := s.config.Listeners()
+	if err != nil {
+		return ""
+	}
+	for _, addr := range addrs {
+		if !addr.TLS {
+			continue
+		}
+		if _, port, err := net.SplitHostPort(addr.Address); err == nil && port != "443" {
+			return port
+		}
+		return ""
+	}
+	return ""
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/coolguy1771/wastebin"
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/health"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/tcpupload"
+	"go.uber.org/zap"
+)
+
+// Server runs a standalone wastebin instance. Every Server uses its own
+// configuration, logger and database connection so several can run in one
+// process.
+type Server struct {
+	config   *config.Config
+	logger   *log.Logger
+	wastebin *wastebin.Wastebin
+	done     chan struct{}
+	reload   chan struct{}
+
+	// challenges answers the ACME HTTP-01 challenges when ACME is enabled
+	challenges *http.Server
+	// redirect redirects plain HTTP to HTTPS when HTTP_REDIRECT_PORT is set
+	redirect *http.Server
+}
+
+// New connects to and migrates the database and sets up the routes
+func New(conf *config.Config, logger *log.Logger) (*Server, error) {
+	wb, err := wastebin.New(conf, wastebin.Options{Logger: logger})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		config:   conf,
+		logger:   logger,
+		wastebin: wb,
+		done:     make(chan struct{}),
+		reload:   make(chan struct{}, 1),
+	}, nil
+}
+
+// Start listens on the configured addresses and blocks until the server is
+// shut down
+func (s *Server) Start() error {
+	// Reload supported settings when the mounted config directory changes or
+	// Reload is called
+	go s.watchConfig()
+
+	if s.config.DBPoolStatsInterval > 0 {
+		go s.watchPool()
+	}
+
+	// Serve the gRPC API on its own port when one is configured
+	if s.config.GRPCPort != "" {
+		listener, err := net.Listen("tcp", ":"+s.config.GRPCPort)
+		if err != nil {
+			return err
+		}
+		s.logger.Info("Starting the gRPC server", zap.String("port", s.config.GRPCPort))
+		go func() {
+			if err := s.wastebin.GRPCServer().Serve(listener); err != nil {
+				s.logger.Error("Error serving the gRPC API", zap.Error(err))
+			}
+		}()
+	}
+
+	// Create pastes from plain TCP connections when a port is configured
+	if s.config.TCPUploadPort != "" {
+		listener, err := net.Listen("tcp", ":"+s.config.TCPUploadPort)
+		if err != nil {
+			return err
+		}
+		s.logger.Info("Starting the TCP upload server", zap.String("port", s.config.TCPUploadPort))
+		go func() {
+			if err := s.wastebin.TCPUploadServer().Serve(listener); err != nil && !errors.Is(err, tcpupload.ErrServerClosed) {
+				s.logger.Error("Error serving the TCP uploads", zap.Error(err))
+			}
+		}()
+	}
+
+	tlsConfig, err := s.tlsConfig()
+	if err != nil {
+		return err
+	}
+	if s.config.HTTPRedirectPort != "" {
+		if s.redirect, err = s.serveHTTP(s.config.HTTPRedirectPort, "HTTPS redirect", s.redirectHandler()); err != nil {
+			return err
+		}
+	}
+	listeners, err := s.listen(tlsConfig)
+	if err != nil {
+		return err
+	}
+
+	// Serve every listener until the app is shut down, which closes them all
+	errs := make(chan error, len(listeners))
+	for _, listener := range listeners {
+		go func(listener net.Listener) {
+			errs <- s.wastebin.App().Listener(listener)
+		}(listener)
+	}
+	return <-errs
+}
+
+// HealthCheck checks the dependencies of the server, see Wastebin.HealthCheck
+func (s *Server) HealthCheck(ctx context.Context) map[string]health.Result {
+	return s.wastebin.HealthCheck(ctx)
+}
+
+// Shutdown stops the server and closes the database connection
+func (s *Server) Shutdown() error {
+	close(s.done)
+	for _, server := range []*http.Server{s.challenges, s.redirect} {
+		if server == nil {
+			continue
+		}
+		if err := server.Close(); err != nil {
+			return err
+		}
+	}
+	return s.wastebin.Close()
+}
+
+// Reload rereads the configuration and applies the settings that can be
+// changed without a restart, see watchConfig
+func (s *Server) Reload() {
+	select {
+	case s.reload <- struct{}{}:
+	default:
+	}
+}
+
+// watchConfig applies the settings that can be changed without a restart
+// whenever the config directory is updated or Reload is called
+func (s *Server) watchConfig() {
+	err := config.Watch(*s.config, s.done, s.reload, func(old, new config.Config, changed []string) {
+		s.logger.Info("Configuration changed", zap.Strings("changed", changed))
+
+		if new.LogLevel != old.LogLevel {
+			if err := s.logger.SetLevel(new.LogLevel); err != nil {
+				s.logger.Error("Error applying the log level", zap.Error(err))
+			}
+		}
+		if err := s.wastebin.Reload(new, changed); err != nil {
+			s.logger.Error("Error applying the configuration", zap.Error(err))
+		}
+	}, func(err error) {
+		s.logger.Error("Error reloading the configuration", zap.Error(err))
+	})
+	if err != nil {
+		s.logger.Error("Error watching the config directory", zap.Error(err))
+	}
+}
@@ -0,0 +1,100 @@
+package stats
+
+import (
+	"database/sql"
+	"os"
+	"runtime"
+	"time"
+)
+
+// RuntimeStats describes the Go runtime of the process
+type RuntimeStats struct {
+	Goroutines  int    `json:"goroutines"`
+	HeapAlloc   uint64 `json:"heap_alloc_bytes"`
+	HeapObjects uint64 `json:"heap_objects"`
+	Sys         uint64 `json:"sys_bytes"`
+	GCCycles    uint32 `json:"gc_cycles"`
+	// GCPauseTotal and LastGCPause are in seconds
+	GCPauseTotal float64 `json:"gc_pause_total_seconds"`
+	LastGCPause  float64 `json:"last_gc_pause_seconds"`
+	// OpenFDs is -1 on systems without /proc
+	OpenFDs int `json:"open_fds"`
+}
+
+// Runtime reads the statistics of the Go runtime. It stops the world briefly
+// to read the memory statistics, so it shouldn't run on every request.
+func Runtime() RuntimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := RuntimeStats{
+		Goroutines:   runtime.NumGoroutine(),
+		HeapAlloc:    mem.HeapAlloc,
+		HeapObjects:  mem.HeapObjects,
+		Sys:          mem.Sys,
+		GCCycles:     mem.NumGC,
+		GCPauseTotal: time.Duration(mem.PauseTotalNs).Seconds(),
+		OpenFDs:      -1,
+	}
+	if mem.NumGC > 0 {
+		stats.LastGCPause = time.Duration(mem.PauseNs[(mem.NumGC+255)%256]).Seconds()
+	}
+	if fds, err := os.ReadDir("/proc/self/fd"); err == nil {
+		stats.OpenFDs = len(fds)
+	}
+	return stats
+}
+
+// PoolStats describes the connection pool of a database
+type PoolStats struct {
+	MaxOpen int `json:"max_open"`
+	Open    int `json:"open"`
+	InUse   int `json:"in_use"`
+	Idle    int `json:"idle"`
+	// WaitCount and WaitDuration total the waits for a free connection,
+	// WaitDuration being in seconds
+	WaitCount         int64   `json:"wait_count"`
+	WaitDuration      float64 `json:"wait_duration_seconds"`
+	MaxIdleClosed     int64   `json:"max_idle_closed"`
+	MaxLifetimeClosed int64   `json:"max_lifetime_closed"`
+}
+
+// Pool converts the statistics of a database connection pool
+func Pool(db sql.DBStats) PoolStats {
+	return PoolStats{
+		MaxOpen:           db.MaxOpenConnections,
+		Open:              db.OpenConnections,
+		InUse:             db.InUse,
+		Idle:              db.Idle,
+		WaitCount:         db.WaitCount,
+		WaitDuration:      db.WaitDuration.Seconds(),
+		MaxIdleClosed:     db.MaxIdleClosed,
+		MaxLifetimeClosed: db.MaxLifetimeClosed,
+	}
+}
+
+// PoolInterval is the use of a connection pool between two observations
+type PoolInterval struct {
+	PoolStats
+	// Waits is the number of waits for a free connection in the interval
+	Waits       int64
+	AverageWait time.Duration
+}
+
+// PoolMonitor follows the waits for free connections of a connection pool
+type PoolMonitor struct {
+	last sql.DBStats
+}
+
+// Observe returns the use of the pool since the previous observation
+func (m *PoolMonitor) Observe(db sql.DBStats) PoolInterval {
+	interval := PoolInterval{
+		PoolStats: Pool(db),
+		Waits:     db.WaitCount - m.last.WaitCount,
+	}
+	if interval.Waits > 0 {
+		interval.AverageWait = (db.WaitDuration - m.last.WaitDuration) / time.Duration(interval.Waits)
+	}
+	m.last = db
+	return interval
+}
@@ -0,0 +1,152 @@
+package stats
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coolguy1771/wastebin/clientip"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxTrackedClients bounds the memory used to find the top talkers. When it is
+// reached every client count is halved and the clients left at zero are
+// forgotten, so the counts of the top talkers are approximate.
+const maxTrackedClients = 10000
+
+// RouteStats counts the requests served by a route
+type RouteStats struct {
+	Route        string `json:"route"`
+	Requests     uint64 `json:"requests"`
+	ClientErrors uint64 `json:"client_errors"`
+	ServerErrors uint64 `json:"server_errors"`
+}
+
+// ClientStats counts the requests made by a client IP
+type ClientStats struct {
+	IP       string `json:"ip"`
+	Requests uint64 `json:"requests"`
+}
+
+// Snapshot is the traffic seen since the Collector was created
+type Snapshot struct {
+	Since      time.Time     `json:"since"`
+	Requests   uint64        `json:"requests"`
+	ErrorRate  float64       `json:"error_rate"`
+	Routes     []RouteStats  `json:"routes"`
+	TopTalkers []ClientStats `json:"top_talkers"`
+}
+
+// Collector is a fiber middleware counting the requests per route and client
+type Collector struct {
+	mu      sync.Mutex
+	since   time.Time
+	routes  map[string]*RouteStats
+	clients map[string]uint64
+}
+
+// NewCollector creates an empty Collector
+func NewCollector() *Collector {
+	return &Collector{
+		since:   time.Now(),
+		routes:  make(map[string]*RouteStats),
+		clients: make(map[string]uint64),
+	}
+}
+
+// Since returns when the Collector started counting
+func (s *Collector) Since() time.Time {
+	return s.since
+}
+
+// Handler records the request once it was handled
+func (s *Collector) Handler(c *fiber.Ctx) error {
+	err := c.Next()
+
+	// The error handler sets the status after the middleware returns
+	status := c.Response().StatusCode()
+	if err != nil {
+		status = fiber.StatusInternalServerError
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			status = fiberErr.Code
+		}
+	}
+	s.record(c.Method()+" "+c.Route().Path, clientip.Get(c), status)
+
+	return err
+}
+
+func (s *Collector) record(route, ip string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, ok := s.routes[route]
+	if !ok {
+		stats = &RouteStats{Route: route}
+		s.routes[route] = stats
+	}
+	stats.Requests++
+	switch {
+	case status >= 500:
+		stats.ServerErrors++
+	case status >= 400:
+		stats.ClientErrors++
+	}
+
+	if _, ok := s.clients[ip]; !ok && len(s.clients) >= maxTrackedClients {
+		for client, count := range s.clients {
+			if count /= 2; count == 0 {
+				delete(s.clients, client)
+			} else {
+				s.clients[client] = count
+			}
+		}
+	}
+	s.clients[ip]++
+}
+
+// Snapshot returns the routes ordered by their number of requests and the
+// topN clients that made the most requests
+func (s *Collector) Snapshot(topN int) Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := Snapshot{
+		Since:      s.since,
+		Routes:     make([]RouteStats, 0, len(s.routes)),
+		TopTalkers: make([]ClientStats, 0, len(s.clients)),
+	}
+
+	var serverErrors uint64
+	for _, stats := range s.routes {
+		snapshot.Routes = append(snapshot.Routes, *stats)
+		snapshot.Requests += stats.Requests
+		serverErrors += stats.ServerErrors
+	}
+	if snapshot.Requests > 0 {
+		snapshot.ErrorRate = float64(serverErrors) / float64(snapshot.Requests)
+	}
+	sort.Slice(snapshot.Routes, func(i, j int) bool {
+		if snapshot.Routes[i].Requests != snapshot.Routes[j].Requests {
+			return snapshot.Routes[i].Requests > snapshot.Routes[j].Requests
+		}
+		return snapshot.Routes[i].Route < snapshot.Routes[j].Route
+	})
+
+	for ip, count := range s.clients {
+		snapshot.TopTalkers = append(snapshot.TopTalkers, ClientStats{IP: ip, Requests: count})
+	}
+	sort.Slice(snapshot.TopTalkers, func(i, j int) bool {
+		if snapshot.TopTalkers[i].Requests != snapshot.TopTalkers[j].Requests {
+			return snapshot.TopTalkers[i].Requests > snapshot.TopTalkers[j].Requests
+		}
+		return snapshot.TopTalkers[i].IP < snapshot.TopTalkers[j].IP
+	})
+	if len(snapshot.TopTalkers) > topN {
+		snapshot.TopTalkers = snapshot.TopTalkers[:topN]
+	}
+
+	return snapshot
+}
@@ -0,0 +1,64 @@
+package stats_test
+
+import (
+	"database/sql"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/stats"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCollector(t *testing.T) {
+	collector := stats.NewCollector()
+	app := fiber.New()
+	app.Use(collector.Handler)
+	app.Get("/ok", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	app.Get("/fail", func(c *fiber.Ctx) error { return fiber.ErrBadGateway })
+
+	for _, path := range []string{"/ok", "/ok", "/fail"} {
+		if _, err := app.Test(httptest.NewRequest("GET", path, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshot := collector.Snapshot(10)
+	if snapshot.Requests != 3 || len(snapshot.Routes) != 2 {
+		t.Fatalf("unexpected snapshot %+v", snapshot)
+	}
+	if route := snapshot.Routes[0]; route.Route != "GET /ok" || route.Requests != 2 || route.ServerErrors != 0 {
+		t.Errorf("unexpected stats for /ok %+v", route)
+	}
+	if route := snapshot.Routes[1]; route.Route != "GET /fail" || route.ServerErrors != 1 {
+		t.Errorf("unexpected stats for /fail %+v", route)
+	}
+	if len(snapshot.TopTalkers) != 1 || snapshot.TopTalkers[0].Requests != 3 {
+		t.Errorf("unexpected top talkers %+v", snapshot.TopTalkers)
+	}
+}
+
+func TestRuntime(t *testing.T) {
+	runtime := stats.Runtime()
+	if runtime.Goroutines < 1 || runtime.HeapAlloc == 0 || runtime.Sys == 0 {
+		t.Errorf("unexpected runtime stats %+v", runtime)
+	}
+}
+
+func TestPoolMonitor(t *testing.T) {
+	var monitor stats.PoolMonitor
+	interval := monitor.Observe(sql.DBStats{InUse: 2, WaitCount: 4, WaitDuration: 400 * time.Millisecond})
+	if interval.InUse != 2 || interval.Waits != 4 || interval.AverageWait != 100*time.Millisecond {
+		t.Errorf("unexpected first interval %+v", interval)
+	}
+
+	interval = monitor.Observe(sql.DBStats{WaitCount: 6, WaitDuration: time.Second})
+	if interval.Waits != 2 || interval.AverageWait != 300*time.Millisecond {
+		t.Errorf("unexpected second interval %+v", interval)
+	}
+
+	interval = monitor.Observe(sql.DBStats{WaitCount: 6, WaitDuration: time.Second})
+	if interval.Waits != 0 || interval.AverageWait != 0 {
+		t.Errorf("expected no waits, got %+v", interval)
+	}
+}
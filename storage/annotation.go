@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrTooManyAnnotations is returned when a paste has no room for another annotation
+var ErrTooManyAnnotations = errors.New("too many annotations")
+
+// AddAnnotation records an annotation unless its paste already has max annotations
+func AddAnnotation(db *gorm.DB, annotation *models.Annotation, max int) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var annotations int64
+		if err := tx.Model(&models.Annotation{}).Where("paste_uuid = ?", annotation.PasteUUID).Count(&annotations).Error; err != nil {
+			return err
+		}
+		if annotations >= int64(max) {
+			return ErrTooManyAnnotations
+		}
+		return tx.Create(annotation).Error
+	})
+}
+
+// PasteAnnotations returns the annotations of a paste by line, oldest first
+func PasteAnnotations(db *gorm.DB, id uuid.UUID) ([]models.Annotation, error) {
+	var annotations []models.Annotation
+	err := db.Where("paste_uuid = ?", id).Order("line, created_at, id").Find(&annotations).Error
+	return annotations, err
+}
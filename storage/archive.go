@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/coolguy1771/wastebin/archive"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ExportPastes streams every paste to w as an archive and returns how many were written
+func ExportPastes(db *gorm.DB, w io.Writer) (int, error) {
+	buffered := bufio.NewWriter(w)
+	hostname, _ := os.Hostname()
+	writer, err := archive.NewWriter(buffered, archive.Instance{
+		Hostname: hostname,
+		Database: db.Dialector.Name(),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Pastes have no primary key to page through so stream the rows instead
+	rows, err := db.Model(&models.Paste{}).Order("created_at").Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var exported int
+	for rows.Next() {
+		var paste models.Paste
+		if err := db.ScanRows(rows, &paste); err != nil {
+			return exported, err
+		}
+		if err := writer.WritePaste(paste); err != nil {
+			return exported, err
+		}
+		exported++
+	}
+	if err := rows.Err(); err != nil {
+		return exported, err
+	}
+	return exported, buffered.Flush()
+}
+
+// ImportPastes stores the pastes of an archive read from r, batchSize pastes
+// at a time. Pastes whose UUID already exists are skipped so an import can be
+// resumed. The pastes that could not be stored are passed to failed and the
+// import goes on with the others.
+func ImportPastes(db *gorm.DB, r io.Reader, batchSize int, failed func(BatchError)) (imported, skipped int, err error) {
+	reader, err := archive.NewReader(r)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var batch []*models.Paste
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		defer func() { batch = batch[:0] }()
+
+		ids := make([]uuid.UUID, len(batch))
+		for i, paste := range batch {
+			ids[i] = paste.UUID
+		}
+		var existing []uuid.UUID
+		if err := db.Model(&models.Paste{}).Where("uuid IN ?", ids).Pluck("uuid", &existing).Error; err != nil {
+			return err
+		}
+		exists := make(map[uuid.UUID]bool, len(existing))
+		for _, id := range existing {
+			exists[id] = true
+		}
+
+		var pastes []*models.Paste
+		for _, paste := range batch {
+			if exists[paste.UUID] {
+				skipped++
+				continue
+			}
+			paste.Derive()
+			pastes = append(pastes, paste)
+		}
+		errs := CreatePastesBatch(db, pastes, batchSize)
+		if failed != nil {
+			for _, e := range errs {
+				failed(e)
+			}
+		}
+		imported += len(pastes) - len(errs)
+		return nil
+	}
+
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return imported, skipped, flush()
+		}
+		if err != nil {
+			return imported, skipped, err
+		}
+		if record.Kind != archive.KindPaste {
+			continue
+		}
+
+		batch = append(batch, record.Paste)
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return imported, skipped, err
+			}
+		}
+	}
+}
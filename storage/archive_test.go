@@ -0,0 +1,63 @@
+package storage_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func openDB(t testing.TB, name string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file:"+name+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Migrate(db, log.Default()); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestExportImport(t *testing.T) {
+	source := openDB(t, "source")
+	for _, content := range []string{"Paste A", "Paste B"} {
+		paste := models.Paste{Content: content, UUID: uuid.New(), ExpiryTimestamp: time.Now().Add(time.Hour)}
+		if err := source.Create(&paste).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	exported, err := storage.ExportPastes(source, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exported != 2 {
+		t.Fatalf("expected 2 exported pastes, got %d", exported)
+	}
+
+	target := openDB(t, "target")
+	data := buf.Bytes()
+	imported, skipped, err := storage.ImportPastes(target, bytes.NewReader(data), 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != 2 || skipped != 0 {
+		t.Fatalf("expected 2 imported pastes, got %d imported and %d skipped", imported, skipped)
+	}
+
+	// Importing again skips the pastes that already exist
+	imported, skipped, err = storage.ImportPastes(target, bytes.NewReader(data), 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != 0 || skipped != 2 {
+		t.Fatalf("expected 2 skipped pastes, got %d imported and %d skipped", imported, skipped)
+	}
+}
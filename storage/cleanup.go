@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"gorm.io/gorm"
+)
+
+// DeleteExpired deletes the pastes that expired before now with their
+// expiry events and returns how many were deleted
+func DeleteExpired(db *gorm.DB, now time.Time) (int64, error) {
+	var deleted int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var pastes []*models.Paste
+		if err := tx.Omit("content", "data", "thumbnail").Where("expiry_timestamp < ?", now).Find(&pastes).Error; err != nil {
+			return err
+		}
+		if err := recordEvents(tx, models.EventPasteExpired, pastes...); err != nil {
+			return err
+		}
+
+		expired := tx.Model(&models.Paste{}).Select("uuid").Where("expiry_timestamp < ?", now)
+		if err := tx.Where("paste_uuid IN (?)", expired).Delete(&models.PasteTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("paste_uuid IN (?)", expired).Delete(&models.ScanFinding{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("paste_uuid IN (?)", expired).Delete(&models.PasteReport{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("paste_uuid IN (?)", expired).Delete(&models.Annotation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("paste_uuid IN (?)", expired).Delete(&models.ShareToken{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("expiry_timestamp < ?", now).Delete(&models.Paste{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
+
+// PublishDue lifts the embargo of the pastes due to be published before now
+// and returns how many were published. Embargoed pastes are readable once due
+// either way, this keeps them from being checked again.
+func PublishDue(db *gorm.DB, now time.Time) (int64, error) {
+	result := db.Model(&models.Paste{}).Where("publish_at <= ?", now).Update("publish_at", nil)
+	return result.RowsAffected, result.Error
+}
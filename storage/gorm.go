@@ -1,76 +1,65 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/coolguy1771/wastebin/config"
 	"github.com/coolguy1771/wastebin/log"
-	"github.com/coolguy1771/wastebin/models"
 	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-var DBConn *gorm.DB
-
 // Connect to the database
-func Connect() error {
+func Connect(conf *config.Config, logger *log.Logger) (*gorm.DB, error) {
 	var (
 		dsn  string
 		conn *gorm.DB
 		err  error
 	)
 
-	if config.Conf.LocalDB {
-		log.Info("Using local database")
-		conn, err = gorm.Open(sqlite.Open("dev.db"), &gorm.Config{})
+	// Prepared statements are reused for the queries made again, which
+	// spares parsing and planning them on every paste read and write
+	gormConfig := &gorm.Config{PrepareStmt: conf.DBPrepareStatements}
+
+	if conf.LocalDB {
+		logger.Info("Using local database")
+		conn, err = gorm.Open(sqlite.Open("dev.db"), gormConfig)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		DBConn = conn
-		log.Info("Connected to local database")
-		return nil
+		logger.Info("Connected to local database")
+		return conn, nil
 	}
-	log.Info("Using remote database", zap.String("host", config.Conf.DBHost), zap.Int("port", config.Conf.DBPort), zap.String("name", config.Conf.DBName))
+	logger.Info("Using remote database", zap.String("host", conf.DBHost), zap.Int("port", conf.DBPort), zap.String("name", conf.DBName))
 	// Create Database connection string and connect to database
-	dsn = fmt.Sprintf("user=%s password=%s host=%s dbname=%s port=%d sslmode=disable", config.Conf.DBUser, config.Conf.DBPassword, config.Conf.DBHost, config.Conf.DBName, config.Conf.DBPort)
-	conn, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	dsn = fmt.Sprintf("user=%s password=%s host=%s dbname=%s port=%d sslmode=disable", conf.DBUser, conf.DBPassword, conf.DBHost, conf.DBName, conf.DBPort)
+	conn, err = gorm.Open(postgres.Open(dsn), gormConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	sqlDB, err := conn.DB()
-	sqlDB.SetMaxIdleConns(config.Conf.DBMaxIdleConns)
-	sqlDB.SetMaxOpenConns(config.Conf.DBMaxOpenConns)
-	sqlDB.SetConnMaxLifetime(time.Hour)
-
 	if err != nil {
-		return err
+		return nil, err
 	}
-	log.Info("Connected to remote database")
+	sqlDB.SetMaxIdleConns(conf.DBMaxIdleConns)
+	sqlDB.SetMaxOpenConns(conf.DBMaxOpenConns)
+	sqlDB.SetConnMaxLifetime(time.Hour)
 
-	log.Info("Set SQL Connection Settings", zap.Int("max_idle_conns", config.Conf.DBMaxIdleConns), zap.Int("max_open_conns", config.Conf.DBMaxOpenConns), zap.Int("conn_max_lifetime", 3600))
+	logger.Info("Connected to remote database")
 
-	DBConn = conn
-	return nil
-}
+	logger.Info("Set SQL Connection Settings", zap.Int("max_idle_conns", conf.DBMaxIdleConns), zap.Int("max_open_conns", conf.DBMaxOpenConns), zap.Int("conn_max_lifetime", 3600), zap.Bool("prepare_statements", conf.DBPrepareStatements))
 
-// Migrate the database
-func Migrate() error {
-	log.Info("Beginning database migration")
-	err := DBConn.AutoMigrate(&models.Paste{})
-	if err != nil {
-		return err
-	}
-	log.Info("Database migration complete")
-	return nil
+	return conn, nil
 }
 
 // Close the database connection
-func Close() error {
-	sqlDB, err := DBConn.DB()
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
 	if err != nil {
 		return err
 	}
@@ -80,3 +69,12 @@ func Close() error {
 	}
 	return nil
 }
+
+// Ping checks that the database answers
+func Ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
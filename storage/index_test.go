@@ -0,0 +1,99 @@
+package storage_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/storage"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// indexedQueries are the paste lookups and deletes that must use an index,
+// with the index they use
+var indexedQueries = []struct {
+	query string
+	index string
+}{
+	{"SELECT * FROM pastes WHERE uuid = '2b2c0c14-9d0a-4a51-8a5d-6a5a4e1c4f43'", "idx_pastes_uuid"},
+	{"DELETE FROM pastes WHERE expiry_timestamp < '2021-01-01 00:00:00'", "idx_pastes_expiry_timestamp"},
+	{"SELECT uuid FROM pastes WHERE expiry_timestamp < '2021-01-01 00:00:00'", "idx_pastes_expiry_timestamp"},
+}
+
+// explain returns the query plan of the query
+func explain(t *testing.T, db *gorm.DB, query string) string {
+	t.Helper()
+	prefix := "EXPLAIN QUERY PLAN "
+	if db.Dialector.Name() == "postgres" {
+		prefix = "EXPLAIN "
+	}
+	rows, err := db.Raw(prefix + query).Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var plan strings.Builder
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		for i := range values {
+			values[i] = new(interface{})
+		}
+		if err := rows.Scan(values...); err != nil {
+			t.Fatal(err)
+		}
+		for _, value := range values {
+			if s, ok := (*value.(*interface{})).(string); ok {
+				plan.WriteString(s + "\n")
+			}
+		}
+	}
+	return plan.String()
+}
+
+func checkQueryPlans(t *testing.T, db *gorm.DB) {
+	for _, q := range indexedQueries {
+		if plan := explain(t, db, q.query); !strings.Contains(plan, q.index) {
+			t.Errorf("expected %q to use %s, got plan:\n%s", q.query, q.index, plan)
+		}
+	}
+}
+
+func TestQueryPlansSQLite(t *testing.T) {
+	checkQueryPlans(t, openDB(t, "query_plans"))
+}
+
+// TestQueryPlansPostgres migrates the database of WASTEBIN_TEST_POSTGRES_DSN,
+// which should be a throwaway one
+func TestQueryPlansPostgres(t *testing.T) {
+	dsn := os.Getenv("WASTEBIN_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("WASTEBIN_TEST_POSTGRES_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	// The setting below only applies to the session it is made in
+	sqlDB.SetMaxOpenConns(1)
+	if err := storage.Migrate(db, log.Default()); err != nil {
+		t.Fatal(err)
+	}
+	// The planner prefers scanning the rows of small tables, so the scans
+	// are discouraged for the plans to show the indexes they can use
+	if err := db.Exec("SET enable_seqscan = off").Error; err != nil {
+		t.Fatal(err)
+	}
+	checkQueryPlans(t, db)
+}
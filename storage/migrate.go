@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/coolguy1771/wastebin/log"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// migrations holds the versioned SQL migrations of every supported database
+// in a directory named after the GORM dialect. Every migration is a pair of
+// NNNNNN_name.up.sql and NNNNNN_name.down.sql files.
+//
+//go:embed migrations
+var migrations embed.FS
+
+// migrationLockID is the Postgres advisory lock taken while migrating so that
+// replicas starting together apply every migration once
+const migrationLockID = 7_361_024
+
+// SchemaMigration records the version of the last applied migration
+type SchemaMigration struct {
+	Version uint `gorm:"primaryKey;autoIncrement:false"`
+}
+
+type migration struct {
+	version uint
+	name    string
+	up      string
+	down    string
+}
+
+// loadMigrations returns the migrations of dialect ordered by version
+func loadMigrations(dialect string) ([]migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrations, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for %s databases", dialect)
+	}
+
+	byVersion := make(map[uint]*migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		prefix, rest, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %s", name)
+		}
+		version, err := strconv.ParseUint(prefix, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s", name)
+		}
+		content, err := fs.ReadFile(migrations, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &migration{version: uint(version)}
+			byVersion[uint(version)] = m
+		}
+		switch {
+		case strings.HasSuffix(rest, ".up.sql"):
+			m.name = strings.TrimSuffix(rest, ".up.sql")
+			m.up = string(content)
+		case strings.HasSuffix(rest, ".down.sql"):
+			m.down = string(content)
+		default:
+			return nil, fmt.Errorf("invalid migration file name %s", name)
+		}
+	}
+
+	sorted := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %d needs an up and a down file", m.version)
+		}
+		sorted = append(sorted, *m)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].version < sorted[j].version })
+	return sorted, nil
+}
+
+// lockedVersion locks the schema for the rest of the transaction and returns its version
+func lockedVersion(tx *gorm.DB) (uint, error) {
+	if tx.Dialector.Name() == "postgres" {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	var current SchemaMigration
+	err := tx.Limit(1).Find(&current).Error
+	return current.Version, err
+}
+
+// setVersion replaces the recorded schema version
+func setVersion(tx *gorm.DB, version uint) error {
+	if err := tx.Where("1 = 1").Delete(&SchemaMigration{}).Error; err != nil {
+		return err
+	}
+	if version == 0 {
+		return nil
+	}
+	return tx.Create(&SchemaMigration{Version: version}).Error
+}
+
+// unprepared returns db without prepared statements. Migration files hold
+// several statements, which SQLite would cut to the first one once prepared
+// and which Postgres refuses to prepare.
+func unprepared(db *gorm.DB) *gorm.DB {
+	prepared, ok := db.Statement.ConnPool.(*gorm.PreparedStmtDB)
+	if !ok {
+		return db
+	}
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// A session with a context has its own statement to swap the pool of
+	db = db.WithContext(ctx)
+	db.Statement.ConnPool = prepared.ConnPool
+	return db
+}
+
+// Migrate applies the migrations the database is missing. Every migration
+// runs in a transaction with the update of the schema version.
+func Migrate(db *gorm.DB, logger *log.Logger) error {
+	logger.Info("Beginning database migration")
+	db = unprepared(db)
+	all, err := loadMigrations(db.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return err
+	}
+
+	for _, m := range all {
+		applied := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			current, err := lockedVersion(tx)
+			if err != nil || current >= m.version {
+				return err
+			}
+			if err := tx.Exec(m.up).Error; err != nil {
+				return err
+			}
+			applied = true
+			return setVersion(tx, m.version)
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d %s: %w", m.version, m.name, err)
+		}
+		if applied {
+			logger.Info("Applied database migration", zap.Uint("version", m.version), zap.String("name", m.name))
+		}
+	}
+
+	logger.Info("Database migration complete")
+	return nil
+}
+
+// MigrateDown reverts the last steps migrations
+func MigrateDown(db *gorm.DB, logger *log.Logger, steps int) error {
+	db = unprepared(db)
+	all, err := loadMigrations(db.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return err
+	}
+
+	for ; steps > 0; steps-- {
+		var reverted *migration
+		err := db.Transaction(func(tx *gorm.DB) error {
+			current, err := lockedVersion(tx)
+			if err != nil || current == 0 {
+				return err
+			}
+
+			i := sort.Search(len(all), func(i int) bool { return all[i].version >= current })
+			if i == len(all) || all[i].version != current {
+				return fmt.Errorf("unknown schema version %d", current)
+			}
+			if err := tx.Exec(all[i].down).Error; err != nil {
+				return err
+			}
+
+			var previous uint
+			if i > 0 {
+				previous = all[i-1].version
+			}
+			reverted = &all[i]
+			return setVersion(tx, previous)
+		})
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			logger.Info("Every database migration is reverted")
+			return nil
+		}
+		logger.Info("Reverted database migration", zap.Uint("version", reverted.version), zap.String("name", reverted.name))
+	}
+	return nil
+}
+
+// MigrationVersion returns the version of the last applied migration, 0 when
+// none were applied
+func MigrationVersion(db *gorm.DB) (uint, error) {
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return 0, nil
+	}
+	var current SchemaMigration
+	err := db.Limit(1).Find(&current).Error
+	return current.Version, err
+}
+
+// CheckMigrated fails unless every migration of the database is applied
+func CheckMigrated(db *gorm.DB) error {
+	all, err := loadMigrations(db.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	current, err := MigrationVersion(db)
+	if err != nil {
+		return err
+	}
+	if latest := all[len(all)-1].version; current != latest {
+		return fmt.Errorf("schema version %d, expected %d", current, latest)
+	}
+	return nil
+}
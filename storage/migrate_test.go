@@ -0,0 +1,90 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/storage"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMigrate(t *testing.T) {
+	db := openDB(t, "migrate")
+
+	version, err := storage.MigrationVersion(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 21 {
+		t.Fatalf("expected schema version 21, got %d", version)
+	}
+	if err := storage.CheckMigrated(db); err != nil {
+		t.Fatal(err)
+	}
+
+	// Migrating again is a no-op
+	if err := storage.Migrate(db, log.Default()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := storage.MigrateDown(db, log.Default(), 18); err != nil {
+		t.Fatal(err)
+	}
+	if version, _ := storage.MigrationVersion(db); version != 2 {
+		t.Fatalf("expected schema version 2 after reverting, got %d", version)
+	}
+	if err := storage.CheckMigrated(db); err == nil {
+		t.Fatal("expected the reverted schema to be reported")
+	}
+	if db.Migrator().HasTable(&models.QuotaUsage{}) {
+		t.Fatal("expected the quota table to be dropped")
+	}
+
+	if err := storage.MigrateDown(db, log.Default(), 20); err != nil {
+		t.Fatal(err)
+	}
+	if version, _ := storage.MigrationVersion(db); version != 0 || db.Migrator().HasTable(&models.Paste{}) {
+		t.Fatalf("expected every migration to be reverted, got version %d", version)
+	}
+
+	if err := storage.Migrate(db, log.Default()); err != nil {
+		t.Fatal(err)
+	}
+	if version, _ := storage.MigrationVersion(db); version != 21 {
+		t.Fatalf("expected schema version 21 after migrating again, got %d", version)
+	}
+}
+
+func TestMigratePrepared(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:migrate_prepared?mode=memory&cache=shared"), &gorm.Config{PrepareStmt: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Migrate(db, log.Default()); err != nil {
+		t.Fatal(err)
+	}
+	if version, _ := storage.MigrationVersion(db); version != 21 {
+		t.Fatalf("expected schema version 21, got %d", version)
+	}
+
+	// Every statement of the migrations holding several ran
+	for _, table := range []string{"paste_tags", "scan_findings", "user_settings"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("expected the table %s created", table)
+		}
+	}
+	for _, index := range [][2]string{{"pastes", "idx_pastes_created_at"}, {"paste_tags", "idx_paste_tags_tag_id"}, {"user_settings", "idx_user_settings_token_hash"}} {
+		if !db.Migrator().HasIndex(index[0], index[1]) {
+			t.Errorf("expected the index %s created", index[1])
+		}
+	}
+
+	if err := storage.MigrateDown(db, log.Default(), 20); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasTable("paste_tags") || db.Migrator().HasTable("pastes") {
+		t.Error("expected every statement of the down migrations run")
+	}
+}
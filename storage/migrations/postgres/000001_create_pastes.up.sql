@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS pastes (
+    content text,
+    burn boolean,
+    language text,
+    uuid uuid,
+    expiry_timestamp timestamptz,
+    created_at timestamptz
+);
+
+-- Databases created before pastes recorded their creation time
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS created_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_pastes_created_at ON pastes (created_at);
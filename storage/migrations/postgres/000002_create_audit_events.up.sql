@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+    id bigserial PRIMARY KEY,
+    "timestamp" timestamptz,
+    action text,
+    actor text,
+    target text
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_timestamp ON audit_events ("timestamp");
@@ -0,0 +1,2 @@
+ALTER TABLE pastes DROP COLUMN IF EXISTS description;
+ALTER TABLE pastes DROP COLUMN IF EXISTS title;
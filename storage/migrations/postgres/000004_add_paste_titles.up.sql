@@ -0,0 +1,2 @@
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS title text NOT NULL DEFAULT '';
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS description text NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE pastes DROP COLUMN IF EXISTS checksum;
+ALTER TABLE pastes DROP COLUMN IF EXISTS size;
@@ -0,0 +1,2 @@
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS size bigint NOT NULL DEFAULT 0;
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS checksum text NOT NULL DEFAULT '';
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS tags (
+    id bigserial PRIMARY KEY,
+    name text
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (name);
+
+CREATE TABLE IF NOT EXISTS paste_tags (
+    paste_uuid uuid,
+    tag_id bigint,
+    PRIMARY KEY (paste_uuid, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_paste_tags_tag_id ON paste_tags (tag_id);
@@ -0,0 +1,2 @@
+ALTER TABLE pastes DROP COLUMN IF EXISTS owner_token_hash;
+ALTER TABLE pastes DROP COLUMN IF EXISTS visibility;
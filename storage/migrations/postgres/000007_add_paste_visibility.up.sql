@@ -0,0 +1,2 @@
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS visibility text NOT NULL DEFAULT 'unlisted';
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS owner_token_hash text NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_pastes_parent_id;
+ALTER TABLE pastes DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,3 @@
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS parent_id uuid;
+
+CREATE INDEX IF NOT EXISTS idx_pastes_parent_id ON pastes (parent_id);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    client text,
+    key text,
+    request_hash text,
+    status bigint,
+    body text,
+    created_at timestamptz,
+    PRIMARY KEY (client, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys (created_at);
@@ -0,0 +1 @@
+ALTER TABLE pastes DROP COLUMN IF EXISTS views;
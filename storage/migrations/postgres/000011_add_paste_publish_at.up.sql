@@ -0,0 +1,3 @@
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS publish_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_pastes_publish_at ON pastes (publish_at);
@@ -0,0 +1,13 @@
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS quarantined boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS scan_findings (
+    id bigserial PRIMARY KEY,
+    paste_uuid uuid,
+    scanner text,
+    reason text,
+    action text,
+    created_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_scan_findings_paste_uuid ON scan_findings (paste_uuid);
+CREATE INDEX IF NOT EXISTS idx_scan_findings_created_at ON scan_findings (created_at);
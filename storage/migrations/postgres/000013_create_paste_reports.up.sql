@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS paste_reports (
+    id bigserial PRIMARY KEY,
+    paste_uuid uuid,
+    reporter text,
+    reason text,
+    created_at timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_paste_reports_paste_reporter ON paste_reports (paste_uuid, reporter);
+CREATE INDEX IF NOT EXISTS idx_paste_reports_reporter_created_at ON paste_reports (reporter, created_at);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS annotations (
+    id bigserial PRIMARY KEY,
+    paste_uuid uuid,
+    line integer,
+    note text,
+    created_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_annotations_paste_uuid ON annotations (paste_uuid);
@@ -0,0 +1,2 @@
+ALTER TABLE pastes DROP COLUMN IF EXISTS data;
+ALTER TABLE pastes DROP COLUMN IF EXISTS content_type;
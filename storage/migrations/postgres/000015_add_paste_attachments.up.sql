@@ -0,0 +1,2 @@
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS content_type text;
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS data bytea;
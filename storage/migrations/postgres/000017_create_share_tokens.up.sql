@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS share_tokens (
+    id bigserial PRIMARY KEY,
+    paste_uuid uuid,
+    token_hash text,
+    scope text,
+    expires_at timestamptz,
+    created_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_tokens_paste_uuid ON share_tokens (paste_uuid);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_share_tokens_token_hash ON share_tokens (token_hash);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id bigserial PRIMARY KEY,
+    type text,
+    paste_uuid uuid,
+    payload text,
+    created_at timestamptz,
+    attempts bigint DEFAULT 0,
+    next_attempt_at timestamptz,
+    delivered_at timestamptz,
+    last_error text
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_created_at ON outbox_events (created_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_next_attempt_at ON outbox_events (next_attempt_at) WHERE delivered_at IS NULL;
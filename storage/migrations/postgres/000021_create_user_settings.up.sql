@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS user_settings (
+    id bigserial PRIMARY KEY,
+    token_hash text,
+    expires bigint DEFAULT 0,
+    language text,
+    visibility text,
+    burn boolean DEFAULT false,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_settings_token_hash ON user_settings (token_hash);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `pastes` (
+    `content` text,
+    `burn` numeric,
+    `language` text,
+    `uuid` uuid,
+    `expiry_timestamp` datetime,
+    `created_at` datetime
+);
+
+CREATE INDEX IF NOT EXISTS `idx_pastes_created_at` ON `pastes` (`created_at`);
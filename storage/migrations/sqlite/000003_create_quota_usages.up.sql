@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `quota_usages` (
+    `client` text,
+    `period` text,
+    `period_start` datetime,
+    `pastes` integer,
+    `bytes` integer,
+    PRIMARY KEY (`client`, `period`, `period_start`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_quota_usages_period_start` ON `quota_usages` (`period_start`);
@@ -0,0 +1,2 @@
+ALTER TABLE `pastes` DROP COLUMN `description`;
+ALTER TABLE `pastes` DROP COLUMN `title`;
@@ -0,0 +1,2 @@
+ALTER TABLE `pastes` ADD COLUMN `title` text NOT NULL DEFAULT '';
+ALTER TABLE `pastes` ADD COLUMN `description` text NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE `pastes` DROP COLUMN `checksum`;
+ALTER TABLE `pastes` DROP COLUMN `size`;
@@ -0,0 +1,2 @@
+ALTER TABLE `pastes` ADD COLUMN `size` integer NOT NULL DEFAULT 0;
+ALTER TABLE `pastes` ADD COLUMN `checksum` text NOT NULL DEFAULT '';
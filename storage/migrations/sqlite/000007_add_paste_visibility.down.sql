@@ -0,0 +1,2 @@
+ALTER TABLE `pastes` DROP COLUMN `owner_token_hash`;
+ALTER TABLE `pastes` DROP COLUMN `visibility`;
@@ -0,0 +1,2 @@
+ALTER TABLE `pastes` ADD COLUMN `visibility` text NOT NULL DEFAULT 'unlisted';
+ALTER TABLE `pastes` ADD COLUMN `owner_token_hash` text NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE `pastes` ADD COLUMN `views` integer NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS `idx_pastes_publish_at`;
+ALTER TABLE `pastes` DROP COLUMN `publish_at`;
@@ -0,0 +1,3 @@
+ALTER TABLE `pastes` ADD COLUMN `publish_at` datetime;
+
+CREATE INDEX IF NOT EXISTS `idx_pastes_publish_at` ON `pastes` (`publish_at`);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `scan_findings`;
+ALTER TABLE `pastes` DROP COLUMN `quarantined`;
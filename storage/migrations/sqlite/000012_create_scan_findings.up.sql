@@ -0,0 +1,13 @@
+ALTER TABLE `pastes` ADD COLUMN `quarantined` numeric NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS `scan_findings` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `paste_uuid` uuid,
+    `scanner` text,
+    `reason` text,
+    `action` text,
+    `created_at` datetime
+);
+
+CREATE INDEX IF NOT EXISTS `idx_scan_findings_paste_uuid` ON `scan_findings` (`paste_uuid`);
+CREATE INDEX IF NOT EXISTS `idx_scan_findings_created_at` ON `scan_findings` (`created_at`);
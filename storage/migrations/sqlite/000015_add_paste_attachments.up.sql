@@ -0,0 +1,2 @@
+ALTER TABLE `pastes` ADD COLUMN `content_type` text;
+ALTER TABLE `pastes` ADD COLUMN `data` blob;
@@ -0,0 +1 @@
+ALTER TABLE `pastes` ADD COLUMN `thumbnail` blob;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS `idx_pastes_expiry_timestamp`;
+DROP INDEX IF EXISTS `idx_pastes_uuid`;
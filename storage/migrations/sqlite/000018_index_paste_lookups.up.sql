@@ -0,0 +1,2 @@
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_pastes_uuid` ON `pastes` (`uuid`);
+CREATE INDEX IF NOT EXISTS `idx_pastes_expiry_timestamp` ON `pastes` (`expiry_timestamp`);
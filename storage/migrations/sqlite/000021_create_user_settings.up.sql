@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS `user_settings` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `token_hash` text,
+    `expires` integer DEFAULT 0,
+    `language` text,
+    `visibility` text,
+    `burn` numeric DEFAULT false,
+    `created_at` datetime,
+    `updated_at` datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_user_settings_token_hash` ON `user_settings` (`token_hash`);
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"gorm.io/gorm"
+)
+
+// eventBatchSize is the number of outbox events inserted per statement
+const eventBatchSize = 500
+
+// newEvent returns the outbox event of a change of paste
+func newEvent(eventType string, paste *models.Paste, now time.Time) (models.OutboxEvent, error) {
+	payload, err := json.Marshal(paste.Meta())
+	if err != nil {
+		return models.OutboxEvent{}, err
+	}
+	return models.OutboxEvent{
+		Type:          eventType,
+		PasteUUID:     paste.UUID,
+		Payload:       string(payload),
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}, nil
+}
+
+// recordEvents stores the events of a change of pastes in the outbox. It
+// runs in the transaction making the change so that the events are kept
+// exactly when the change is.
+func recordEvents(tx *gorm.DB, eventType string, pastes ...*models.Paste) error {
+	if len(pastes) == 0 {
+		return nil
+	}
+	now := time.Now()
+	events := make([]models.OutboxEvent, 0, len(pastes))
+	for _, paste := range pastes {
+		event, err := newEvent(eventType, paste, now)
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+	}
+	return tx.CreateInBatches(events, eventBatchSize).Error
+}
+
+// PendingEvents returns up to limit undelivered events due by now, the
+// oldest first
+func PendingEvents(db *gorm.DB, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := db.Where("delivered_at IS NULL AND next_attempt_at <= ?", now).
+		Order("id").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// CountPendingEvents returns the number of undelivered events
+func CountPendingEvents(db *gorm.DB) (int64, error) {
+	var count int64
+	err := db.Model(&models.OutboxEvent{}).Where("delivered_at IS NULL").Count(&count).Error
+	return count, err
+}
+
+// ClaimEvent postpones the next attempt of event until the end of its
+// delivery, so that other dispatchers don't deliver it meanwhile. It reports
+// false when another dispatcher claimed it first. An event whose dispatcher
+// stopped before the end of the delivery is attempted again from until.
+func ClaimEvent(db *gorm.DB, event *models.OutboxEvent, until time.Time) (bool, error) {
+	result := db.Model(&models.OutboxEvent{}).
+		Where("id = ? AND next_attempt_at = ? AND delivered_at IS NULL", event.ID, event.NextAttemptAt).
+		UpdateColumn("next_attempt_at", until)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	event.NextAttemptAt = until
+	return result.RowsAffected == 1, nil
+}
+
+// MarkEventDelivered records that an event was delivered
+func MarkEventDelivered(db *gorm.DB, id uint, at time.Time) error {
+	return db.Model(&models.OutboxEvent{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"delivered_at": at, "last_error": ""}).Error
+}
+
+// MarkEventFailed records a failed delivery of an event, attempted again at
+// next
+func MarkEventFailed(db *gorm.DB, id uint, next time.Time, reason string) error {
+	return db.Model(&models.OutboxEvent{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"attempts":        gorm.Expr("attempts + ?", 1),
+			"next_attempt_at": next,
+			"last_error":      reason,
+		}).Error
+}
+
+// PruneEvents deletes the events created before before, delivered or not,
+// and returns how many were deleted
+func PruneEvents(db *gorm.DB, before time.Time) (int64, error) {
+	result := db.Where("created_at < ?", before).Delete(&models.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
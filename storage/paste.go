@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreatePaste stores a paste with its tags and its creation event
+func CreatePaste(db *gorm.DB, paste *models.Paste) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(paste).Error; err != nil {
+			return err
+		}
+		if err := setTags(tx, paste.UUID, paste.Tags); err != nil {
+			return err
+		}
+		return recordEvents(tx, models.EventPasteCreated, paste)
+	})
+}
+
+// BatchError is the error of a paste of a batch that could not be stored
+type BatchError struct {
+	// Index is the position of the paste in the pastes stored
+	Index int
+	UUID  uuid.UUID
+	Err   error
+}
+
+func (e BatchError) Error() string {
+	return fmt.Sprintf("paste %d (%s): %v", e.Index, e.UUID, e.Err)
+}
+
+func (e BatchError) Unwrap() error {
+	return e.Err
+}
+
+// CreatePastesBatch stores the pastes with their tags, inserting batchSize
+// pastes per statement. When a batch fails its pastes are stored one by one
+// instead, so that a single invalid paste doesn't fail the others, and the
+// errors of the pastes that could not be stored are returned.
+func CreatePastesBatch(db *gorm.DB, pastes []*models.Paste, batchSize int) []BatchError {
+	var failed []BatchError
+	for start := 0; start < len(pastes); start += batchSize {
+		end := start + batchSize
+		if end > len(pastes) {
+			end = len(pastes)
+		}
+		batch := pastes[start:end]
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.CreateInBatches(batch, batchSize).Error; err != nil {
+				return err
+			}
+			for _, paste := range batch {
+				if err := setTags(tx, paste.UUID, paste.Tags); err != nil {
+					return err
+				}
+			}
+			return recordEvents(tx, models.EventPasteCreated, batch...)
+		})
+		if err == nil {
+			continue
+		}
+		for i, paste := range batch {
+			if err := CreatePaste(db, paste); err != nil {
+				failed = append(failed, BatchError{Index: start + i, UUID: paste.UUID, Err: err})
+			}
+		}
+	}
+	return failed
+}
+
+// DeletePaste deletes a paste with its tags, records event, such as
+// models.EventPasteBurned, and returns how many pastes were deleted
+func DeletePaste(db *gorm.DB, id uuid.UUID, event string) (int64, error) {
+	var deleted int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var paste models.Paste
+		result := tx.Omit("content", "data", "thumbnail").Limit(1).Find(&paste, "uuid = ?", id)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		if err := tx.Where("paste_uuid = ?", id).Delete(&models.PasteTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("paste_uuid = ?", id).Delete(&models.ScanFinding{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("paste_uuid = ?", id).Delete(&models.PasteReport{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("paste_uuid = ?", id).Delete(&models.Annotation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("paste_uuid = ?", id).Delete(&models.ShareToken{}).Error; err != nil {
+			return err
+		}
+		result = tx.Where("uuid = ?", id).Delete(&models.Paste{})
+		if result.Error != nil {
+			return result.Error
+		}
+		// A concurrent delete got to the paste first and records the event
+		if deleted = result.RowsAffected; deleted == 0 {
+			return nil
+		}
+		return recordEvents(tx, event, &paste)
+	})
+	return deleted, err
+}
+
+// CountView adds a view to a paste
+func CountView(db *gorm.DB, id uuid.UUID) error {
+	return db.Model(&models.Paste{}).Where("uuid = ?", id).UpdateColumn("views", gorm.Expr("views + ?", 1)).Error
+}
+
+// ExtendPaste moves the expiry of a paste to expiry
+func ExtendPaste(db *gorm.DB, id uuid.UUID, expiry time.Time) error {
+	result := db.Model(&models.Paste{}).Where("uuid = ?", id).UpdateColumn("expiry_timestamp", expiry)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package storage_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestCreatePastesBatch(t *testing.T) {
+	db := openDB(t, "create_batch")
+	existing := models.Paste{UUID: uuid.New(), Content: "Existing", ExpiryTimestamp: time.Now().Add(time.Hour)}
+	if err := storage.CreatePaste(db, &existing); err != nil {
+		t.Fatal(err)
+	}
+
+	var pastes []*models.Paste
+	for i := 0; i < 5; i++ {
+		pastes = append(pastes, &models.Paste{UUID: uuid.New(), Content: fmt.Sprint("Paste ", i), Tags: []string{"batch"}, ExpiryTimestamp: time.Now().Add(time.Hour)})
+	}
+	// The paste taking an existing UUID fails its batch without failing
+	// the other pastes
+	pastes[3].UUID = existing.UUID
+
+	failed := storage.CreatePastesBatch(db, pastes, 2)
+	if len(failed) != 1 || failed[0].Index != 3 || failed[0].UUID != existing.UUID {
+		t.Fatalf("expected paste 3 to fail, got %v", failed)
+	}
+	for i, paste := range pastes {
+		if i == 3 {
+			continue
+		}
+		tags, err := storage.PasteTags(db, paste.UUID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tags) != 1 || tags[0] != "batch" {
+			t.Errorf("expected paste %d to be stored with its tags, got %v", i, tags)
+		}
+	}
+}
+
+// BenchmarkPasteQueries compares the hot paste queries with and without
+// prepared statements, see DB_PREPARE_STATEMENTS
+func BenchmarkPasteQueries(b *testing.B) {
+	for _, prepare := range []bool{false, true} {
+		db := openDB(b, fmt.Sprintf("bench_prepare_%v", prepare)).Session(&gorm.Session{PrepareStmt: prepare})
+		var created []uuid.UUID
+
+		b.Run(fmt.Sprintf("prepared=%v/create", prepare), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				paste := models.Paste{UUID: uuid.New(), Content: "benchmark", ExpiryTimestamp: time.Now().Add(time.Hour)}
+				if err := storage.CreatePaste(db, &paste); err != nil {
+					b.Fatal(err)
+				}
+				created = append(created, paste.UUID)
+			}
+		})
+		b.Run(fmt.Sprintf("prepared=%v/get", prepare), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var paste models.Paste
+				if err := db.First(&paste, "uuid = ?", created[i%len(created)]).Error; err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("prepared=%v/delete", prepare), func(b *testing.B) {
+			// Pastes already deleted cost the same queries
+			for i := 0; i < b.N; i++ {
+				if _, err := storage.DeletePaste(db, created[i%len(created)], models.EventPasteDeleted); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
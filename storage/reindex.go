@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"github.com/coolguy1771/wastebin/models"
+	"gorm.io/gorm"
+)
+
+// Reindex recomputes the derived fields of every paste in batches of
+// batchSize. progress is called after each batch with the number of pastes
+// done and the total.
+func Reindex(db *gorm.DB, batchSize int, progress func(done, total int64)) (int64, error) {
+	var total int64
+	if err := db.Model(&models.Paste{}).Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	// Pastes have no primary key so page through them by UUID
+	var (
+		done int64
+		last string
+	)
+	for {
+		var batch []models.Paste
+		query := db.Order("uuid").Limit(batchSize)
+		if last != "" {
+			query = query.Where("uuid > ?", last)
+		}
+		if err := query.Find(&batch).Error; err != nil {
+			return done, err
+		}
+		if len(batch) == 0 {
+			return done, nil
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, paste := range batch {
+				paste.Derive()
+				err := tx.Model(&models.Paste{}).Where("uuid = ?", paste.UUID).Updates(map[string]interface{}{
+					"size":      paste.Size,
+					"checksum":  paste.Checksum,
+					"thumbnail": paste.Thumbnail,
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return done, err
+		}
+
+		done += int64(len(batch))
+		last = batch[len(batch)-1].UUID.String()
+		if progress != nil {
+			progress(done, total)
+		}
+	}
+}
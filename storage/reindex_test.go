@@ -0,0 +1,46 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/google/uuid"
+)
+
+func TestReindex(t *testing.T) {
+	db := openDB(t, "reindex")
+	for _, content := range []string{"Paste A", "Paste B", "Paste C"} {
+		paste := models.Paste{Content: content, UUID: uuid.New(), ExpiryTimestamp: time.Now().Add(time.Hour)}
+		if err := db.Create(&paste).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var batches int
+	reindexed, err := storage.Reindex(db, 2, func(done, total int64) {
+		batches++
+		if total != 3 {
+			t.Errorf("expected a total of 3 pastes, got %d", total)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reindexed != 3 || batches != 2 {
+		t.Fatalf("expected 3 pastes reindexed in 2 batches, got %d in %d", reindexed, batches)
+	}
+
+	var pastes []models.Paste
+	if err := db.Find(&pastes).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, paste := range pastes {
+		want := paste
+		want.Derive()
+		if paste.Size != 7 || paste.Checksum != want.Checksum {
+			t.Errorf("unexpected derived fields %d %q", paste.Size, paste.Checksum)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"errors"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrAlreadyReported is returned when a client reports a paste again
+var ErrAlreadyReported = errors.New("paste already reported")
+
+// ReportPaste records a report and returns how many reports the paste has
+func ReportPaste(db *gorm.DB, report *models.PasteReport) (int64, error) {
+	var reports int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&models.PasteReport{}).Where("paste_uuid = ? AND reporter = ?", report.PasteUUID, report.Reporter).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrAlreadyReported
+		}
+		if err := tx.Create(report).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.PasteReport{}).Where("paste_uuid = ?", report.PasteUUID).Count(&reports).Error
+	})
+	return reports, err
+}
+
+// CountReportsBy returns how many reports the client made since a time
+func CountReportsBy(db *gorm.DB, reporter string, since time.Time) (int64, error) {
+	var reports int64
+	err := db.Model(&models.PasteReport{}).Where("reporter = ? AND created_at >= ?", reporter, since).Count(&reports).Error
+	return reports, err
+}
+
+// ListReports returns up to limit reports waiting for moderation, the newest first
+func ListReports(db *gorm.DB, limit int) ([]models.PasteReport, error) {
+	reports := []models.PasteReport{}
+	err := db.Order("created_at DESC, id DESC").Limit(limit).Find(&reports).Error
+	return reports, err
+}
+
+// QuarantinePaste hides a paste from everyone but the admin until it is released
+func QuarantinePaste(db *gorm.DB, id uuid.UUID) error {
+	return db.Model(&models.Paste{}).Where("uuid = ?", id).Update("quarantined", true).Error
+}
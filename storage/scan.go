@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AddFindings records why pastes were quarantined or flagged for review
+func AddFindings(db *gorm.DB, findings []models.ScanFinding) error {
+	if len(findings) == 0 {
+		return nil
+	}
+	return db.Create(&findings).Error
+}
+
+// ListFindings returns up to limit findings waiting for review, the newest first
+func ListFindings(db *gorm.DB, limit int) ([]models.ScanFinding, error) {
+	findings := []models.ScanFinding{}
+	err := db.Order("created_at DESC, id DESC").Limit(limit).Find(&findings).Error
+	return findings, err
+}
+
+// ReleasePaste marks a paste as reviewed, lifting its quarantine and deleting
+// its findings and reports. It returns how many pastes were released.
+func ReleasePaste(db *gorm.DB, id uuid.UUID) (int64, error) {
+	var released int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Paste{}).Where("uuid = ?", id).Update("quarantined", false)
+		if result.Error != nil {
+			return result.Error
+		}
+		released = result.RowsAffected
+		if err := tx.Where("paste_uuid = ?", id).Delete(&models.ScanFinding{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("paste_uuid = ?", id).Delete(&models.PasteReport{}).Error
+	})
+	return released, err
+}
@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"github.com/coolguy1771/wastebin/models"
+	"gorm.io/gorm"
+)
+
+// UserSettingsByToken returns the settings of token, gorm.ErrRecordNotFound
+// when there are none
+func UserSettingsByToken(db *gorm.DB, token string) (models.UserSettings, error) {
+	var settings models.UserSettings
+	err := db.First(&settings, "token_hash = ?", models.HashToken(token)).Error
+	return settings, err
+}
+
+// SaveUserSettings creates settings, or replaces them when they exist
+func SaveUserSettings(db *gorm.DB, settings *models.UserSettings) error {
+	return db.Save(settings).Error
+}
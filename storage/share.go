@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"errors"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrTooManyShareTokens is returned when a paste has no room for another share token
+var ErrTooManyShareTokens = errors.New("too many share tokens")
+
+// AddShareToken records a share token unless its paste already has max
+// share tokens that haven't expired at now
+func AddShareToken(db *gorm.DB, token *models.ShareToken, max int, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var tokens int64
+		err := tx.Model(&models.ShareToken{}).
+			Where("paste_uuid = ? AND (expires_at IS NULL OR expires_at > ?)", token.PasteUUID, now).
+			Count(&tokens).Error
+		if err != nil {
+			return err
+		}
+		if tokens >= int64(max) {
+			return ErrTooManyShareTokens
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// SharedWith reports whether token is a share token of the paste that
+// hasn't expired at now
+func SharedWith(db *gorm.DB, id uuid.UUID, token string, now time.Time) (bool, error) {
+	var tokens int64
+	err := db.Model(&models.ShareToken{}).
+		Where("paste_uuid = ? AND token_hash = ? AND scope = ?", id, models.HashToken(token), models.ScopeRead).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Count(&tokens).Error
+	return tokens > 0, err
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"strings"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"gorm.io/gorm"
+)
+
+// DailyCount is the number of pastes created on a day
+type DailyCount struct {
+	Day    string `json:"day"`
+	Pastes int64  `json:"pastes"`
+}
+
+// Usage describes the pastes currently stored
+type Usage struct {
+	Pastes     int64        `json:"pastes"`
+	Characters int64        `json:"characters"`
+	Growth     []DailyCount `json:"growth"`
+}
+
+// GetUsage aggregates the stored pastes and the number created per day since
+func GetUsage(db *gorm.DB, since time.Time) (Usage, error) {
+	var usage Usage
+
+	row := db.Model(&models.Paste{}).
+		Select("COUNT(*), COALESCE(SUM(LENGTH(content)), 0)").
+		Row()
+	if err := row.Scan(&usage.Pastes, &usage.Characters); err != nil {
+		return usage, err
+	}
+
+	var err error
+	usage.Growth, err = dailyCreations(db, since)
+	return usage, err
+}
+
+// dailyCreations returns the number of pastes created per day since
+func dailyCreations(db *gorm.DB, since time.Time) ([]DailyCount, error) {
+	var days []DailyCount
+	err := db.Model(&models.Paste{}).
+		Select("DATE(created_at) AS day, COUNT(*) AS pastes").
+		Where("created_at >= ?", since).
+		Group("DATE(created_at)").
+		Order("day").
+		Scan(&days).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Postgres returns the day as a timestamp
+	for i := range days {
+		if len(days[i].Day) > len("2006-01-02") {
+			days[i].Day = days[i].Day[:len("2006-01-02")]
+		}
+	}
+	return days, nil
+}
+
+// CountPastes returns the number of stored pastes and of those created since
+func CountPastes(db *gorm.DB, since time.Time) (int64, int64, error) {
+	var total, created int64
+	row := db.Model(&models.Paste{}).
+		Select("COUNT(*), COALESCE(SUM(CASE WHEN created_at >= ? THEN 1 ELSE 0 END), 0)", since).
+		Row()
+	err := row.Scan(&total, &created)
+	return total, created, err
+}
+
+// LanguageCount is the number of pastes in a language, empty for the pastes
+// without one
+type LanguageCount struct {
+	Language string `json:"language"`
+	Pastes   int64  `json:"pastes"`
+}
+
+// ExpiryCount is the number of pastes expiring within a period from now, and
+// not within the shorter periods
+type ExpiryCount struct {
+	Within string `json:"within"`
+	Pastes int64  `json:"pastes"`
+}
+
+// expiryBuckets are the periods the expiry of the pastes is distributed over,
+// the pastes expiring later being counted as "later"
+var expiryBuckets = []struct {
+	name   string
+	within time.Duration
+}{
+	{"1h", time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+	{"365d", 365 * 24 * time.Hour},
+}
+
+// PasteStats describes the stored pastes
+type PasteStats struct {
+	Pastes    int64           `json:"pastes"`
+	Bytes     int64           `json:"bytes"`
+	Languages []LanguageCount `json:"languages"`
+	Creations []DailyCount    `json:"creations"`
+	Expiry    []ExpiryCount   `json:"expiry"`
+}
+
+// GetPasteStats aggregates the stored pastes: their number and size, the
+// pastes per language, the pastes created per day since and how soon the
+// pastes expire after now. The pastes that expired but weren't deleted yet
+// are counted as "expired".
+func GetPasteStats(db *gorm.DB, since, now time.Time) (PasteStats, error) {
+	var stats PasteStats
+
+	// Count every expiry bucket in the same pass as the totals
+	columns := []string{"COUNT(*)", "COALESCE(SUM(size), 0)", "COALESCE(SUM(CASE WHEN expiry_timestamp <= ? THEN 1 ELSE 0 END), 0)"}
+	args := []interface{}{now}
+	for _, bucket := range expiryBuckets {
+		columns = append(columns, "COALESCE(SUM(CASE WHEN expiry_timestamp <= ? THEN 1 ELSE 0 END), 0)")
+		args = append(args, now.Add(bucket.within))
+	}
+	counts := make([]int64, len(expiryBuckets)+1)
+	dest := []interface{}{&stats.Pastes, &stats.Bytes}
+	for i := range counts {
+		dest = append(dest, &counts[i])
+	}
+	row := db.Model(&models.Paste{}).Select(strings.Join(columns, ", "), args...).Row()
+	if err := row.Scan(dest...); err != nil {
+		return stats, err
+	}
+	// The buckets are cumulative, keep what each period adds
+	stats.Expiry = append(stats.Expiry, ExpiryCount{Within: "expired", Pastes: counts[0]})
+	for i, bucket := range expiryBuckets {
+		stats.Expiry = append(stats.Expiry, ExpiryCount{Within: bucket.name, Pastes: counts[i+1] - counts[i]})
+	}
+	stats.Expiry = append(stats.Expiry, ExpiryCount{Within: "later", Pastes: stats.Pastes - counts[len(counts)-1]})
+
+	stats.Languages = []LanguageCount{}
+	err := db.Model(&models.Paste{}).
+		Select("COALESCE(language, '') AS language, COUNT(*) AS pastes").
+		Group("COALESCE(language, '')").
+		Order("pastes DESC, language").
+		Scan(&stats.Languages).Error
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Creations, err = dailyCreations(db, since)
+	return stats, err
+}
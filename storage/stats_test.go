@@ -0,0 +1,52 @@
+package storage_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/google/uuid"
+)
+
+func TestGetPasteStats(t *testing.T) {
+	db := openDB(t, "paste_stats")
+	now := time.Now()
+	for _, p := range []struct {
+		language string
+		content  string
+		expiry   time.Duration
+	}{
+		{"go", "package main", -time.Minute},
+		{"go", "package stats", 30 * time.Minute},
+		{"python", "print(1)", 2 * time.Hour},
+		{"", "notes", 10 * 24 * time.Hour},
+		{"go", "package later", 2 * 365 * 24 * time.Hour},
+	} {
+		paste := models.Paste{UUID: uuid.New(), Language: p.language, Content: p.content, ExpiryTimestamp: now.Add(p.expiry)}
+		paste.Derive()
+		if err := db.Create(&paste).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := storage.GetPasteStats(db, now.AddDate(0, 0, -30), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Pastes != 5 || stats.Bytes != 51 {
+		t.Errorf("expected 5 pastes of 51 bytes, got %d of %d", stats.Pastes, stats.Bytes)
+	}
+	languages := []storage.LanguageCount{{"go", 3}, {"", 1}, {"python", 1}}
+	if !reflect.DeepEqual(stats.Languages, languages) {
+		t.Errorf("expected languages %v, got %v", languages, stats.Languages)
+	}
+	expiry := []storage.ExpiryCount{{"expired", 1}, {"1h", 1}, {"24h", 1}, {"7d", 0}, {"30d", 1}, {"365d", 0}, {"later", 1}}
+	if !reflect.DeepEqual(stats.Expiry, expiry) {
+		t.Errorf("expected expiry %v, got %v", expiry, stats.Expiry)
+	}
+	if len(stats.Creations) != 1 || stats.Creations[0].Pastes != 5 {
+		t.Errorf("expected the pastes created today, got %v", stats.Creations)
+	}
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Number of pastes listed when the request doesn't set a limit, and the most it may ask for
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 100
+)
+
+// setTags links a paste to the named tags, creating the missing ones
+func setTags(tx *gorm.DB, id uuid.UUID, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	tags := make([]models.Tag, len(names))
+	for i, name := range names {
+		tags[i].Name = name
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+		return err
+	}
+	// Tags that already existed were skipped so read every ID back
+	tags = nil
+	if err := tx.Where("name IN ?", names).Find(&tags).Error; err != nil {
+		return err
+	}
+
+	links := make([]models.PasteTag, len(tags))
+	for i, tag := range tags {
+		links[i] = models.PasteTag{PasteUUID: id, TagID: tag.ID}
+	}
+	return tx.Create(&links).Error
+}
+
+// PasteTags returns the names of the tags of a paste in alphabetical order
+func PasteTags(db *gorm.DB, id uuid.UUID) ([]string, error) {
+	names := []string{}
+	err := db.Model(&models.Tag{}).
+		Joins("JOIN paste_tags ON paste_tags.tag_id = tags.id").
+		Where("paste_tags.paste_uuid = ?", id).
+		Order("tags.name").
+		Pluck("tags.name", &names).Error
+	return names, err
+}
+
+// listed restricts a query on pastes to the ones that may be listed: public
+// pastes, except burn after reading ones which are only for whoever has the
+// link, quarantined ones and embargoed ones which aren't published yet
+func listed(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Where("pastes.visibility = ? AND pastes.expiry_timestamp > ? AND pastes.burn = ? AND pastes.quarantined = ?", models.VisibilityPublic, now, false, false).
+		Where("pastes.publish_at IS NULL OR pastes.publish_at <= ?", now)
+}
+
+// ListTags returns the tags of the listed pastes with how many use each,
+// the most used first
+func ListTags(db *gorm.DB, now time.Time) ([]models.TagCount, error) {
+	counts := []models.TagCount{}
+	err := listed(db.Model(&models.Tag{}), now).
+		Select("tags.name AS name, COUNT(*) AS count").
+		Joins("JOIN paste_tags ON paste_tags.tag_id = tags.id").
+		Joins("JOIN pastes ON pastes.uuid = paste_tags.paste_uuid").
+		Group("tags.name").
+		Order("count DESC, tags.name").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// ListPastesByTag returns up to limit listed pastes tagged with tag, the
+// newest first
+func ListPastesByTag(db *gorm.DB, tag string, now time.Time, limit int) ([]models.PasteSummary, error) {
+	pastes := []models.PasteSummary{}
+	err := listed(db.Model(&models.Paste{}), now).
+		Select("pastes.uuid, pastes.language, pastes.title, pastes.description, pastes.size, pastes.parent_id, pastes.expiry_timestamp, pastes.created_at, pastes.content_type, pastes.thumbnail IS NOT NULL AS has_thumbnail").
+		Joins("JOIN paste_tags ON paste_tags.paste_uuid = pastes.uuid").
+		Joins("JOIN tags ON tags.id = paste_tags.tag_id").
+		Where("tags.name = ?", tag).
+		Order("pastes.created_at DESC").
+		Limit(limit).
+		Scan(&pastes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pastes {
+		if pastes[i].Tags, err = PasteTags(db, pastes[i].UUID); err != nil {
+			return nil, err
+		}
+	}
+	return pastes, nil
+}
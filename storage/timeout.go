@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	queryTimeoutContext = "storage:query_timeout_context"
+	queryTimeoutCancel  = "storage:query_timeout_cancel"
+)
+
+// RegisterQueryTimeout bounds every query of db by timeout. The deadline is
+// derived from the context the query runs with, so queries bound to a
+// request stop at the earlier of the request deadline and the timeout, and
+// the others at the timeout. It does nothing when timeout is 0.
+//
+// Queries read with Row, Rows or Scan aren't bounded since their rows are
+// read after the query returns, such as the pastes streamed by an export.
+func RegisterQueryTimeout(db *gorm.DB, timeout time.Duration) error {
+	if timeout <= 0 {
+		return nil
+	}
+	before := func(tx *gorm.DB) {
+		ctx := tx.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+			return
+		}
+		bounded, cancel := context.WithTimeout(ctx, timeout)
+		tx.InstanceSet(queryTimeoutContext, tx.Statement.Context)
+		tx.InstanceSet(queryTimeoutCancel, cancel)
+		tx.Statement.Context = bounded
+	}
+	// after restores the context of the statement, which the query may be
+	// chained from again
+	after := func(tx *gorm.DB) {
+		value, _ := tx.InstanceGet(queryTimeoutCancel)
+		cancel, ok := value.(context.CancelFunc)
+		if !ok {
+			return
+		}
+		cancel()
+		ctx, _ := tx.InstanceGet(queryTimeoutContext)
+		tx.Statement.Context, _ = ctx.(context.Context)
+		tx.InstanceSet(queryTimeoutCancel, nil)
+	}
+
+	c := db.Callback()
+	for _, err := range []error{
+		c.Create().Before("*").Register("storage:timeout_before_create", before),
+		c.Create().After("*").Register("storage:timeout_after_create", after),
+		c.Query().Before("*").Register("storage:timeout_before_query", before),
+		c.Query().After("*").Register("storage:timeout_after_query", after),
+		c.Update().Before("*").Register("storage:timeout_before_update", before),
+		c.Update().After("*").Register("storage:timeout_after_update", after),
+		c.Delete().Before("*").Register("storage:timeout_before_delete", before),
+		c.Delete().After("*").Register("storage:timeout_after_delete", after),
+		c.Raw().Before("*").Register("storage:timeout_before_raw", before),
+		c.Raw().After("*").Register("storage:timeout_after_raw", after),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
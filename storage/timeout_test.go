@@ -0,0 +1,42 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/storage"
+)
+
+func TestRegisterQueryTimeout(t *testing.T) {
+	db := openDB(t, "query_timeout")
+	if err := storage.RegisterQueryTimeout(db, 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	slow := "WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n WHERE x < 100000000) SELECT count(*) FROM n"
+	var count int64
+	start := time.Now()
+	err := db.Raw(slow).Find(&count).Error
+	if err == nil || time.Since(start) > 5*time.Second {
+		t.Fatalf("expected the slow query canceled at the timeout, got %v after %s", err, time.Since(start))
+	}
+
+	// The statement keeps its own context for the queries chained from it
+	query := db.Model(&models.Paste{})
+	for i := 0; i < 2; i++ {
+		if err := query.Count(&count).Error; err != nil {
+			t.Fatalf("unexpected error of query %d: %v", i, err)
+		}
+	}
+
+	// Earlier deadlines of the context are kept
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	if err := db.WithContext(ctx).Model(&models.Paste{}).Count(&count).Error; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline of the context, got %v", err)
+	}
+}
@@ -0,0 +1,360 @@
+// Package tcpupload creates pastes from the data sent over plain TCP
+// connections, like termbin, so that `cat file | nc host 9999` answers with
+// the URL of a new paste. It shares the storage, limits, quotas, IP filters,
+// abuse detection and content scanners of the HTTP API.
+package tcpupload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coolguy1771/wastebin/abuse"
+	"github.com/coolguy1771/wastebin/audit"
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/handlers"
+	"github.com/coolguy1771/wastebin/ipfilter"
+	"github.com/coolguy1771/wastebin/language"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/quota"
+	"github.com/coolguy1771/wastebin/scan"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// maxUploadTime bounds the time a client may take to send a paste, so slow
+// clients can't hold connections forever
+const maxUploadTime = time.Minute
+
+// maxConnections is the number of uploads handled at once, the connections
+// beyond are refused
+const maxConnections = 100
+
+// ErrServerClosed is returned by Serve once the server is closed
+var ErrServerClosed = errors.New("tcpupload: server closed")
+
+// Server creates a paste from every connection
+type Server struct {
+	config  *config.Config
+	logger  *log.Logger
+	db      *gorm.DB
+	quota   *quota.Quota
+	scanner scan.Scanner
+	filter  *ipfilter.Filter
+	abuse   *abuse.Detector
+
+	slots   chan struct{}
+	conns   sync.WaitGroup
+	closing chan struct{}
+
+	mu        sync.Mutex
+	closed    bool
+	listeners map[net.Listener]struct{}
+}
+
+// New creates a Server reserving the pastes on quotas, shared with the HTTP
+// API. The content of new pastes is checked with scanner unless it is nil.
+func New(conf *config.Config, logger *log.Logger, db *gorm.DB, quotas *quota.Quota, scanner scan.Scanner) *Server {
+	return &Server{
+		config:    conf,
+		logger:    logger,
+		db:        db,
+		quota:     quotas,
+		scanner:   scanner,
+		slots:     make(chan struct{}, maxConnections),
+		closing:   make(chan struct{}),
+		listeners: make(map[net.Listener]struct{}),
+	}
+}
+
+// SetFilter refuses the connections of the clients filter doesn't allow
+func (s *Server) SetFilter(filter *ipfilter.Filter) {
+	s.filter = filter
+}
+
+// SetAbuseDetector scores the clients uploading pastes with detector, shared
+// with the HTTP API, to penalize the abusive ones
+func (s *Server) SetAbuseDetector(detector *abuse.Detector) {
+	s.abuse = detector
+}
+
+// Serve accepts connections on the listener until the server is closed. It
+// always returns a non-nil error, ErrServerClosed after Close.
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		return ErrServerClosed
+	}
+	s.listeners[listener] = struct{}{}
+	s.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			delete(s.listeners, listener)
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		select {
+		case s.slots <- struct{}{}:
+		default:
+			conn.Write([]byte("Too many uploads, try again later\n"))
+			conn.Close()
+			continue
+		}
+		s.conns.Add(1)
+		go func() {
+			defer func() {
+				<-s.slots
+				s.conns.Done()
+			}()
+			s.handle(conn)
+		}()
+	}
+}
+
+// Close stops accepting connections and waits for the uploads in progress
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.closing)
+	}
+	for listener := range s.listeners {
+		listener.Close()
+	}
+	s.mu.Unlock()
+	s.conns.Wait()
+	return nil
+}
+
+// handle reads a paste from the connection and answers with its URL, or with
+// why it couldn't be created
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	client := clientIP(conn)
+	if s.filter != nil && !s.filter.Allowed(net.ParseIP(client)) {
+		s.logger.Warn("Blocked TCP upload from IP", zap.String("ip", client))
+		s.recordAudit(client, audit.ActionRequestBlocked, "TCP upload")
+		fmt.Fprintln(conn, "Forbidden")
+		return
+	}
+
+	content, err := s.read(conn)
+	if err == nil {
+		var url string
+		if url, err = s.create(client, content); err == nil {
+			fmt.Fprintln(conn, url)
+			return
+		}
+	}
+	var refused *refusedError
+	if !errors.As(err, &refused) {
+		s.logger.Error("Error creating paste uploaded over TCP", zap.String("client", client), zap.Error(err))
+		err = &refusedError{"Error creating paste"}
+	}
+	fmt.Fprintln(conn, err)
+}
+
+// refusedError tells the client why its paste wasn't created
+type refusedError struct {
+	message string
+}
+
+func (e *refusedError) Error() string {
+	return e.message
+}
+
+// read returns the data sent on the connection. The upload ends when the
+// client closes its side or stops sending for TCP_UPLOAD_TIMEOUT, since not
+// every netcat closes it.
+func (s *Server) read(conn net.Conn) (string, error) {
+	var content bytes.Buffer
+	limit := int64(handlers.Limits(s.config).MaxSize)
+	r := &idleReader{conn: conn, idle: s.config.TCPUploadTimeout, deadline: time.Now().Add(maxUploadTime)}
+	_, err := io.Copy(&content, io.LimitReader(r, limit+1))
+
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout():
+		if !time.Now().Before(r.deadline) {
+			return "", &refusedError{fmt.Sprintf("Upload took longer than %s", maxUploadTime)}
+		}
+	case err != nil:
+		return "", err
+	}
+	if int64(content.Len()) > limit {
+		return "", &refusedError{fmt.Sprintf("Content cannot be larger than %d bytes", limit)}
+	}
+	if content.Len() == 0 {
+		return "", &refusedError{"Content cannot be empty"}
+	}
+	return content.String(), nil
+}
+
+// create stores the content as a new unlisted paste and returns its URL
+func (s *Server) create(client, content string) (string, error) {
+	if s.abuse != nil && s.abusive(client, content) {
+		return s.penalize()
+	}
+
+	var lang string
+	if s.config.DetectLanguage {
+		if detected := language.Detect(content); handlers.Limits(s.config).AllowsLanguage(detected) {
+			lang = detected
+		}
+	}
+
+	// Check the content for abuse like the HTTP API does
+	var findings []scan.Finding
+	if s.scanner != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.ScanTimeout)
+		var err error
+		findings, err = s.scanner.Scan(ctx, content)
+		cancel()
+		if err != nil {
+			s.logger.Error("Error scanning paste content", zap.Error(err))
+		}
+	}
+	action := scan.Strictest(findings)
+	if action == scan.ActionReject {
+		reasons := scan.Reasons(findings)
+		s.recordAudit(client, audit.ActionPasteReject, reasons)
+		return "", &refusedError{"Content rejected: " + reasons}
+	}
+
+	paste := models.Paste{
+		Content:         content,
+		Language:        lang,
+		UUID:            uuid.New(),
+		ExpiryTimestamp: time.Now().Add(s.config.TCPUploadExpiry),
+		Visibility:      models.VisibilityUnlisted,
+		Quarantined:     action == scan.ActionQuarantine,
+	}
+	paste.Derive()
+
+	reservation, err := s.quota.Reserve("ip:"+client, paste.Size, time.Now())
+	var exceeded *quota.ExceededError
+	if errors.As(err, &exceeded) {
+		return "", &refusedError{fmt.Sprintf("%s, resets at %s", exceeded.Error(), exceeded.Reset.Format(time.RFC3339))}
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := storage.CreatePaste(s.db, &paste); err != nil {
+		if err := s.quota.Release(reservation); err != nil {
+			s.logger.Error("Error releasing paste quota", zap.Error(err))
+		}
+		return "", err
+	}
+	s.logger.Info("Paste uploaded over TCP", zap.String("uuid", paste.UUID.String()), zap.String("client", client))
+	if len(findings) > 0 {
+		s.recordFindings(client, paste.UUID, findings, action)
+	}
+	return strings.TrimRight(s.config.BaseURL, "/") + "/paste/" + paste.UUID.String(), nil
+}
+
+// abusive records the upload with the abuse detection and reports whether the
+// client is penalized
+func (s *Server) abusive(client, content string) bool {
+	now := time.Now()
+	if s.abuse.Penalized(client, now) {
+		return true
+	}
+	sum := sha256.Sum256([]byte(content))
+	// Connections carry no user agent, so don't score its absence
+	creation := abuse.Creation{UserAgent: "tcp", ContentHash: hex.EncodeToString(sum[:])}
+	signals, penalized := s.abuse.Observe(client, creation, now)
+	if len(signals) > 0 {
+		s.logger.Warn("Suspicious paste upload", zap.String("client", client), zap.Strings("signals", signals))
+	}
+	if penalized {
+		s.recordAudit(client, audit.ActionClientPenalized, strings.Join(signals, ","))
+	}
+	return penalized
+}
+
+// penalize answers an upload of a penalized client with the configured
+// ABUSE_ACTION, like the HTTP API does
+func (s *Server) penalize() (string, error) {
+	s.abuse.Blocked()
+	if s.config.AbuseAction == "shadowban" {
+		// Pretend the paste was created so the bot doesn't adapt
+		return strings.TrimRight(s.config.BaseURL, "/") + "/paste/" + uuid.New().String(), nil
+	}
+	select {
+	case <-time.After(s.config.AbuseTarpitDelay):
+	case <-s.closing:
+	}
+	return "", &refusedError{"Too many uploads, try again later"}
+}
+
+// recordFindings keeps the findings of a quarantined or flagged paste for
+// admin review
+func (s *Server) recordFindings(client string, paste uuid.UUID, findings []scan.Finding, action scan.Action) {
+	records := make([]models.ScanFinding, 0, len(findings))
+	for _, finding := range findings {
+		records = append(records, finding.Record(paste))
+	}
+	if err := storage.AddFindings(s.db, records); err != nil {
+		s.logger.Error("Error recording scan findings", zap.Error(err))
+	}
+	if action == scan.ActionQuarantine {
+		s.recordAudit(client, audit.ActionPasteQuarantine, paste.String())
+	} else {
+		s.recordAudit(client, audit.ActionPasteFlag, paste.String())
+	}
+}
+
+func (s *Server) recordAudit(client, action, target string) {
+	if err := audit.Record(s.db, action, client, target); err != nil {
+		s.logger.Error("Error recording audit event", zap.String("action", action), zap.Error(err))
+	}
+}
+
+// idleReader reads from a connection until it is idle for too long or the
+// deadline of the upload passes
+type idleReader struct {
+	conn     net.Conn
+	idle     time.Duration
+	deadline time.Time
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	deadline := time.Now().Add(r.idle)
+	if deadline.After(r.deadline) {
+		deadline = r.deadline
+	}
+	if err := r.conn.SetReadDeadline(deadline); err != nil {
+		return 0, err
+	}
+	return r.conn.Read(p)
+}
+
+// clientIP returns the IP address of the client of the connection
+func clientIP(conn net.Conn) string {
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return conn.RemoteAddr().String()
+	}
+	return host
+}
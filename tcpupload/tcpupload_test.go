@@ -0,0 +1,142 @@
+package tcpupload_test
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coolguy1771/wastebin/audit"
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/ipfilter"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/models"
+	"github.com/coolguy1771/wastebin/quota"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/coolguy1771/wastebin/tcpupload"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestUpload(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:tcpupload?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Migrate(db, log.Default()); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := config.Default()
+	conf.BaseURL = "https://paste.example.com/"
+	conf.MaxPasteSize = 16
+	conf.TCPUploadTimeout = 100 * time.Millisecond
+	quotas := quota.New(db, log.Default(), quota.Limits{}, quota.Limits{})
+	server := tcpupload.New(&conf, log.Default(), db, quotas, nil)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(listener) }()
+
+	upload := func(content string, closeWrite bool) string {
+		return send(t, listener.Addr().String(), content, closeWrite)
+	}
+
+	url := upload("Paste A\n", true)
+	if !strings.HasPrefix(url, "https://paste.example.com/paste/") {
+		t.Fatalf("expected the URL of the paste, got %q", url)
+	}
+	var paste models.Paste
+	if err := db.First(&paste, "uuid = ?", strings.TrimPrefix(url, "https://paste.example.com/paste/")).Error; err != nil {
+		t.Fatal(err)
+	}
+	if paste.Content != "Paste A\n" || paste.Visibility != models.VisibilityUnlisted || time.Until(paste.ExpiryTimestamp) < 23*time.Hour {
+		t.Errorf("unexpected paste %+v", paste)
+	}
+
+	// Clients that keep their side open are answered once they are idle
+	if url := upload("Paste B", false); !strings.HasPrefix(url, "https://paste.example.com/paste/") {
+		t.Errorf("expected the URL of the paste after the idle timeout, got %q", url)
+	}
+	if answer := upload("", true); answer != "Content cannot be empty" {
+		t.Errorf("unexpected answer to an empty upload %q", answer)
+	}
+	if answer := upload(strings.Repeat("a", 17), true); answer != "Content cannot be larger than 16 bytes" {
+		t.Errorf("unexpected answer to a large upload %q", answer)
+	}
+
+	// The quotas are shared with the HTTP API, which may change them
+	quotas.SetLimits(quota.Limits{Pastes: 1}, quota.Limits{})
+	if url := upload("Paste C", true); !strings.HasPrefix(url, "https://paste.example.com/paste/") {
+		t.Errorf("expected the URL of the paste within the quota, got %q", url)
+	}
+	if answer := upload("Paste D", true); !strings.Contains(answer, "quota exceeded") {
+		t.Errorf("unexpected answer to an upload over the quota %q", answer)
+	}
+
+	if err := server.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-served; err != tcpupload.ErrServerClosed {
+		t.Errorf("expected the server closed, got %v", err)
+	}
+}
+
+func TestUploadFilter(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:tcpupload_filter?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Migrate(db, log.Default()); err != nil {
+		t.Fatal(err)
+	}
+	filter, err := ipfilter.New("", "127.0.0.1/32", log.Default(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := config.Default()
+	conf.BaseURL = "https://paste.example.com/"
+	server := tcpupload.New(&conf, log.Default(), db, quota.New(db, log.Default(), quota.Limits{}, quota.Limits{}), nil)
+	server.SetFilter(filter)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	// Denied clients are refused before their upload is read
+	if answer := send(t, listener.Addr().String(), "", false); answer != "Forbidden" {
+		t.Errorf("unexpected answer to a denied client %q", answer)
+	}
+	var blocked int64
+	db.Model(&models.AuditEvent{}).Where("action = ?", audit.ActionRequestBlocked).Count(&blocked)
+	if blocked != 1 {
+		t.Errorf("expected the blocked upload audited, got %d events", blocked)
+	}
+}
+
+// send uploads content to the server at addr and returns its answer
+func send(t *testing.T, addr, content string, closeWrite bool) string {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := io.WriteString(conn, content); err != nil {
+		t.Fatal(err)
+	}
+	if closeWrite {
+		conn.(*net.TCPConn).CloseWrite()
+	}
+	answer, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(answer))
+}
@@ -0,0 +1,103 @@
+// Package thumbnail renders the small previews of image pastes shown in
+// listings
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+
+	// Decoders of the image formats thumbnails are rendered from
+	_ "image/gif"
+	_ "image/jpeg"
+)
+
+// MaxSize is the width and height in pixels thumbnails fit in
+const MaxSize = 256
+
+// maxPixels bounds the images decoded, so that small files declaring huge
+// dimensions don't exhaust the memory
+const maxPixels = 50_000_000
+
+// ErrTooLarge is returned for images with more than 50 million pixels
+var ErrTooLarge = errors.New("image too large for a thumbnail")
+
+// types are the MIME types of the images thumbnails are rendered from
+var types = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+}
+
+// Supported reports whether thumbnails are rendered from images of the MIME type
+func Supported(contentType string) bool {
+	return types[contentType]
+}
+
+// Generate renders a PNG thumbnail of the image fitting in MaxSize pixels.
+// Images already fitting are only converted to PNG.
+func Generate(data []byte) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, scale(src)); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// scale shrinks the image to fit in MaxSize pixels, averaging the pixels
+// every thumbnail pixel covers
+func scale(src image.Image) image.Image {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= MaxSize && h <= MaxSize {
+		return src
+	}
+	tw, th := MaxSize, MaxSize
+	if w > h {
+		th = max(1, h*MaxSize/w)
+	} else {
+		tw = max(1, w*MaxSize/h)
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := bounds.Min.Y+y*h/th, bounds.Min.Y+(y+1)*h/th
+		for x := 0; x < tw; x++ {
+			x0, x1 := bounds.Min.X+x*w/tw, bounds.Min.X+(x+1)*w/tw
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBAModel.Convert(src.At(sx, sy)).(color.NRGBA)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					b += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: uint8(a / n)})
+		}
+	}
+	return dst
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,69 @@
+package thumbnail_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/coolguy1771/wastebin/thumbnail"
+)
+
+func encode(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGenerate(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 1024, 512))
+	for y := 0; y < 512; y++ {
+		for x := 0; x < 1024; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: 200, A: 255})
+		}
+	}
+	data, err := thumbnail.Generate(encode(t, src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumb, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := thumb.Bounds().Size(); size.X != thumbnail.MaxSize || size.Y != thumbnail.MaxSize/2 {
+		t.Errorf("expected the thumbnail to keep the aspect ratio, got %v", size)
+	}
+	if r, _, _, _ := thumb.At(10, 10).RGBA(); r>>8 != 200 {
+		t.Errorf("expected the colors kept, got red %d", r>>8)
+	}
+
+	small := image.NewGray(image.Rect(0, 0, 10, 20))
+	data, err = thumbnail.Generate(encode(t, small))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config, err := png.DecodeConfig(bytes.NewReader(data)); err != nil || config.Width != 10 || config.Height != 20 {
+		t.Errorf("expected small images kept at their size, got %+v, %v", config, err)
+	}
+
+	if _, err := thumbnail.Generate([]byte("not an image")); err == nil {
+		t.Error("expected an error for content that isn't an image")
+	}
+}
+
+func TestSupported(t *testing.T) {
+	for contentType, want := range map[string]bool{
+		"image/png":     true,
+		"image/jpeg":    true,
+		"image/svg+xml": false,
+		"text/plain":    false,
+	} {
+		if got := thumbnail.Supported(contentType); got != want {
+			t.Errorf("Supported(%q) = %v, want %v", contentType, got, want)
+		}
+	}
+}
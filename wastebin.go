@@ -0,0 +1,592 @@
+// Package wastebin embeds the wastebin paste service in other Go programs.
+//
+// New sets up the paste API on its own fiber app using the given
+// configuration, which can be served directly or mounted behind another
+// router through the net/http Handler:
+//
+//	conf := config.Default()
+//	conf.LocalDB = true
+//
+//	wb, err := wastebin.New(&conf, wastebin.Options{DisableUI: true})
+//	if err != nil {
+//		return err
+//	}
+//	defer wb.Close()
+//
+//	mux.Handle("/paste/", requireAuth(wb.Handler()))
+//
+// Every Wastebin uses only the configuration, logger and database it was
+// created with, so several can run in the same process.
+package wastebin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/coolguy1771/wastebin/abuse"
+	"github.com/coolguy1771/wastebin/alert"
+	"github.com/coolguy1771/wastebin/audit"
+	"github.com/coolguy1771/wastebin/breaker"
+	"github.com/coolguy1771/wastebin/captcha"
+	"github.com/coolguy1771/wastebin/clientip"
+	"github.com/coolguy1771/wastebin/config"
+	"github.com/coolguy1771/wastebin/errorsink"
+	"github.com/coolguy1771/wastebin/grpcapi"
+	"github.com/coolguy1771/wastebin/handlers"
+	"github.com/coolguy1771/wastebin/health"
+	"github.com/coolguy1771/wastebin/ipfilter"
+	"github.com/coolguy1771/wastebin/loadshed"
+	"github.com/coolguy1771/wastebin/log"
+	"github.com/coolguy1771/wastebin/outbox"
+	"github.com/coolguy1771/wastebin/quota"
+	"github.com/coolguy1771/wastebin/ratelimit"
+	"github.com/coolguy1771/wastebin/routes"
+	"github.com/coolguy1771/wastebin/scan"
+	"github.com/coolguy1771/wastebin/storage"
+	"github.com/coolguy1771/wastebin/tcpupload"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/valyala/fasthttp"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
+)
+
+// Options customize a Wastebin beyond its configuration
+type Options struct {
+	// Logger is used instead of the package level logger
+	Logger *log.Logger
+	// DB is used instead of connecting to the configured database. It is
+	// migrated but not closed by Close.
+	DB *gorm.DB
+	// DisableUI skips serving the web frontend so only the API is exposed
+	DisableUI bool
+	// ErrorSink receives the logged errors, panics and 5xx responses instead
+	// of the configured Sentry project. It is not closed by Close.
+	ErrorSink errorsink.Sink
+	// Scanner checks the content of new pastes after the configured scanners
+	Scanner scan.Scanner
+	// EventSink receives the events of the pastes, such as a queue, instead
+	// of the configured webhook and message broker
+	EventSink outbox.Sink
+	// AlertChannel receives the moderation and abuse alerts as well as the
+	// configured channels
+	AlertChannel alert.Channel
+}
+
+// errorSinkFlushTimeout bounds the time Close waits for the queued errors to
+// be reported
+const errorSinkFlushTimeout = 5 * time.Second
+
+// Wastebin is an embeddable instance of the paste service
+type Wastebin struct {
+	config  *config.Config
+	logger  *log.Logger
+	db      *gorm.DB
+	ownsDB  bool
+	redis   *redis.Client
+	app     *fiber.App
+	handler *handlers.Handler
+	limiter *ratelimit.Limiter
+	grpc    *grpc.Server
+	tcp     *tcpupload.Server
+	breaker *breaker.Breaker
+	health  *health.Registry
+	outbox  *outbox.Dispatcher
+	// eventBroker is the connection to the configured message broker
+	eventBroker io.Closer
+	alerts      *alert.Notifier
+
+	errorSink     errorsink.Sink
+	ownsErrorSink bool
+}
+
+// New connects to and migrates the database and sets up the routes
+func New(conf *config.Config, opts Options) (_ *Wastebin, err error) {
+	w := &Wastebin{
+		config: conf,
+		logger: opts.Logger,
+		db:     opts.DB,
+	}
+	if w.logger == nil {
+		w.logger = log.Default()
+	}
+
+	// Release what was already set up when a later step fails
+	defer func() {
+		if err != nil {
+			w.closeClients()
+		}
+	}()
+
+	// Report the logged errors when an error sink is configured
+	w.errorSink = opts.ErrorSink
+	if w.errorSink == nil && conf.SentryDSN != "" {
+		w.errorSink, err = errorsink.NewSentry(conf.SentryDSN, conf.SentryEnvironment, w.logger)
+		if err != nil {
+			return nil, err
+		}
+		w.ownsErrorSink = true
+	}
+	if w.errorSink != nil {
+		w.logger = w.logger.WithErrorHook(errorsink.LogHook(w.errorSink))
+	}
+
+	resolver, err := clientip.New(conf.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := ratelimit.ParseRules(conf.RateLimitRoutes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Share the rate limits between instances when Redis is configured
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if conf.RedisAddr != "" {
+		w.redis = redis.NewClient(&redis.Options{
+			Addr:     conf.RedisAddr,
+			Password: conf.RedisPassword,
+		})
+		store = ratelimit.NewRedisStore(w.redis)
+		w.logger.Info("Using Redis for rate limiting", zap.String("addr", conf.RedisAddr))
+	}
+	w.limiter = ratelimit.New(store, ratelimit.Limit{
+		PerMinute: conf.RateLimitPerMinute,
+		Burst:     conf.RateLimitBurst,
+	}, rules, w.logger)
+
+	if w.db == nil {
+		w.db, err = storage.Connect(conf, w.logger)
+		if err != nil {
+			return nil, err
+		}
+		w.ownsDB = true
+	}
+
+	if err := storage.Migrate(w.db, w.logger); err != nil {
+		return nil, err
+	}
+
+	// Fail the queries fast while the database is down
+	w.breaker = breaker.New(breaker.Config{
+		Threshold: conf.DBBreakerThreshold,
+		Cooldown:  conf.DBBreakerCooldown,
+		Probe: func(ctx context.Context) error {
+			return storage.Ping(ctx, w.db)
+		},
+	}, w.logger)
+	if err := w.breaker.Register(w.db); err != nil {
+		return nil, err
+	}
+	// Keep slow queries from holding the handlers and background tasks
+	if err := storage.RegisterQueryTimeout(w.db, conf.DBQueryTimeout); err != nil {
+		return nil, err
+	}
+
+	filter, err := ipfilter.New(conf.IPAllowlist, conf.IPDenylist, w.logger, w.db)
+	if err != nil {
+		return nil, err
+	}
+
+	// Leave room for the form encoding of the largest paste or attachment
+	bodyLimit := 3 * conf.MaxPasteSize
+	if conf.MaxAttachmentSize > conf.MaxPasteSize {
+		bodyLimit = 3 * conf.MaxAttachmentSize
+	}
+
+	// Create new fiber instance
+	w.app = fiber.New(fiber.Config{
+		Prefork:               false,
+		CaseSensitive:         true,
+		StrictRouting:         false,
+		ServerHeader:          "Fiber",
+		AppName:               "Wastebin",
+		DisableStartupMessage: true,
+		BodyLimit:             bodyLimit,
+		ErrorHandler:          handlers.ErrorHandler,
+	})
+
+	scanner, err := newScanner(conf, opts.Scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	w.handler = handlers.New(conf, w.logger, w.db)
+	if w.errorSink != nil {
+		w.handler.SetErrorSink(w.errorSink)
+	}
+	if scanner != nil {
+		w.handler.SetScanner(scanner)
+	}
+	if conf.DBBreakerThreshold > 0 {
+		w.handler.SetBreaker(w.breaker)
+	}
+	var detector *abuse.Detector
+	if conf.AbuseDetection {
+		detector = abuse.New(abuse.Config{
+			BurstLimit:     conf.AbuseBurstLimit,
+			DuplicateLimit: conf.AbuseDuplicateLimit,
+			Threshold:      conf.AbuseThreshold,
+			Window:         conf.AbuseWindow,
+			BanDuration:    conf.AbuseBanDuration,
+		})
+		w.handler.SetAbuseDetector(detector)
+	}
+	if conf.CaptchaProvider != "" {
+		verifier, err := captcha.New(conf.CaptchaProvider, conf.CaptchaSiteKey, conf.CaptchaSecretKey, conf.CaptchaVerifyURL)
+		if err != nil {
+			return nil, err
+		}
+		w.handler.SetCaptcha(verifier)
+	}
+	w.limiter.OnLimited(w.handler.RecordRateLimited)
+
+	// Load routes
+	routes.AddRoutes(w.app, w.handler, routes.Middleware{
+		ClientIP: resolver,
+		Filter:   filter,
+		Limiter:  w.limiter,
+		Shedder: loadshed.New(loadshed.Config{
+			MaxInFlight:  conf.MaxConcurrentRequests,
+			QueueDepth:   conf.RequestQueueDepth,
+			QueueTimeout: conf.RequestQueueTimeout,
+		}),
+		Breaker: w.breaker,
+	})
+	if !opts.DisableUI {
+		routes.AddUIRoutes(w.app, w.handler, conf)
+	}
+	w.registerHealthChecks()
+	w.handler.SetHealth(w.health)
+	w.grpc = grpcapi.NewServer(conf, w.logger, w.db, w.handler.Quota(), scanner)
+	w.tcp = tcpupload.New(conf, w.logger, w.db, w.handler.Quota(), scanner)
+	w.tcp.SetFilter(filter)
+	if detector != nil {
+		w.tcp.SetAbuseDetector(detector)
+	}
+
+	// Deliver the events of the pastes, or only prune them without a sink
+	sink := opts.EventSink
+	if sink == nil {
+		if sink, err = w.newEventSink(); err != nil {
+			return nil, err
+		}
+	}
+	w.outbox = outbox.New(w.db, sink, outbox.Config{
+		Interval:  conf.OutboxPollInterval,
+		Timeout:   conf.WebhookTimeout,
+		Retention: conf.OutboxRetention,
+	}, w.logger)
+	w.outbox.Start()
+	w.handler.SetOutbox(w.outbox)
+
+	// Alert the moderators on their chat channels
+	if channels := w.alertChannels(opts.AlertChannel); len(channels) > 0 {
+		w.alerts = alert.New(w.logger, channels...)
+		w.alerts.Start()
+		w.handler.SetAlerts(w.alerts)
+	}
+	w.handler.MarkStarted()
+
+	return w, nil
+}
+
+// alertChannels returns the configured alert channels followed by extra
+func (w *Wastebin) alertChannels(extra alert.Channel) []alert.Channel {
+	var channels []alert.Channel
+	if w.config.AlertSlackWebhookURL != "" {
+		channels = append(channels, alert.NewSlack(w.config.AlertSlackWebhookURL))
+	}
+	if w.config.AlertDiscordWebhookURL != "" {
+		channels = append(channels, alert.NewDiscord(w.config.AlertDiscordWebhookURL))
+	}
+	if w.config.AlertMatrixHomeserver != "" {
+		channels = append(channels, alert.NewMatrix(w.config.AlertMatrixHomeserver, w.config.AlertMatrixRoom, w.config.AlertMatrixToken))
+	}
+	if extra != nil {
+		channels = append(channels, extra)
+	}
+	return channels
+}
+
+// newEventSink returns the configured webhook and message broker, nil when
+// there are none
+func (w *Wastebin) newEventSink() (outbox.Sink, error) {
+	var sinks outbox.Sinks
+	if w.config.WebhookURL != "" {
+		sinks = append(sinks, outbox.NewWebhook(w.config.WebhookURL, w.config.WebhookSecret))
+	}
+	switch w.config.EventBroker {
+	case "nats":
+		broker, err := outbox.NewNATS(w.config.EventBrokerURL, w.config.EventBrokerTopic)
+		if err != nil {
+			return nil, err
+		}
+		w.eventBroker = broker
+		sinks = append(sinks, broker)
+	case "kafka":
+		sinks = append(sinks, outbox.NewKafka(w.config.EventBrokerURL, w.config.EventBrokerTopic))
+	}
+	switch len(sinks) {
+	case 0:
+		return nil, nil
+	case 1:
+		return sinks[0], nil
+	}
+	return sinks, nil
+}
+
+// newScanner returns the content scanners configured in conf followed by
+// extra, nil when there are none
+func newScanner(conf *config.Config, extra scan.Scanner) (scan.Scanner, error) {
+	var chain scan.Chain
+	action := scan.Action(conf.ScanAction)
+	if strings.TrimSpace(conf.ScanBlocklist) != "" {
+		blocklist, err := scan.NewBlocklist(conf.ScanBlocklist, action)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, blocklist)
+	}
+	if conf.ScanSecrets {
+		secretsAction := action
+		if conf.ScanSecretsAction != "" {
+			secretsAction = scan.Action(conf.ScanSecretsAction)
+		}
+		chain = append(chain, scan.NewSecrets(secretsAction))
+	}
+	if conf.ScanURL != "" {
+		chain = append(chain, scan.NewHTTP(conf.ScanURL, conf.ScanTimeout, action))
+	}
+	if extra != nil {
+		chain = append(chain, extra)
+	}
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return chain, nil
+}
+
+// App returns the fiber app serving the routes
+func (w *Wastebin) App() *fiber.App {
+	return w.app
+}
+
+// Handler returns the routes as a net/http Handler
+func (w *Wastebin) Handler() http.Handler {
+	handler := w.app.Handler()
+	limit := int64(w.app.Config().BodyLimit)
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var req fasthttp.Request
+		body, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, limit))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(rw, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		req.Header.SetMethod(r.Method)
+		req.SetRequestURI(r.URL.RequestURI())
+		req.Header.SetHost(r.Host)
+		for key, values := range r.Header {
+			for _, value := range values {
+				req.Header.Add(key, value)
+			}
+		}
+		req.SetBody(body)
+
+		var remote net.Addr
+		if addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr); err == nil {
+			remote = addr
+		}
+		var ctx fasthttp.RequestCtx
+		ctx.Init(&req, remote, nil)
+		handler(&ctx)
+
+		resp := &ctx.Response
+		stream := resp.IsBodyStream()
+		resp.Header.VisitAll(func(key, value []byte) {
+			switch k := string(key); {
+			case strings.EqualFold(k, fasthttp.HeaderConnection), strings.EqualFold(k, fasthttp.HeaderTransferEncoding):
+			case stream && strings.EqualFold(k, fasthttp.HeaderContentLength):
+			default:
+				rw.Header().Add(k, string(value))
+			}
+		})
+		rw.WriteHeader(resp.StatusCode())
+		// Streamed bodies, such as the events of a paste, are sent as they
+		// are written
+		resp.BodyWriteTo(flushWriter{rw})
+	})
+}
+
+// flushWriter flushes every write to the client
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if flusher, ok := f.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
+
+// GRPCServer returns the gRPC server of the paste API, which callers serve on
+// their own listener
+func (w *Wastebin) GRPCServer() *grpc.Server {
+	return w.grpc
+}
+
+// TCPUploadServer returns the server creating pastes from plain TCP
+// connections, which callers serve on their own listener
+func (w *Wastebin) TCPUploadServer() *tcpupload.Server {
+	return w.tcp
+}
+
+// Health returns the registry of the checks of the dependencies of the
+// server, to which embedding programs can add their own
+func (w *Wastebin) Health() *health.Registry {
+	return w.health
+}
+
+// HealthCheck runs the checks of the dependencies of the server, by default
+// that the database is reachable and migrated and that Redis, when
+// configured, is reachable. It returns the result of every check by name.
+func (w *Wastebin) HealthCheck(ctx context.Context) map[string]health.Result {
+	return w.health.Check(ctx)
+}
+
+// registerHealthChecks registers the checks of the dependencies of the server
+func (w *Wastebin) registerHealthChecks() {
+	w.health = health.New()
+	w.health.Register("database", time.Second, func(ctx context.Context) error {
+		return storage.Ping(ctx, w.db)
+	})
+	w.health.Register("migrations", time.Second, func(ctx context.Context) error {
+		return storage.CheckMigrated(w.db.WithContext(ctx))
+	})
+	if w.redis != nil {
+		w.health.Register("redis", 500*time.Millisecond, func(ctx context.Context) error {
+			return w.redis.Ping(ctx).Err()
+		})
+	}
+}
+
+// DBStats returns the statistics of the database connection pool
+func (w *Wastebin) DBStats() (sql.DBStats, error) {
+	sqlDB, err := w.db.DB()
+	if err != nil {
+		return sql.DBStats{}, err
+	}
+	return sqlDB.Stats(), nil
+}
+
+// SetAllowedOrigins replaces the origins allowed to make CORS requests
+func (w *Wastebin) SetAllowedOrigins(origins string) {
+	w.handler.SetAllowedOrigins(origins)
+}
+
+// Reload applies the settings of conf that can be changed at runtime, the
+// allowed origins, rate limits and quotas, and records the names of the
+// changed settings in the audit log. The other settings are ignored.
+func (w *Wastebin) Reload(conf config.Config, changed []string) error {
+	rules, err := ratelimit.ParseRules(conf.RateLimitRoutes)
+	if err != nil {
+		return err
+	}
+	w.handler.SetAllowedOrigins(conf.AllowedOrigins)
+	w.limiter.SetLimits(ratelimit.Limit{
+		PerMinute: conf.RateLimitPerMinute,
+		Burst:     conf.RateLimitBurst,
+	}, rules)
+	w.handler.SetQuotas(
+		quota.Limits{Pastes: conf.QuotaHourlyPastes, Bytes: conf.QuotaHourlyBytes},
+		quota.Limits{Pastes: conf.QuotaDailyPastes, Bytes: conf.QuotaDailyBytes},
+	)
+
+	host, _ := os.Hostname()
+	return audit.Record(w.db, audit.ActionConfigReload, host, strings.Join(changed, ","))
+}
+
+// Close shuts down the fiber app and closes the database connection if it
+// was opened by New
+func (w *Wastebin) Close() error {
+	// End the event streams, which would never finish, then let the requests
+	// being handled finish within SHUTDOWN_TIMEOUT while refusing new ones
+	w.handler.Close()
+	ctx := context.Background()
+	if w.config.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.config.ShutdownTimeout)
+		defer cancel()
+	}
+	if running := w.handler.Shutdown(ctx); running > 0 {
+		w.logger.Warn("Shutting down with requests still running", zap.Int64("requests", running))
+	}
+	if err := w.shutdownApp(ctx); err != nil {
+		return err
+	}
+	w.grpc.GracefulStop()
+	w.tcp.Close()
+	return w.closeClients()
+}
+
+// shutdownApp closes the listeners and the idle connections of the app and
+// waits for the others to close until ctx is done
+func (w *Wastebin) shutdownApp(ctx context.Context) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return w.app.Shutdown()
+	}
+	err := w.app.ShutdownWithTimeout(time.Until(deadline))
+	if errors.Is(err, context.DeadlineExceeded) {
+		w.logger.Warn("Closing the server with connections still open")
+		return nil
+	}
+	return err
+}
+
+// closeClients closes the connections opened by New
+func (w *Wastebin) closeClients() error {
+	if w.outbox != nil {
+		w.outbox.Stop()
+	}
+	if w.alerts != nil {
+		w.alerts.Stop()
+	}
+	if w.eventBroker != nil {
+		if err := w.eventBroker.Close(); err != nil {
+			w.logger.Warn("Error closing the message broker connection", zap.Error(err))
+		}
+	}
+	if w.breaker != nil {
+		w.breaker.Stop()
+	}
+	// Close the error sink last so the errors closing the others are reported
+	if w.ownsErrorSink {
+		defer w.errorSink.Close(errorSinkFlushTimeout)
+	}
+	if w.redis != nil {
+		if err := w.redis.Close(); err != nil {
+			return err
+		}
+	}
+	if w.ownsDB && w.db != nil {
+		return storage.Close(w.db)
+	}
+	return nil
+}
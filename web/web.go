@@ -0,0 +1,36 @@
+// Package web holds the built frontend so that a single binary serves it
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+)
+
+// devBuild is where the frontend is built during development
+const devBuild = "./web/build"
+
+// build is the output of yarn build, which must run before go build for the
+// binary to serve the frontend
+//
+//go:embed all:build
+var build embed.FS
+
+// Favicon is the icon of the site, served even when the frontend isn't built
+//
+//go:embed static/favicon.ico
+var Favicon []byte
+
+// Files returns the built frontend. In development it is read from disk so
+// that rebuilds are served without compiling the binary again.
+func Files(dev bool) fs.FS {
+	if dev {
+		return os.DirFS(devBuild)
+	}
+	files, err := fs.Sub(build, "build")
+	if err != nil {
+		// build is a valid directory of the embedded files
+		panic(err)
+	}
+	return files
+}